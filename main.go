@@ -2,12 +2,15 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"postty/src/cli"
 	"postty/src/components"
 	"postty/src/handlers"
 	"postty/src/model"
+	"postty/src/services"
 	"postty/src/types"
 )
 
@@ -34,9 +37,21 @@ func (a app) Init() tea.Cmd {
 }
 
 func main() {
+	// Subcommands run without the TUI
+	if len(os.Args) > 1 && os.Args[1] == "request" {
+		os.Exit(cli.RunRequest(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		os.Exit(cli.RunSequence(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	a := app{model: model.New()}
 	p := tea.NewProgram(a, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error: %v", err)
 	}
+	// Cookies set by the last responses may still be waiting to be written
+	if err := services.SaveCookies(); err != nil {
+		fmt.Printf("Cookies: %v\n", err)
+	}
 }
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// grpcEnvelope is the JSON document printed for a gRPC call with --json
+type grpcEnvelope struct {
+	Status   string              `json:"status"`
+	Code     int                 `json:"code"`
+	Message  string              `json:"message,omitempty"`
+	Headers  map[string][]string `json:"headers,omitempty"`
+	Trailers map[string][]string `json:"trailers,omitempty"`
+	Messages []json.RawMessage   `json:"messages"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// grpcClientErrors are the status codes caused by the request rather than the server
+var grpcClientErrors = map[string]bool{
+	"INVALID_ARGUMENT":    true,
+	"NOT_FOUND":           true,
+	"ALREADY_EXISTS":      true,
+	"PERMISSION_DENIED":   true,
+	"FAILED_PRECONDITION": true,
+	"OUT_OF_RANGE":        true,
+	"UNAUTHENTICATED":     true,
+}
+
+// runGRPC calls a gRPC method and prints its response messages as they arrive
+func runGRPC(req types.Request, protoFiles, importPaths []string, stdout, stderr io.Writer, asJSON bool) int {
+	req.Method = types.GRPCMethod
+
+	var started types.GRPCStartedMsg
+	switch msg := services.CallGRPC(context.Background(), req, protoFiles, importPaths)().(type) {
+	case types.GRPCStartedMsg:
+		started = msg
+	case types.ResponseMsg:
+		if asJSON {
+			writeGRPCEnvelope(stdout, grpcEnvelope{Messages: []json.RawMessage{}, Error: msg.Err.Error()})
+		} else {
+			fmt.Fprintf(stderr, "postty: %v\n", msg.Err)
+		}
+		return ExitTransport
+	}
+
+	envelope := grpcEnvelope{Headers: headerMap(started.Headers), Messages: []json.RawMessage{}}
+	for reply := range started.Call.Replies() {
+		if asJSON {
+			envelope.Messages = append(envelope.Messages, json.RawMessage(reply.Message))
+		} else {
+			fmt.Fprintln(stdout, reply.Message)
+		}
+	}
+
+	result := started.Call.Result()
+	if asJSON {
+		envelope.Status = result.Code
+		envelope.Code = result.Number
+		envelope.Message = result.Message
+		envelope.Trailers = headerMap(result.Trailers)
+		writeGRPCEnvelope(stdout, envelope)
+	} else if result.Number != 0 {
+		fmt.Fprintf(stderr, "postty: %s: %s\n", result.Code, result.Message)
+	}
+
+	switch {
+	case result.Number == 0:
+		return ExitOK
+	case grpcClientErrors[result.Code]:
+		return ExitClientError
+	default:
+		return ExitServerError
+	}
+}
+
+// writeGRPCEnvelope prints the result of a gRPC call as an indented JSON document
+func writeGRPCEnvelope(w io.Writer, envelope grpcEnvelope) {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(envelope)
+}
+
+// headerMap groups header values by name, nil when there are none
+func headerMap(headers []types.Header) map[string][]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	grouped := map[string][]string{}
+	for _, h := range headers {
+		grouped[h.Key] = append(grouped[h.Key], h.Value)
+	}
+	return grouped
+}
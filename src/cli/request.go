@@ -0,0 +1,416 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// Exit codes returned by the request and run subcommands
+const (
+	ExitOK          = 0
+	ExitTransport   = 1 // The request could not be completed
+	ExitUsage       = 2 // Invalid flags or input
+	ExitFailed      = 3 // A request of a sequence run failed
+	ExitClientError = 4 // The server answered with a 4xx status
+	ExitServerError = 5 // The server answered with a 5xx status
+)
+
+// headerFlags collects repeated -H values
+type headerFlags []types.Header
+
+// String implements flag.Value
+func (h *headerFlags) String() string {
+	parts := make([]string, len(*h))
+	for i, header := range *h {
+		parts[i] = header.Key + ": " + header.Value
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Set implements flag.Value
+func (h *headerFlags) Set(value string) error {
+	key, val, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("header %q must look like \"Name: value\"", value)
+	}
+	*h = append(*h, types.Header{Key: strings.TrimSpace(key), Value: strings.TrimSpace(val)})
+	return nil
+}
+
+// formFlags collects repeated -F values
+type formFlags []types.FormField
+
+// String implements flag.Value
+func (f *formFlags) String() string {
+	parts := make([]string, len(*f))
+	for i, field := range *f {
+		parts[i] = services.FormFieldSpec(field)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Set implements flag.Value
+func (f *formFlags) Set(value string) error {
+	field, err := services.ParseFormField(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, field)
+	return nil
+}
+
+// listFlags collects repeated string values
+type listFlags []string
+
+// String implements flag.Value
+func (l *listFlags) String() string {
+	return strings.Join(*l, ", ")
+}
+
+// Set implements flag.Value
+func (l *listFlags) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// responseEnvelope is the JSON document printed with --json
+type responseEnvelope struct {
+	Status     int                 `json:"status,omitempty"`
+	StatusText string              `json:"status_text,omitempty"`
+	Proto      string              `json:"proto,omitempty"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+	TimeMS     float64             `json:"time_ms"`
+	Size       int64               `json:"size"`
+	Body       string              `json:"body"`
+	TLS        *types.TLSInfo      `json:"tls,omitempty"`
+	Proxy      string              `json:"proxy,omitempty"`
+	URL        string              `json:"url,omitempty"`       // Final URL, when redirects were followed
+	Redirects  []redirectEnvelope  `json:"redirects,omitempty"` // Redirects followed, in order
+	Outcome    string              `json:"outcome,omitempty"`
+	Error      string              `json:"error,omitempty"`
+}
+
+// redirectEnvelope is the --json form of a redirect followed on the way to the response
+type redirectEnvelope struct {
+	Status     int     `json:"status"`
+	StatusText string  `json:"status_text"`
+	Method     string  `json:"method"`
+	URL        string  `json:"url"`
+	Location   string  `json:"location"`
+	TimeMS     float64 `json:"time_ms"`
+}
+
+// streamEvent is the --json form of one Server-Sent Event, printed one per line
+type streamEvent struct {
+	ID    string `json:"id,omitempty"`
+	Event string `json:"event"`
+	Data  string `json:"data"`
+	Retry int    `json:"retry,omitempty"`
+	Time  string `json:"time"`
+}
+
+// RunRequest implements "postty request": it sends a single request without the TUI,
+// prints the response and returns the process exit code
+func RunRequest(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("postty request", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var headers headerFlags
+	var form formFlags
+	var protoFiles, importPaths, caFiles listFlags
+	method := fs.String("X", "", "HTTP method, or GRPC (default GET, or POST with a body)")
+	fs.StringVar(method, "method", "", "HTTP method, or GRPC (default GET, or POST with a body)")
+	url := fs.String("url", "", "request URL (may also be given as an argument)")
+	fs.Var(&headers, "H", "header \"Name: value\" (repeatable)")
+	fs.Var(&headers, "header", "header \"Name: value\" (repeatable)")
+	data := fs.String("d", "", "request body; @path reads a file, @- reads stdin")
+	fs.StringVar(data, "data", "", "request body; @path reads a file, @- reads stdin")
+	dataFile := fs.String("data-file", "", "read the request body from a file, - for stdin")
+	fs.Var(&form, "F", "form field name=value or name=@path[;type=...] (repeatable, implies multipart)")
+	fs.Var(&form, "form", "form field name=value or name=@path[;type=...] (repeatable, implies multipart)")
+	contentType := fs.String("content-type", "", "Content-Type of the body (default application/json, or multipart/form-data with -F)")
+	envName := fs.String("env", "", "environment used to resolve {{variables}}")
+	timeout := fs.Int("timeout", -1, "timeout in seconds, 0 for none (default from config)")
+	asJSON := fs.Bool("json", false, "print a JSON envelope with status, headers, timing and body")
+	fs.Var(&protoFiles, "proto", ".proto file describing gRPC methods (repeatable, default proto_files from config)")
+	fs.Var(&importPaths, "import-path", "directory .proto imports are resolved from (repeatable)")
+	insecure := fs.Bool("k", false, "skip verifying the server's TLS certificate")
+	fs.BoolVar(insecure, "insecure", false, "skip verifying the server's TLS certificate")
+	certFile := fs.String("cert", "", "client certificate file, PEM or PKCS#12")
+	keyFile := fs.String("key", "", "PEM private key of --cert, when not in the certificate file")
+	certPassword := fs.String("pass", "", "password of a PKCS#12 --cert")
+	fs.Var(&caFiles, "cacert", "PEM CA bundle trusted on top of the system roots (repeatable)")
+	serverName := fs.String("servername", "", "TLS server name (SNI) to use instead of the URL host")
+	minTLS := fs.String("tls-min", "", "lowest TLS version to offer: 1.0, 1.1, 1.2 or 1.3")
+	proxy := fs.String("x", "", "proxy URL, http://, https:// or socks5://, or direct (default from config and HTTP_PROXY)")
+	fs.StringVar(proxy, "proxy", "", "proxy URL, http://, https:// or socks5://, or direct (default from config and HTTP_PROXY)")
+	noProxy := fs.String("noproxy", "", "comma-separated hosts, domains and CIDRs reached without --proxy")
+	maxRedirects := fs.Int("max-redirs", -1, "redirects followed at most, 0 to print the first redirect response (default 10)")
+	keepMethod := fs.Bool("keep-method", false, "resend the method and body on 301 and 302 redirects too")
+
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: postty request [flags] <url>")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	// Allow flags before and after the URL
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return ExitOK
+			}
+			return ExitUsage
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if *url == "" && len(positional) > 0 {
+		*url = positional[0]
+		positional = positional[1:]
+	}
+	if *url == "" || len(positional) > 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	body, err := readBody(*data, *dataFile, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "postty: %v\n", err)
+		return ExitUsage
+	}
+
+	if len(form) > 0 && body != "" {
+		fmt.Fprintln(stderr, "postty: use either --data or --form, not both")
+		return ExitUsage
+	}
+	if *contentType == "" {
+		*contentType = types.ContentTypes[0]
+		if len(form) > 0 {
+			*contentType = services.FormMultipart
+		}
+	}
+	if len(form) > 0 && !services.IsFormContentType(*contentType) {
+		fmt.Fprintf(stderr, "postty: --form needs %s or %s\n", services.FormMultipart, services.FormURLEncoded)
+		return ExitUsage
+	}
+
+	if *method == "" {
+		*method = "GET"
+		if body != "" || len(form) > 0 {
+			*method = "POST"
+		}
+	}
+
+	config, err := services.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(stderr, "postty: config: %v\n", err)
+	}
+
+	req := types.Request{
+		Method:      strings.ToUpper(*method),
+		URL:         *url,
+		Body:        body,
+		ContentType: *contentType,
+		Headers:     headers,
+		Form:        form,
+		TLS: types.TLSSettings{
+			CertFile:     *certFile,
+			KeyFile:      *keyFile,
+			CertPassword: *certPassword,
+			CAFiles:      caFiles,
+			Insecure:     *insecure,
+			ServerName:   *serverName,
+			MinVersion:   *minTLS,
+		},
+		Redirects: types.RedirectPolicy{
+			NoFollow:   *maxRedirects == 0,
+			Max:        max(*maxRedirects, 0),
+			KeepMethod: *keepMethod,
+		},
+	}
+
+	if *envName != "" {
+		variables, err := environmentVariables(config, *envName)
+		if err != nil {
+			fmt.Fprintf(stderr, "postty: %v\n", err)
+			return ExitUsage
+		}
+		var unresolved []string
+		req, unresolved = services.InterpolateRequest(req, variables)
+		if len(unresolved) > 0 {
+			fmt.Fprintf(stderr, "postty: unresolved variables: %s\n", strings.Join(unresolved, ", "))
+			return ExitUsage
+		}
+	}
+
+	seconds := config.RequestTimeout
+	if *timeout >= 0 {
+		seconds = *timeout
+	}
+	req.Timeout = seconds
+	req.TLS = services.MergeTLS(config.TLS, req.TLS)
+	req.Proxy = services.ProxySettingsFor(config, *envName)
+	if *proxy != "" {
+		req.Proxy = types.ProxySettings{URL: *proxy, NoProxy: *noProxy}
+	}
+
+	if strings.EqualFold(req.Method, types.GRPCMethod) {
+		if len(protoFiles) == 0 {
+			protoFiles, importPaths = config.ProtoFiles, append(importPaths, config.ProtoImportPaths...)
+		}
+		return runGRPC(req, protoFiles, importPaths, stdout, stderr, *asJSON)
+	}
+
+	var msg types.ResponseMsg
+	switch result := services.ExecuteRequest(context.Background(), req)().(type) {
+	case types.StreamStartedMsg:
+		return writeStream(stdout, stderr, result, *asJSON)
+	case types.ResponseMsg:
+		msg = result
+	}
+
+	if *asJSON {
+		writeEnvelope(stdout, msg)
+	} else if msg.Err != nil {
+		fmt.Fprintf(stderr, "postty: %v\n", msg.Err)
+	} else {
+		fmt.Fprint(stdout, msg.Body)
+		if msg.Body != "" && !strings.HasSuffix(msg.Body, "\n") {
+			fmt.Fprintln(stdout)
+		}
+	}
+
+	return exitCode(msg)
+}
+
+// readBody resolves the body from --data or --data-file
+func readBody(data, dataFile string, stdin io.Reader) (string, error) {
+	if data != "" && dataFile != "" {
+		return "", fmt.Errorf("use either --data or --data-file, not both")
+	}
+	if strings.HasPrefix(data, "@") {
+		dataFile = data[1:]
+		data = ""
+	}
+	if dataFile == "" {
+		return data, nil
+	}
+
+	var content []byte
+	var err error
+	if dataFile == "-" {
+		content, err = io.ReadAll(stdin)
+	} else {
+		content, err = os.ReadFile(dataFile)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// environmentVariables loads the variables of the named environment
+func environmentVariables(config types.Config, name string) (map[string]string, error) {
+	environments, err := services.LoadEnvironments(config.EnvironmentsDir)
+	for _, env := range environments {
+		if env.Name == name {
+			return env.Variables, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("environment %q not found in %s", name, config.EnvironmentsDir)
+}
+
+// writeEnvelope prints the response as an indented JSON document
+func writeEnvelope(w io.Writer, msg types.ResponseMsg) {
+	envelope := responseEnvelope{
+		Status:     msg.StatusCode,
+		StatusText: msg.Meta.Status,
+		Proto:      msg.Meta.Proto,
+		TimeMS:     float64(msg.Meta.Duration.Microseconds()) / 1000,
+		Size:       msg.Meta.BodySize,
+		Body:       msg.Body,
+		TLS:        msg.Meta.TLS,
+		Proxy:      msg.Meta.Proxy,
+		URL:        msg.Meta.URL,
+		Outcome:    string(msg.Outcome),
+	}
+	for _, hop := range msg.Meta.Redirects {
+		envelope.Redirects = append(envelope.Redirects, redirectEnvelope{
+			Status:     hop.StatusCode,
+			StatusText: hop.Status,
+			Method:     hop.Method,
+			URL:        hop.URL,
+			Location:   hop.Location,
+			TimeMS:     float64(hop.Duration.Microseconds()) / 1000,
+		})
+	}
+	if msg.Err != nil {
+		envelope.Error = msg.Err.Error()
+	}
+	envelope.Headers = headerMap(msg.Headers)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(envelope)
+}
+
+// writeStream prints the events of a Server-Sent Events response as they arrive: the data
+// of each event followed by a blank line, or one JSON object per line with --json
+func writeStream(stdout, stderr io.Writer, started types.StreamStartedMsg, asJSON bool) int {
+	encoder := json.NewEncoder(stdout)
+	encoder.SetEscapeHTML(false)
+
+	for event := range started.Stream.Events() {
+		if asJSON {
+			encoder.Encode(streamEvent{
+				ID:    event.ID,
+				Event: event.Event,
+				Data:  event.Data,
+				Retry: event.Retry,
+				Time:  event.Time.Format(time.RFC3339Nano),
+			})
+		} else {
+			fmt.Fprintf(stdout, "%s\n\n", event.Data)
+		}
+	}
+
+	if err := started.Stream.Err(); err != nil {
+		fmt.Fprintf(stderr, "postty: %v\n", err)
+		return ExitTransport
+	}
+	return exitCode(types.ResponseMsg{StatusCode: started.StatusCode})
+}
+
+// exitCode maps a response to the process exit code
+func exitCode(msg types.ResponseMsg) int {
+	switch {
+	case msg.Err != nil:
+		return ExitTransport
+	case msg.StatusCode >= 500:
+		return ExitServerError
+	case msg.StatusCode >= 400:
+		return ExitClientError
+	default:
+		return ExitOK
+	}
+}
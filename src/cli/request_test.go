@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// echoServer answers /status/N with that status and describes every other request it gets
+// as JSON: {"method", "content_type", "x_a", "body"}. /moved redirects to /.
+func echoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var status int
+		if _, err := fmt.Sscanf(r.URL.Path, "/status/%d", &status); err == nil {
+			w.WriteHeader(status)
+			return
+		}
+		if r.URL.Path == "/moved" {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Echo", "yes")
+		json.NewEncoder(w).Encode(map[string]string{
+			"method":       r.Method,
+			"content_type": r.Header.Get("Content-Type"),
+			"x_a":          r.Header.Get("X-A"),
+			"body":         string(body),
+		})
+	}))
+}
+
+// isolate points the config and data directories at a temporary directory and returns
+// the directory environments are read from
+func isolate(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("HTTP_PROXY", "")
+	environments := filepath.Join(dir, "environments")
+	os.MkdirAll(filepath.Join(dir, "config", "postty"), 0o755)
+	os.MkdirAll(environments, 0o755)
+	config, _ := json.Marshal(map[string]string{"environments_dir": environments})
+	os.WriteFile(filepath.Join(dir, "config", "postty", "config.json"), config, 0o644)
+	return environments
+}
+
+// runRequest runs "postty request" with args and returns its exit code and output
+func runRequest(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := RunRequest(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunRequestExitCodes(t *testing.T) {
+	isolate(t)
+	server := echoServer()
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"ok", []string{server.URL}, ExitOK},
+		{"help", []string{"-h"}, ExitOK},
+		{"connection refused", []string{"http://127.0.0.1:1/"}, ExitTransport},
+		{"no URL", []string{"-X", "GET"}, ExitUsage},
+		{"two URLs", []string{server.URL, server.URL}, ExitUsage},
+		{"unknown flag", []string{"--nope", server.URL}, ExitUsage},
+		{"bad header", []string{"-H", "nocolon", server.URL}, ExitUsage},
+		{"data and form", []string{"-d", "x", "-F", "a=1", server.URL}, ExitUsage},
+		{"form as JSON", []string{"-F", "a=1", "--content-type", "application/json", server.URL}, ExitUsage},
+		{"missing body file", []string{"-d", "@" + filepath.Join(t.TempDir(), "nope"), server.URL}, ExitUsage},
+		{"unknown environment", []string{"--env", "nope", server.URL}, ExitUsage},
+		{"client error", []string{server.URL + "/status/404"}, ExitClientError},
+		{"server error", []string{server.URL + "/status/503"}, ExitServerError},
+	}
+	for _, tt := range tests {
+		if code, _, stderr := runRequest("", tt.args...); code != tt.want {
+			t.Errorf("%s: got exit code %d, want %d (%s)", tt.name, code, tt.want, stderr)
+		}
+	}
+}
+
+func TestRunSequenceExitCodes(t *testing.T) {
+	isolate(t)
+	server := echoServer()
+	defer server.Close()
+
+	dir := t.TempDir()
+	write := func(name, url string) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(fmt.Sprintf(`{"name":%q,"requests":[{"method":"GET","url":%q}]}`, name, url)), 0o644)
+		return path
+	}
+	var stdout, stderr bytes.Buffer
+	if code := RunSequence([]string{write("ok.json", server.URL)}, &stdout, &stderr); code != ExitOK {
+		t.Errorf("got exit code %d for a passing run: %s%s", code, stdout.String(), stderr.String())
+	}
+	if code := RunSequence([]string{write("fail.json", server.URL+"/status/500")}, &stdout, &stderr); code != ExitFailed {
+		t.Errorf("got exit code %d for a failing run", code)
+	}
+	if code := RunSequence([]string{filepath.Join(dir, "missing.json")}, &stdout, &stderr); code != ExitUsage {
+		t.Errorf("got exit code %d for a missing sequence", code)
+	}
+}
+
+func TestRunRequestFlags(t *testing.T) {
+	environments := isolate(t)
+	os.WriteFile(filepath.Join(environments, "dev.json"), []byte(`{"token":"t1","name":"bob"}`), 0o644)
+	server := echoServer()
+	defer server.Close()
+
+	bodyFile := filepath.Join(t.TempDir(), "body.json")
+	os.WriteFile(bodyFile, []byte(`{"from":"file"}`), 0o644)
+
+	tests := []struct {
+		name  string
+		stdin string
+		args  []string
+		want  map[string]string
+	}{
+		{"GET by default", "", []string{server.URL},
+			map[string]string{"method": "GET", "body": ""}},
+		{"POST with a body", "", []string{"-d", `{"a":1}`, server.URL},
+			map[string]string{"method": "POST", "content_type": "application/json", "body": `{"a":1}`}},
+		{"method and header", "", []string{"--method", "put", "-H", "X-A: 1", server.URL},
+			map[string]string{"method": "PUT", "x_a": "1"}},
+		{"flags after the URL", "", []string{server.URL, "--header", "X-A:2", "-X", "DELETE"},
+			map[string]string{"method": "DELETE", "x_a": "2"}},
+		{"body from a file", "", []string{"-d", "@" + bodyFile, "--content-type", "text/plain", server.URL},
+			map[string]string{"method": "POST", "content_type": "text/plain", "body": `{"from":"file"}`}},
+		{"body from stdin", "piped", []string{"--data-file", "-", server.URL},
+			map[string]string{"method": "POST", "body": "piped"}},
+		{"urlencoded form", "", []string{"-F", "a=1", "-F", "b=x y", "--content-type", "application/x-www-form-urlencoded", server.URL},
+			map[string]string{"method": "POST", "content_type": "application/x-www-form-urlencoded", "body": "a=1&b=x+y"}},
+		{"environment", "", []string{"--env", "dev", "-H", "X-A: {{token}}", "-d", "{{name}}", server.URL},
+			map[string]string{"x_a": "t1", "body": "bob"}},
+	}
+	for _, tt := range tests {
+		code, stdout, stderr := runRequest(tt.stdin, tt.args...)
+		var got map[string]string
+		if err := json.Unmarshal([]byte(stdout), &got); code != ExitOK || err != nil {
+			t.Errorf("%s: got exit code %d, %v: %s%s", tt.name, code, err, stdout, stderr)
+			continue
+		}
+		for key, want := range tt.want {
+			if got[key] != want {
+				t.Errorf("%s: got %s %q, want %q", tt.name, key, got[key], want)
+			}
+		}
+	}
+
+	// Multipart forms carry their boundary
+	_, stdout, _ := runRequest("", "-F", "a=1", server.URL)
+	if !strings.Contains(stdout, `"multipart/form-data; boundary=`) || !strings.Contains(stdout, `name=\"a\"`) {
+		t.Errorf("got %s for a multipart form", stdout)
+	}
+}
+
+func TestRunRequestJSONEnvelope(t *testing.T) {
+	isolate(t)
+	server := echoServer()
+	defer server.Close()
+
+	code, stdout, _ := runRequest("", "--json", server.URL+"/moved")
+	var envelope responseEnvelope
+	if err := json.Unmarshal([]byte(stdout), &envelope); code != ExitOK || err != nil {
+		t.Fatalf("got exit code %d, %v: %s", code, err, stdout)
+	}
+	if envelope.Status != 200 || envelope.StatusText != "200 OK" || envelope.Proto != "HTTP/1.1" || envelope.Outcome != "" || envelope.Error != "" {
+		t.Errorf("got %+v", envelope)
+	}
+	if envelope.Headers["X-Echo"][0] != "yes" || !strings.Contains(envelope.Body, `"method": "GET"`) || fmt.Sprint(envelope.Size) != envelope.Headers["Content-Length"][0] || envelope.TimeMS <= 0 {
+		t.Errorf("got headers %v, body %q, size %d, time %v", envelope.Headers, envelope.Body, envelope.Size, envelope.TimeMS)
+	}
+	if envelope.URL != server.URL+"/" || len(envelope.Redirects) != 1 {
+		t.Fatalf("got URL %s, redirects %+v", envelope.URL, envelope.Redirects)
+	}
+	if hop := envelope.Redirects[0]; hop.Status != 302 || hop.Method != "GET" || hop.URL != server.URL+"/moved" || hop.Location != server.URL+"/" {
+		t.Errorf("got redirect %+v", hop)
+	}
+
+	// The redirect itself is printed when none are followed
+	_, stdout, _ = runRequest("", "--json", "--max-redirs", "0", server.URL+"/moved")
+	if err := json.Unmarshal([]byte(stdout), &envelope); err != nil || envelope.Status != 302 {
+		t.Errorf("got %s without following redirects", stdout)
+	}
+
+	// A failed request still prints an envelope, with the error and how it ended
+	code, stdout, _ = runRequest("", "--json", "http://127.0.0.1:1/")
+	envelope = responseEnvelope{}
+	if err := json.Unmarshal([]byte(stdout), &envelope); code != ExitTransport || err != nil || envelope.Error == "" || envelope.Outcome != "error" || envelope.Status != 0 {
+		t.Errorf("got exit code %d, %s", code, stdout)
+	}
+}
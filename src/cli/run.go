@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// RunSequence implements "postty run": it replays the requests of a sequence file in order,
+// once per row of an optional data file, prints a line per request and writes reports
+func RunSequence(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("postty run", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var protoFiles, importPaths listFlags
+	dataFile := fs.String("data", "", "CSV or JSON data file; the sequence runs once per row, with its columns as variables")
+	envName := fs.String("env", "", "environment used to resolve {{variables}}")
+	junitPath := fs.String("junit", "", "write a JUnit XML report to this file, - for stdout")
+	reportPath := fs.String("report", "", "write a JSON report to this file, - for stdout")
+	timeout := fs.Int("timeout", -1, "timeout in seconds for requests that do not set one, 0 for none (default from config)")
+	bail := fs.Bool("bail", false, "stop at the first failed request")
+	fs.Var(&protoFiles, "proto", ".proto file describing gRPC methods (repeatable, default proto_files from config)")
+	fs.Var(&importPaths, "import-path", "directory .proto imports are resolved from (repeatable)")
+	insecure := fs.Bool("k", false, "skip verifying the servers' TLS certificates")
+	fs.BoolVar(insecure, "insecure", false, "skip verifying the servers' TLS certificates")
+
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: postty run [flags] <sequence.json>")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	// Allow flags before and after the file
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return ExitOK
+			}
+			return ExitUsage
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	seq, err := services.LoadSequence(positional[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "postty: %v\n", err)
+		return ExitUsage
+	}
+
+	config, err := services.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(stderr, "postty: config: %v\n", err)
+	}
+
+	options := services.RunOptions{
+		Timeout:          config.RequestTimeout,
+		StopOnFailure:    *bail,
+		ProtoFiles:       protoFiles,
+		ProtoImportPaths: importPaths,
+		TLS:              config.TLS,
+		Proxy:            services.ProxySettingsFor(config, *envName),
+	}
+	options.TLS.Insecure = options.TLS.Insecure || *insecure
+	if *timeout >= 0 {
+		options.Timeout = *timeout
+	}
+	if len(protoFiles) == 0 {
+		options.ProtoFiles = config.ProtoFiles
+		options.ProtoImportPaths = append(importPaths, config.ProtoImportPaths...)
+	}
+	if *envName != "" {
+		if options.Variables, err = environmentVariables(config, *envName); err != nil {
+			fmt.Fprintf(stderr, "postty: %v\n", err)
+			return ExitUsage
+		}
+	}
+	if *dataFile != "" {
+		if options.Rows, err = services.LoadDataRows(*dataFile); err != nil {
+			fmt.Fprintf(stderr, "postty: %v\n", err)
+			return ExitUsage
+		}
+		if len(options.Rows) == 0 {
+			fmt.Fprintf(stderr, "postty: %s has no rows\n", *dataFile)
+			return ExitUsage
+		}
+	}
+
+	// Progress moves out of the way of a report printed to stdout
+	progress := stdout
+	if *junitPath == "-" || *reportPath == "-" {
+		progress = stderr
+	}
+
+	report := services.RunSequence(context.Background(), seq, options, func(result types.RunResult) {
+		writeRunResult(progress, result, len(options.Rows) > 1)
+	})
+
+	passed, failed := services.RunCounts(report.Results)
+	fmt.Fprintf(progress, "\n%d passed, %d failed in %.3fs\n", passed, failed, report.Duration.Seconds())
+
+	code := ExitOK
+	if failed > 0 {
+		code = ExitFailed
+	}
+	if *junitPath != "" {
+		if err := writeReport(*junitPath, stdout, report, services.WriteJUnitReport); err != nil {
+			fmt.Fprintf(stderr, "postty: %v\n", err)
+			code = ExitTransport
+		}
+	}
+	if *reportPath != "" {
+		if err := writeReport(*reportPath, stdout, report, services.WriteJSONReport); err != nil {
+			fmt.Fprintf(stderr, "postty: %v\n", err)
+			code = ExitTransport
+		}
+	}
+	return code
+}
+
+// writeRunResult prints one line for a finished request, followed by why it failed
+func writeRunResult(w io.Writer, result types.RunResult, showRow bool) {
+	mark := "ok  "
+	if len(result.Failures) > 0 {
+		mark = "FAIL"
+	}
+	row := ""
+	if showRow {
+		row = fmt.Sprintf("row %d ", result.Iteration)
+	}
+	status := "-"
+	if result.StatusCode > 0 {
+		status = fmt.Sprint(result.StatusCode)
+	}
+	fmt.Fprintf(w, "%s %s#%d %s %s  %s  %d ms\n", mark, row, result.Step, result.Method, result.URL, status, result.Duration.Milliseconds())
+	for _, failure := range result.Failures {
+		fmt.Fprintf(w, "       %s\n", strings.ReplaceAll(failure, "\n", "\n       "))
+	}
+}
+
+// writeReport writes a report to path, or to stdout when path is -
+func writeReport(path string, stdout io.Writer, report types.RunReport, write func(io.Writer, types.RunReport) error) error {
+	if path == "-" {
+		return write(stdout, report)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file, report); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,53 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"postty/src/types"
+)
+
+// RenderTestResults renders the pass/fail list of the assertions checked against a response
+func RenderTestResults(results []types.AssertionResult, assertions int) string {
+	if len(results) == 0 {
+		if assertions == 0 {
+			return "No assertions. Add them in the Tests tab of pane 6."
+		}
+		return "No test results. Send the request to check its assertions."
+	}
+
+	styles := NewStyles()
+	var lines []string
+	for _, result := range results {
+		if result.Passed {
+			lines = append(lines, styles.StatusGreen.Render("PASS")+" "+result.Assertion)
+			continue
+		}
+		lines = append(lines, styles.StatusRed.Render("FAIL")+" "+result.Assertion)
+		if result.Message != "" {
+			lines = append(lines, "       "+result.Message)
+		}
+	}
+	summary := fmt.Sprintf("%d of %d passed", passedCount(results), len(results))
+	return summary + "\n\n" + strings.Join(lines, "\n")
+}
+
+// testBadge renders the test results of a request as a short badge such as [✓ 3/3]
+func testBadge(results []types.AssertionResult, styles Styles) string {
+	passed := passedCount(results)
+	if passed == len(results) {
+		return styles.StatusGreen.Render(fmt.Sprintf("[✓ %d/%d]", passed, len(results)))
+	}
+	return styles.StatusRed.Render(fmt.Sprintf("[✗ %d/%d]", passed, len(results)))
+}
+
+// passedCount counts the assertions that passed
+func passedCount(results []types.AssertionResult) int {
+	passed := 0
+	for _, result := range results {
+		if result.Passed {
+			passed++
+		}
+	}
+	return passed
+}
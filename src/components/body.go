@@ -1,6 +1,9 @@
 package components
 
 import (
+	"strings"
+
+	"postty/src/services"
 	"postty/src/types"
 )
 
@@ -9,6 +12,34 @@ func RenderBodyPane(m types.Model, styles Styles, width, height int) string {
 	bodyTitle := styles.PaneNumber.Render("[3] ") + styles.Title.Render("Body")
 	bodyContent := bodyTitle + "\n" + m.BodyInput.View()
 
+	if hint := scriptsHint(m); hint != "" {
+		bodyContent += "\n" + hint
+	}
+
+	if contentType := types.ContentTypes[m.SelectedHeader]; services.IsFormContentType(contentType) && !m.ImportingCurl && m.BodyTab == types.RequestBodyTab {
+		kind := "urlencoded"
+		if contentType == services.FormMultipart {
+			kind = "multipart"
+		}
+		bodyTitle = styles.PaneNumber.Render("[3] ") + styles.Title.Render("Body") + "  form: " + kind
+		bodyContent = bodyTitle + "\n" + renderFormFields(m, styles)
+	}
+
+	if types.ContentTypes[m.SelectedHeader] == services.GraphQLContentType && !m.ImportingCurl && m.BodyTab == types.RequestBodyTab {
+		bodyTitle = styles.PaneNumber.Render("[3] ") + styles.Title.Render("Body") + "  graphql: " + renderTabs(graphQLEditorNames, int(m.GraphQLEditor), styles)
+		bodyContent = bodyTitle + "\n" + renderGraphQLEditor(m, styles)
+	}
+
+	if m.BodyTab != types.RequestBodyTab && !m.ImportingCurl {
+		bodyTitle = styles.PaneNumber.Render("[3] ") + styles.Title.Render("Script") + "  " + renderTabs(scriptTabNames, int(m.BodyTab)-1, styles)
+		bodyContent = bodyTitle + "\n" + renderScriptEditor(m)
+	}
+
+	if m.ImportingCurl {
+		bodyTitle = styles.PaneNumber.Render("[3] ") + styles.Title.Render("Import cURL")
+		bodyContent = bodyTitle + "\n" + m.CurlInput.View() + "\n" + "  Alt+Enter: import | Esc: cancel"
+	}
+
 	style := styles.Border
 	if m.ActivePane == types.BodyPane {
 		style = styles.ActiveBorder
@@ -17,3 +48,53 @@ func RenderBodyPane(m types.Model, styles Styles, width, height int) string {
 	// Subtract 2 for borders (top + bottom)
 	return style.Width(width).Height(height - 2).Render(bodyContent)
 }
+
+// renderFormFields renders the field list of a form body, followed by the field editor or key hints
+func renderFormFields(m types.Model, styles Styles) string {
+	var rows []string
+	for i, field := range m.FormFields {
+		check := "[x] "
+		if field.Disabled {
+			check = "[ ] "
+		}
+
+		row := field.Key + " = " + field.Value
+		if field.File {
+			row = field.Key + " = @" + field.Value
+		}
+		if field.ContentType != "" {
+			row += " (" + field.ContentType + ")"
+		}
+		if field.Key == "" {
+			row = "(empty)"
+		}
+
+		if i == m.SelectedFormField {
+			rows = append(rows, styles.SelectedItem.Render("▶ ")+check+row)
+		} else {
+			rows = append(rows, "  "+check+row)
+		}
+	}
+
+	if len(rows) == 0 {
+		rows = append(rows, "  (no fields)")
+		if strings.TrimSpace(m.BodyInput.Value()) != "" {
+			rows = append(rows, "  The raw body is sent until a field is added.")
+		}
+	}
+
+	m.FormViewport.SetContent(strings.Join(rows, "\n"))
+
+	// Auto-scroll to keep selected field visible
+	if m.SelectedFormField < m.FormViewport.YOffset {
+		m.FormViewport.SetYOffset(m.SelectedFormField)
+	} else if m.SelectedFormField >= m.FormViewport.YOffset+m.FormViewport.Height {
+		m.FormViewport.SetYOffset(m.SelectedFormField - m.FormViewport.Height + 1)
+	}
+
+	footer := "  a: add | e: edit | d: del | space: toggle | J/K: move | Alt+Enter: send"
+	if m.EditingFormField {
+		footer = "  " + m.FormFieldInput.View()
+	}
+	return m.FormViewport.View() + "\n" + footer
+}
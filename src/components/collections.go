@@ -0,0 +1,76 @@
+package components
+
+import (
+	"strings"
+
+	"postty/src/types"
+)
+
+// RenderCollectionsPane renders the saved request collections tree
+func RenderCollectionsPane(m types.Model, styles Styles, width, height int) string {
+	collectionsTitle := styles.PaneNumber.Render("[8] ") + styles.Title.Render("Collections")
+	collectionsContent := collectionsTitle + "\n"
+
+	switch m.CollectionsMode {
+	case types.CollectionsSaveMode, types.CollectionsFolderMode:
+		if m.CollectionsMode == types.CollectionsSaveMode {
+			collectionsContent += "  Save request as:\n"
+		} else {
+			collectionsContent += "  New folder:\n"
+		}
+		collectionsContent += "  " + m.CollectionNameInput.View() + "\n"
+		collectionsContent += "\n"
+		collectionsContent += "  Enter: save | Esc: cancel\n"
+
+	default:
+		if len(m.CollectionNodes) == 0 {
+			collectionsContent += "  No saved requests.\n\n"
+			collectionsContent += "  s: save current | f: folder"
+			break
+		}
+
+		// Build tree rows
+		var rows []string
+		for i, node := range m.CollectionNodes {
+			indent := strings.Repeat("  ", node.Depth)
+
+			row := ""
+			if node.Folder != nil {
+				marker := "▸ "
+				if node.Expanded {
+					marker = "▾ "
+				}
+				row = indent + marker + node.Folder.Name + "/"
+			} else {
+				row = indent + node.Request.Method + " " + node.Request.Name
+			}
+
+			if i == m.SelectedCollection {
+				rows = append(rows, styles.SelectedItem.Render("▶ "+row))
+			} else {
+				rows = append(rows, "  "+row)
+			}
+		}
+
+		// Set viewport content
+		m.CollectionsViewport.SetContent(strings.Join(rows, "\n"))
+
+		// Auto-scroll to keep selected item visible
+		if m.SelectedCollection < m.CollectionsViewport.YOffset {
+			m.CollectionsViewport.SetYOffset(m.SelectedCollection)
+		} else if m.SelectedCollection >= m.CollectionsViewport.YOffset+m.CollectionsViewport.Height {
+			m.CollectionsViewport.SetYOffset(m.SelectedCollection - m.CollectionsViewport.Height + 1)
+		}
+
+		collectionsContent += m.CollectionsViewport.View() + "\n"
+		collectionsContent += "  Enter: load | s: save | d: del"
+	}
+
+	style := styles.Border
+	if m.ActivePane == types.CollectionsPane {
+		style = styles.ActiveBorder
+	}
+
+	// Subtract 2 for borders (top + bottom)
+	return style.Width(width).Height(height - 2).Render(collectionsContent)
+}
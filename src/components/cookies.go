@@ -0,0 +1,66 @@
+package components
+
+import (
+	"strings"
+
+	"postty/src/types"
+)
+
+// cookieValueWidth is how much of a cookie value the list shows
+const cookieValueWidth = 24
+
+// RenderCookies renders the cookie jar grouped by domain, each cookie followed by its
+// attributes. It also returns the line of the selected cookie.
+func RenderCookies(cookies []types.Cookie, selected int) (string, int) {
+	styles := NewStyles()
+
+	if len(cookies) == 0 {
+		return "No cookies yet. Responses add theirs, or press a to add one.", 0
+	}
+
+	var lines []string
+	selectedLine := 0
+	domain := ""
+	for i, cookie := range cookies {
+		if i == 0 || cookie.Domain != domain {
+			domain = cookie.Domain
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			header := cookie.Domain
+			if !cookie.HostOnly {
+				header += " (and subdomains)"
+			}
+			lines = append(lines, styles.Title.Render(header))
+		}
+
+		value := cookie.Value
+		if len(value) > cookieValueWidth {
+			value = value[:cookieValueWidth-3] + "..."
+		}
+		if i == selected {
+			selectedLine = len(lines)
+			lines = append(lines, styles.SelectedItem.Render("▶ "+cookie.Name+"="+value))
+		} else {
+			lines = append(lines, "  "+cookie.Name+"="+value)
+		}
+
+		attributes := []string{cookie.Path}
+		if cookie.Expires.IsZero() {
+			attributes = append(attributes, "session")
+		} else {
+			attributes = append(attributes, "expires "+cookie.Expires.Local().Format("2006-01-02 15:04"))
+		}
+		if cookie.Secure {
+			attributes = append(attributes, "Secure")
+		}
+		if cookie.HTTPOnly {
+			attributes = append(attributes, "HttpOnly")
+		}
+		if cookie.SameSite != "" {
+			attributes = append(attributes, "SameSite="+cookie.SameSite)
+		}
+		lines = append(lines, "    "+strings.Join(attributes, " · "))
+	}
+	return strings.Join(lines, "\n"), selectedLine
+}
@@ -2,15 +2,44 @@ package components
 
 import (
 	"fmt"
+	"strings"
 
+	"postty/src/services"
 	"postty/src/types"
 )
 
+// headersPaneTabNames are the tab labels of the headers pane, in HeadersPaneTab order
+var headersPaneTabNames = []string{"Headers", "Params", "Auth", "Tests"}
+
 // RenderCustomHeadersPane renders the custom headers management pane
 func RenderCustomHeadersPane(m types.Model, styles Styles, width, height int) string {
-	headersTitle := styles.PaneNumber.Render("[6] ") + styles.Title.Render("Custom Headers")
+	headersTitle := styles.PaneNumber.Render("[6] ") + renderTabs(headersPaneTabNames, int(m.HeadersPaneTab), styles)
 	headersContent := headersTitle + "\n"
 
+	switch m.HeadersPaneTab {
+	case types.QueryParamsTab:
+		headersContent += renderQueryParams(m, styles)
+	case types.AuthTab:
+		headersContent += renderAuth(m, styles)
+	case types.AssertionsTab:
+		headersContent += renderAssertions(m, styles)
+	default:
+		headersContent += renderCustomHeaders(m, styles)
+	}
+
+	style := styles.Border
+	if m.ActivePane == types.HeadersPane {
+		style = styles.ActiveBorder
+	}
+
+	// Subtract 2 for borders (top + bottom)
+	return style.Width(width).Height(height - 2).Render(headersContent)
+}
+
+// renderCustomHeaders renders the headers list, template picker or header editor
+func renderCustomHeaders(m types.Model, styles Styles) string {
+	headersContent := ""
+
 	switch m.HeadersMode {
 	case types.HeadersViewMode:
 		if len(m.CustomHeaders) == 0 {
@@ -56,11 +85,128 @@ func RenderCustomHeadersPane(m types.Model, styles Styles, width, height int) st
 		}
 	}
 
-	style := styles.Border
-	if m.ActivePane == types.HeadersPane {
-		style = styles.ActiveBorder
+	return headersContent
+}
+
+// renderQueryParams renders the params list, or the param being edited
+func renderQueryParams(m types.Model, styles Styles) string {
+	content := ""
+
+	if m.HeadersMode == types.HeadersEditMode && len(m.QueryParams) > 0 {
+		content += "  Editing param (key=value):\n"
+		content += "\n"
+		content += "  " + m.HeaderEditInput.View() + "\n"
+		content += "\n"
+		content += "  Enter: save | Esc: cancel\n"
+		return content
 	}
 
-	// Subtract 2 for borders (top + bottom)
-	return style.Width(width).Height(height - 2).Render(headersContent)
+	if len(m.QueryParams) == 0 {
+		content += "  (no params)\n"
+		content += "  Press 'a' to add"
+		return content
+	}
+
+	for i, p := range m.QueryParams {
+		prefix := "  "
+		if i == m.SelectedParam {
+			prefix = styles.SelectedItem.Render("▶ ")
+		}
+		check := "[x] "
+		if p.Disabled {
+			check = "[ ] "
+		}
+		line := p.Key
+		if p.Value != "" || p.Equals {
+			line += " = " + p.Value
+		}
+		content += prefix + check + line + "\n"
+	}
+	content += "  a: add | e: edit | d: del\n"
+	content += "  space: toggle | J/K: move"
+	return content
+}
+
+// renderAuth renders the auth fields, or the field being edited. Secrets are masked.
+func renderAuth(m types.Model, styles Styles) string {
+	content := ""
+	fields := services.AuthFields(m.Auth)
+
+	if m.HeadersMode == types.HeadersEditMode && m.SelectedAuthField < len(fields) {
+		content += fmt.Sprintf("  Editing %s:\n", strings.ToLower(fields[m.SelectedAuthField].Label))
+		content += "\n"
+		content += "  " + m.HeaderEditInput.View() + "\n"
+		content += "\n"
+		content += "  Enter: save | Esc: cancel\n"
+		return content
+	}
+
+	for i, field := range fields {
+		prefix := "  "
+		if i == m.SelectedAuthField {
+			prefix = styles.SelectedItem.Render("▶ ")
+		}
+
+		value := services.AuthFieldValue(m.Auth, field.Name)
+		switch {
+		case field.Name == "type":
+			value = services.AuthTypeName(m.Auth.Type)
+		case value == "" && len(field.Choices) > 0:
+			value = field.Choices[0]
+		case value == "" && field.Default != "":
+			value = field.Default
+		case value == "":
+			value = "(empty)"
+		case field.Secret:
+			value = strings.Repeat("•", min(len([]rune(value)), 12))
+		case len([]rune(value)) > 23:
+			value = string([]rune(value)[:22]) + "…"
+		}
+		content += prefix + fmt.Sprintf("%-10s %s", field.Label, value) + "\n"
+	}
+
+	if m.Auth.Type == types.AuthNone {
+		content += "  space: choose a type"
+		return content
+	}
+	content += "  e: edit | d: clear | space: next"
+	if m.Auth.Type == types.AuthOAuth2 {
+		content += "\n  f: get a token now"
+	}
+	return content
+}
+
+// renderAssertions renders the assertions list, or the assertion being edited
+func renderAssertions(m types.Model, styles Styles) string {
+	content := ""
+
+	if m.HeadersMode == types.HeadersEditMode && len(m.Assertions) > 0 {
+		content += "  Editing assertion (e.g. status == 200):\n"
+		content += "\n"
+		content += "  " + m.HeaderEditInput.View() + "\n"
+		content += "\n"
+		content += "  Enter: save | Esc: cancel\n"
+		return content
+	}
+
+	if len(m.Assertions) == 0 {
+		content += "  (no assertions)\n"
+		content += "  Press 'a' to add"
+		return content
+	}
+
+	for i, a := range m.Assertions {
+		prefix := "  "
+		if i == m.SelectedAssertion {
+			prefix = styles.SelectedItem.Render("▶ ")
+		}
+		check := "[x] "
+		if a.Disabled {
+			check = "[ ] "
+		}
+		content += prefix + check + services.FormatAssertion(a) + "\n"
+	}
+	content += "  a: add | e: edit | d: del\n"
+	content += "  space: toggle | J/K: move"
+	return content
 }
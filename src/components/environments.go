@@ -0,0 +1,73 @@
+package components
+
+import (
+	"sort"
+	"strings"
+
+	"postty/src/types"
+)
+
+// RenderEnvironmentsPane renders the environment picker along with the variables of the selected environment
+func RenderEnvironmentsPane(m types.Model, styles Styles, width, height int) string {
+	environmentsTitle := styles.PaneNumber.Render("[9] ") + styles.Title.Render("Environments")
+	environmentsContent := environmentsTitle + "\n"
+
+	if len(m.Environments) == 0 {
+		environmentsContent += "  No environments.\n"
+		environmentsContent += "  Add .json files to\n"
+		environmentsContent += "  " + m.EnvironmentsDir + "\n"
+	} else {
+		var lines []string
+		for i, env := range m.Environments {
+			marker := "○ "
+			if i == m.ActiveEnvironment {
+				marker = "● "
+			}
+			if i == m.SelectedEnvironment {
+				lines = append(lines, styles.SelectedItem.Render("▶ "+marker+env.Name))
+			} else {
+				lines = append(lines, "  "+marker+env.Name)
+			}
+		}
+
+		// Show the variables of the selected environment below the list
+		selected := m.Environments[m.SelectedEnvironment]
+		names := make([]string, 0, len(selected.Variables))
+		for name := range selected.Variables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		if len(names) > 0 {
+			lines = append(lines, "")
+		}
+		for _, name := range names {
+			line := "    " + name + " = " + selected.Variables[name]
+			if maxWidth := width - 4; maxWidth > 3 && len(line) > maxWidth {
+				line = line[:maxWidth-3] + "..."
+			}
+			lines = append(lines, line)
+		}
+
+		// Set viewport content
+		m.EnvironmentsViewport.SetContent(strings.Join(lines, "\n"))
+
+		// Auto-scroll to keep selected item visible
+		if m.SelectedEnvironment < m.EnvironmentsViewport.YOffset {
+			m.EnvironmentsViewport.SetYOffset(m.SelectedEnvironment)
+		} else if m.SelectedEnvironment >= m.EnvironmentsViewport.YOffset+m.EnvironmentsViewport.Height {
+			m.EnvironmentsViewport.SetYOffset(m.SelectedEnvironment - m.EnvironmentsViewport.Height + 1)
+		}
+
+		environmentsContent += m.EnvironmentsViewport.View() + "\n"
+		environmentsContent += "  Enter: use | r: reload"
+	}
+
+	style := styles.Border
+	if m.ActivePane == types.EnvironmentsPane {
+		style = styles.ActiveBorder
+	}
+
+	// Subtract 2 for borders (top + bottom)
+	return style.Width(width).Height(height - 2).Render(environmentsContent)
+}
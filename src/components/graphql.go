@@ -0,0 +1,131 @@
+package components
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/lipgloss"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// graphQLEditorNames labels the GraphQL editors, in GraphQLEditor order
+var graphQLEditorNames = []string{"Query", "Variables", "Operation"}
+
+// TextBeforeCursor returns the text of a textarea up to its cursor
+func TextBeforeCursor(ta textarea.Model) string {
+	lines := strings.Split(ta.Value(), "\n")
+	row := ta.Line()
+	if row >= len(lines) {
+		return ta.Value()
+	}
+
+	info := ta.LineInfo()
+	current := []rune(lines[row])
+	col := min(info.StartColumn+info.ColumnOffset, len(current))
+	return strings.Join(append(lines[:row:row], string(current[:col])), "\n")
+}
+
+// renderGraphQLEditor renders the active GraphQL editor with completions or key hints below it
+func renderGraphQLEditor(m types.Model, styles Styles) string {
+	var editor string
+	switch m.GraphQLEditor {
+	case types.GraphQLVariablesEditor:
+		editor = m.GraphQLVariables.View()
+	case types.GraphQLOperationEditor:
+		editor = m.GraphQLOperation.View()
+	default:
+		editor = m.BodyInput.View()
+	}
+
+	footer := "  Ctrl+G: editor | Ctrl+N: complete | Ctrl+R: load schema"
+	if m.GraphQLEditor == types.GraphQLQueryEditor && m.GraphQLSchema != nil && m.ActivePane == types.BodyPane {
+		candidates, active := []string(nil), -1
+		if m.GraphQLCompletion != nil {
+			candidates, active = m.GraphQLCompletion.Candidates, m.GraphQLCompletion.Index
+		} else {
+			_, candidates = services.GraphQLCompletions(m.GraphQLSchema, TextBeforeCursor(m.BodyInput))
+		}
+		if len(candidates) > 0 {
+			footer = "  " + renderCompletions(candidates, active, m.BodyInput.Width(), styles)
+		}
+	}
+	return editor + "\n" + footer
+}
+
+// renderCompletions lists completion candidates on one line, as many as fit in width
+func renderCompletions(candidates []string, active, width int, styles Styles) string {
+	var parts []string
+	used := 0
+	for i, candidate := range candidates {
+		if used+len(candidate)+1 > width-2 {
+			parts = append(parts, "…")
+			break
+		}
+		used += len(candidate) + 1
+		if i == active {
+			parts = append(parts, styles.SelectedItem.Render(candidate))
+		} else {
+			parts = append(parts, candidate)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// RenderGraphQLResponse renders a GraphQL response with its errors listed apart from its
+// data. Bodies that are not GraphQL responses with errors are returned unchanged.
+func RenderGraphQLResponse(body string) string {
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message   string `json:"message"`
+			Locations []struct {
+				Line   int `json:"line"`
+				Column int `json:"column"`
+			} `json:"locations"`
+			Path       []any           `json:"path"`
+			Extensions json.RawMessage `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(body), &response); err != nil || len(response.Errors) == 0 {
+		return body
+	}
+
+	styles := NewStyles()
+	heading := lipgloss.NewStyle().Bold(true)
+
+	var lines []string
+	lines = append(lines, styles.StatusRed.Render(fmt.Sprintf("Errors (%d)", len(response.Errors))))
+	for _, e := range response.Errors {
+		lines = append(lines, "• "+e.Message)
+		if len(e.Path) > 0 {
+			path := make([]string, len(e.Path))
+			for i, part := range e.Path {
+				path[i] = fmt.Sprint(part)
+			}
+			lines = append(lines, "  path: "+strings.Join(path, "."))
+		}
+		for _, location := range e.Locations {
+			lines = append(lines, fmt.Sprintf("  at line %d, column %d", location.Line, location.Column))
+		}
+		if len(e.Extensions) > 0 && string(e.Extensions) != "null" {
+			var compact bytes.Buffer
+			if json.Compact(&compact, e.Extensions) == nil {
+				lines = append(lines, "  extensions: "+compact.String())
+			}
+		}
+	}
+
+	lines = append(lines, "", heading.Render("Data"))
+	data := "null"
+	var pretty bytes.Buffer
+	if len(response.Data) > 0 && json.Indent(&pretty, response.Data, "", "  ") == nil {
+		data = pretty.String()
+	}
+	lines = append(lines, data)
+	return strings.Join(lines, "\n")
+}
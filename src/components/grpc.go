@@ -0,0 +1,80 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"postty/src/types"
+)
+
+// RenderGRPCResponse renders the response messages of a gRPC call, followed by its
+// status and trailers once it has ended
+func RenderGRPCResponse(replies []types.GRPCReply, result *types.GRPCResult) string {
+	styles := NewStyles()
+
+	var lines []string
+	indent := strings.Repeat(" ", len("15:04:05.000")+3)
+	for _, reply := range replies {
+		for i, line := range strings.Split(reply.Message, "\n") {
+			if i == 0 {
+				lines = append(lines, reply.Time.Format("15:04:05.000")+" ← "+line)
+			} else {
+				lines = append(lines, indent+line)
+			}
+		}
+	}
+
+	if result == nil {
+		if len(lines) == 0 {
+			return "Waiting for response messages..."
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "No response messages.")
+	}
+
+	statusStyle := styles.StatusGreen
+	if result.Number != 0 {
+		statusStyle = styles.StatusRed
+	}
+	statusText := fmt.Sprintf("Status: %s (%d)", result.Code, result.Number)
+	lines = append(lines, "", statusStyle.Render(statusText))
+	if result.Message != "" {
+		lines = append(lines, result.Message)
+	}
+
+	if len(result.Trailers) > 0 {
+		lines = append(lines, "", "Trailers:")
+		for _, trailer := range result.Trailers {
+			lines = append(lines, fmt.Sprintf("  %s: %s", trailer.Key, trailer.Value))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RenderGRPCMethods renders the methods of a gRPC server as a list, one per line
+func RenderGRPCMethods(methods []types.GRPCMethodInfo, selected int) string {
+	styles := NewStyles()
+
+	var lines []string
+	for i, method := range methods {
+		kind := ""
+		switch {
+		case method.ClientStreaming && method.ServerStreaming:
+			kind = "  (bidirectional streaming)"
+		case method.ClientStreaming:
+			kind = "  (client streaming)"
+		case method.ServerStreaming:
+			kind = "  (server streaming)"
+		}
+
+		if i == selected {
+			lines = append(lines, styles.SelectedItem.Render("▶ "+method.FullName)+kind)
+		} else {
+			lines = append(lines, "  "+method.FullName+kind)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -47,8 +47,11 @@ func RenderHistoryPane(m types.Model, styles Styles, width, height int) string {
 				historyLines = append(historyLines, "")
 			}
 
-			// Request number (1-indexed)
+			// Request number (1-indexed), with a dot when marked for a sequence
 			requestNum := fmt.Sprintf("[%d]", i+1)
+			if m.MarkedHistory[i] {
+				requestNum += "●"
+			}
 
 			// Format: METHOD [STATUS]
 			statusText := ""
@@ -60,6 +63,25 @@ func RenderHistoryPane(m types.Model, styles Styles, width, height int) string {
 					statusStyle = styles.StatusYellow
 				}
 				statusText = " " + statusStyle.Render(fmt.Sprintf("[%d]", item.StatusCode))
+			} else if item.ResponseMeta != nil && item.ResponseMeta.GRPCStatus != "" {
+				statusStyle := styles.StatusGreen
+				if item.ResponseMeta.GRPCStatus != "OK" {
+					statusStyle = styles.StatusRed
+				}
+				statusText = " " + statusStyle.Render("["+item.ResponseMeta.GRPCStatus+"]")
+			} else {
+				switch item.Outcome {
+				case types.OutcomeCancelled:
+					statusText = " " + styles.StatusYellow.Render("[CANCELLED]")
+				case types.OutcomeTimedOut:
+					statusText = " " + styles.StatusRed.Render("[TIMEOUT]")
+				case types.OutcomeError:
+					statusText = " " + styles.StatusRed.Render("[ERROR]")
+				}
+			}
+
+			if len(item.TestResults) > 0 {
+				statusText += " " + testBadge(item.TestResults, styles)
 			}
 
 			methodLine := requestNum + " " + item.Method + statusText
@@ -120,6 +142,7 @@ func RenderHistoryPane(m types.Model, styles Styles, width, height int) string {
 		historyContent += m.HistoryViewport.View()
 		historyContent += "\n"
 		historyContent += "  Enter: load | d: del\n"
+		historyContent += "  Space: mark | r: run | w: save\n"
 	}
 
 	style := styles.Border
@@ -17,6 +17,8 @@ type Dimensions struct {
 	HeaderHeight       int
 	HeadersHeight      int
 	HistoryHeight      int
+	CollectionsHeight  int
+	EnvironmentsHeight int
 	ResultHeight       int
 }
 
@@ -91,8 +93,25 @@ func CalculateDimensions(width, height int) Dimensions {
 		}
 	}
 
-	// History: matches column height exactly
-	historyHeight := columnHeight
+	// Left column breakdown: Collections and Environments below History
+	collectionsHeight := (columnHeight * 30) / 100
+	if collectionsHeight < 8 {
+		collectionsHeight = 8
+	}
+
+	environmentsHeight := (columnHeight * 25) / 100
+	if environmentsHeight < 7 {
+		environmentsHeight = 7
+	}
+
+	// History: remainder (ensures left column = columnHeight exactly)
+	historyHeight := columnHeight - collectionsHeight - environmentsHeight
+	if historyHeight < 9 {
+		// If not enough space, give History its minimum and split the rest
+		historyHeight = 9
+		collectionsHeight = (columnHeight - historyHeight) / 2
+		environmentsHeight = columnHeight - historyHeight - collectionsHeight
+	}
 
 	return Dimensions{
 		HistoryColumnWidth: historyColumnWidth,
@@ -104,6 +123,8 @@ func CalculateDimensions(width, height int) Dimensions {
 		HeaderHeight:       headerHeight,
 		HeadersHeight:      headersHeight,
 		HistoryHeight:      historyHeight,
+		CollectionsHeight:  collectionsHeight,
+		EnvironmentsHeight: environmentsHeight,
 		ResultHeight:       resultHeight,
 	}
 }
@@ -119,6 +140,8 @@ func RenderLayout(m types.Model) string {
 
 	// Render all panes
 	historyPane := RenderHistoryPane(m, styles, dims.HistoryColumnWidth, dims.HistoryHeight)
+	collectionsPane := RenderCollectionsPane(m, styles, dims.HistoryColumnWidth, dims.CollectionsHeight)
+	environmentsPane := RenderEnvironmentsPane(m, styles, dims.HistoryColumnWidth, dims.EnvironmentsHeight)
 
 	urlPane := RenderURLPane(m, styles, dims.MiddleColumnWidth, dims.URLHeight)
 	bodyPane := RenderBodyPane(m, styles, dims.MiddleColumnWidth, dims.BodyHeight)
@@ -129,7 +152,7 @@ func RenderLayout(m types.Model) string {
 	headersPane := RenderCustomHeadersPane(m, styles, dims.RightColumnWidth, dims.HeadersHeight)
 
 	// Compose layout: History | Middle | Right
-	historyColumn := historyPane
+	historyColumn := lipgloss.JoinVertical(lipgloss.Left, historyPane, collectionsPane, environmentsPane)
 	middleColumn := lipgloss.JoinVertical(lipgloss.Left, urlPane, bodyPane, resultPane)
 	rightColumn := lipgloss.JoinVertical(lipgloss.Left, methodPane, headerPane, headersPane)
 	mainView := lipgloss.JoinHorizontal(lipgloss.Top, historyColumn, middleColumn, rightColumn)
@@ -137,12 +160,19 @@ func RenderLayout(m types.Model) string {
 	// Render help bar
 	help := styles.Help.Render(
 		styles.Key.Render("Tab") + " Next Pane │ " +
-			styles.Key.Render("1-7") + " Jump │ " +
+			styles.Key.Render("1-9") + " Jump │ " +
 			styles.Key.Render("↑↓jk") + " Scroll │ " +
 			styles.Key.Render("Enter") + "/" + styles.Key.Render("Alt+Enter") + " Send │ " +
+			styles.Key.Render("Ctrl+X") + " Cancel │ " +
+			styles.Key.Render("Ctrl+S") + " Save │ " +
 			styles.Key.Render("esc") + "/" + styles.Key.Render("q") + " Quit",
 	)
 
+	// Show transient status (errors, confirmations) after the help bar
+	if m.StatusMessage != "" {
+		help += " " + styles.Status.Render(m.StatusMessage)
+	}
+
 	// Add top padding and combine with help bar
 	return "\n" + mainView + "\n" + help
 }
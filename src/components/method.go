@@ -1,6 +1,7 @@
 package components
 
 import (
+	"fmt"
 	"strings"
 
 	"postty/src/types"
@@ -12,7 +13,7 @@ func RenderMethodPane(m types.Model, styles Styles, width, height int) string {
 
 	// Build method list content for viewport
 	var methodLines []string
-	for i, method := range types.HTTPMethods {
+	for i, method := range types.Methods {
 		if i == m.SelectedMethod {
 			methodLines = append(methodLines, styles.SelectedItem.Render("▶ "+method))
 		} else {
@@ -30,7 +31,61 @@ func RenderMethodPane(m types.Model, styles Styles, width, height int) string {
 		m.MethodViewport.SetYOffset(m.SelectedMethod - m.MethodViewport.Height + 1)
 	}
 
-	methodContent := methodTitle + "\n" + m.MethodViewport.View()
+	// Per-request timeout, adjusted with +/-
+	timeoutText := fmt.Sprintf("%ds", m.RequestTimeout)
+	if m.RequestTimeout == 0 {
+		timeoutText = "default"
+		if m.Config.RequestTimeout > 0 {
+			timeoutText += fmt.Sprintf(" (%ds)", m.Config.RequestTimeout)
+		} else {
+			timeoutText += " (none)"
+		}
+	}
+
+	methodContent := methodTitle + "\n" + m.MethodViewport.View() + "\n" + "  Timeout: " + timeoutText + "  +/-"
+
+	// gRPC methods are listed from the Method pane
+	if types.Methods[m.SelectedMethod] == types.GRPCMethod {
+		methodContent += "  r: list"
+	}
+
+	// WebSocket options replace the timeout, which only covers the handshake
+	if types.Methods[m.SelectedMethod] == types.WebSocketMethod {
+		frame := "text"
+		if m.WebSocketBinary {
+			frame = "binary"
+		}
+		closeCode := types.WebSocketCloseCodes[m.WebSocketCloseCode].Code
+		methodContent = methodTitle + "\n" + m.MethodViewport.View() + "\n" + fmt.Sprintf("  Frames: %s (b)  Close: %d (c)", frame, closeCode)
+	}
+
+	// Server certificate verification and the cookie jar, toggled with v and o
+	tlsText := "verify"
+	if m.TLS.Insecure || m.Config.TLS.Insecure {
+		tlsText = "insecure"
+	}
+	cookiesText := "on"
+	if m.NoCookies {
+		cookiesText = "off"
+	}
+	methodContent += fmt.Sprintf("\n  TLS: %s (v)  Cookies: %s (o)", tlsText, cookiesText)
+
+	// Redirect policy, cycled with f, and its limit, edited with m
+	redirectsText := "follow"
+	if m.Redirects.KeepMethod {
+		redirectsText += " +method"
+	}
+	if m.Redirects.NoFollow {
+		redirectsText = "off"
+	}
+	maxText := fmt.Sprint(types.DefaultMaxRedirects)
+	if m.Redirects.Max > 0 {
+		maxText = fmt.Sprint(m.Redirects.Max)
+	}
+	if m.EditingMaxRedirects {
+		maxText = m.MaxRedirectsInput.View()
+	}
+	methodContent += fmt.Sprintf("\n  Redirects: %s (f)\n  Max redirects: %s (m)", redirectsText, maxText)
 
 	style := styles.Border
 	if m.ActivePane == types.MethodPane {
@@ -0,0 +1,15 @@
+package components
+
+import "strings"
+
+// RenderOAuth2Login renders the sign-in URL of an authorization code login, wrapped to width
+// so it can be copied whole
+func RenderOAuth2Login(url string, width int, copied bool) string {
+	content := "Sign in at:\n\n"
+	content += strings.Join(wrapText(url, max(width, 20)), "\n") + "\n\n"
+	if copied {
+		content += "The URL is also on the clipboard.\n"
+	}
+	content += "Waiting for the browser to come back... Ctrl+X cancels."
+	return content
+}
@@ -2,10 +2,16 @@ package components
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"postty/src/services"
 	"postty/src/types"
 )
 
+// responseTabNames holds the labels of the response pane tabs, in ResponseTab order
+var responseTabNames = []string{"Body", "Headers", "Info", "Tests", "Console"}
+
 // RenderResponsePane renders the HTTP response pane
 func RenderResponsePane(m types.Model, styles Styles, width, height int) string {
 	resultTitle := styles.PaneNumber.Render("[5] ") + styles.Title.Render("Result")
@@ -18,10 +24,59 @@ func RenderResponsePane(m types.Model, styles Styles, width, height int) string
 			statusStyle = styles.StatusYellow
 		}
 		resultTitle += " " + statusStyle.Render(fmt.Sprintf("[%d]", m.StatusCode))
+		if m.ResponseMeta != nil && len(m.ResponseMeta.Redirects) == 1 {
+			resultTitle += " " + styles.StatusYellow.Render("1 redirect")
+		} else if m.ResponseMeta != nil && len(m.ResponseMeta.Redirects) > 1 {
+			resultTitle += " " + styles.StatusYellow.Render(fmt.Sprintf("%d redirects", len(m.ResponseMeta.Redirects)))
+		}
+	} else if m.ResponseMeta != nil && m.ResponseMeta.GRPCStatus != "" {
+		statusStyle := styles.StatusGreen
+		if m.ResponseMeta.GRPCStatus != "OK" {
+			statusStyle = styles.StatusRed
+		}
+		resultTitle += " " + statusStyle.Render("["+m.ResponseMeta.GRPCStatus+"]")
+	}
+
+	tabNames := responseTabNames
+	if len(m.TestResults) > 0 {
+		tabNames = append([]string(nil), responseTabNames...)
+		tabNames[types.ResponseTestsTab] = fmt.Sprintf("Tests %d/%d", passedCount(m.TestResults), len(m.TestResults))
+	}
+	resultTitle += "  " + renderTabs(tabNames, int(m.ResponseTab), styles)
+
+	if m.EventStream != nil {
+		if m.StreamPaused {
+			resultTitle += "  " + styles.StatusYellow.Render(fmt.Sprintf("[PAUSED +%d]", len(m.SSEEvents)+m.SSEDropped-m.StreamShown))
+		} else {
+			resultTitle += "  " + styles.StatusGreen.Render(fmt.Sprintf("[LIVE %d]", len(m.SSEEvents)+m.SSEDropped))
+		}
 	}
 
 	resultContent := resultTitle + "\n" + m.ResponseViewport.View()
 
+	if m.Exporting {
+		formatNames := make([]string, len(services.SnippetFormats))
+		for i, format := range services.SnippetFormats {
+			formatNames[i] = format.Name
+		}
+		resultTitle = styles.PaneNumber.Render("[5] ") + styles.Title.Render("Export") + "  " + renderTabs(formatNames, m.ExportFormat, styles)
+		resultContent = resultTitle + "\n" + m.ResponseViewport.View() + "\n" + "  Enter/c: copy | w: write file | Esc: close"
+	}
+
+	if m.BrowsingGRPC {
+		resultTitle = styles.PaneNumber.Render("[5] ") + styles.Title.Render("gRPC Methods")
+		resultContent = resultTitle + "\n" + m.ResponseViewport.View() + "\n" + "  Enter: use method | Esc: close"
+	}
+
+	if m.BrowsingCookies {
+		resultTitle = styles.PaneNumber.Render("[5] ") + styles.Title.Render("Cookies")
+		help := "  e: edit | a: add | d: delete | D: clear domain | Esc: close"
+		if m.EditingCookie {
+			help = m.CookieInput.View()
+		}
+		resultContent = resultTitle + "\n" + m.ResponseViewport.View() + "\n" + help
+	}
+
 	style := styles.Border
 	if m.ActivePane == types.ResponsePane {
 		style = styles.ActiveBorder
@@ -30,3 +85,124 @@ func RenderResponsePane(m types.Model, styles Styles, width, height int) string
 	// Subtract 2 for borders (top + bottom)
 	return style.Width(width).Height(height - 2).Render(resultContent)
 }
+
+// renderTabs renders a row of tab labels with the active one highlighted
+func renderTabs(names []string, active int, styles Styles) string {
+	tabs := make([]string, len(names))
+	for i, name := range names {
+		if i == active {
+			tabs[i] = styles.SelectedItem.Render(name)
+		} else {
+			tabs[i] = name
+		}
+	}
+	return strings.Join(tabs, " │ ")
+}
+
+// RenderResponseHeaders renders the response headers, one per line
+func RenderResponseHeaders(headers []types.Header) string {
+	if len(headers) == 0 {
+		return "No response headers."
+	}
+
+	var lines []string
+	for _, h := range headers {
+		lines = append(lines, fmt.Sprintf("%s: %s", h.Key, h.Value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RenderResponseInfo renders the response metadata summary
+func RenderResponseInfo(meta *types.ResponseMeta) string {
+	if meta == nil {
+		return "No response yet."
+	}
+
+	contentLength := "unknown"
+	if meta.ContentLength >= 0 {
+		contentLength = formatSize(meta.ContentLength)
+	}
+
+	encoding := meta.ContentEncoding
+	if encoding == "" {
+		encoding = "identity"
+	}
+	if meta.Decompressed {
+		encoding += " (decompressed)"
+	}
+
+	lines := []string{
+		fmt.Sprintf("Status:          %s", meta.Status),
+		fmt.Sprintf("Protocol:        %s", meta.Proto),
+		fmt.Sprintf("Time:            %s", meta.Duration.Round(100*time.Microsecond)),
+		fmt.Sprintf("Body size:       %s", formatSize(meta.BodySize)),
+		fmt.Sprintf("Content-Length:  %s", contentLength),
+		fmt.Sprintf("Encoding:        %s", encoding),
+	}
+	if meta.Proxy != "" {
+		lines = append(lines, fmt.Sprintf("Proxy:           %s", meta.Proxy))
+	}
+	if len(meta.Redirects) > 0 {
+		lines = append(lines, "", renderRedirects(meta))
+	}
+	if meta.TLS != nil {
+		lines = append(lines, "", renderTLSInfo(meta.TLS))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderRedirects renders the redirects followed before the final response, each with its
+// status, Location and time, then the final response
+func renderRedirects(meta *types.ResponseMeta) string {
+	lines := []string{"Redirects:"}
+	remaining := meta.Duration
+	for i, hop := range meta.Redirects {
+		lines = append(lines,
+			fmt.Sprintf("  %d. %s  %s  %s", i+1, hop.Status, hop.Duration.Round(100*time.Microsecond), hop.Method),
+			fmt.Sprintf("     %s", hop.URL),
+			fmt.Sprintf("     Location: %s", hop.Location),
+		)
+		remaining -= hop.Duration
+	}
+	lines = append(lines,
+		fmt.Sprintf("  %d. %s  %s", len(meta.Redirects)+1, meta.Status, max(remaining, 0).Round(100*time.Microsecond)),
+		fmt.Sprintf("     %s", meta.URL),
+	)
+	return strings.Join(lines, "\n")
+}
+
+// renderTLSInfo renders the negotiated TLS parameters and the server's certificate chain
+func renderTLSInfo(info *types.TLSInfo) string {
+	lines := []string{
+		fmt.Sprintf("TLS:             %s, %s", info.Version, info.CipherSuite),
+	}
+	if info.ServerName != "" {
+		lines = append(lines, fmt.Sprintf("Server name:     %s", info.ServerName))
+	}
+	if len(info.PeerCertificates) > 0 {
+		lines = append(lines, "Certificates:")
+	}
+	for i, cert := range info.PeerCertificates {
+		lines = append(lines,
+			fmt.Sprintf("  %d. %s", i+1, cert.Subject),
+			fmt.Sprintf("     Issuer:  %s", cert.Issuer),
+			fmt.Sprintf("     Expires: %s", cert.NotAfter.Format("2006-01-02 15:04 MST")),
+		)
+		if len(cert.DNSNames) > 0 {
+			lines = append(lines, fmt.Sprintf("     DNS:     %s", strings.Join(cert.DNSNames, ", ")))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatSize renders a byte count in human readable units
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB (%d bytes)", float64(n)/(1<<20), n)
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB (%d bytes)", float64(n)/(1<<10), n)
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
@@ -0,0 +1,55 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// RenderRunProgress renders the requests of a sequence run as they finish, followed by a
+// summary once report is given
+func RenderRunProgress(results []types.RunResult, report *types.RunReport) string {
+	styles := NewStyles()
+	showRow := false
+	for _, result := range results {
+		showRow = showRow || result.Iteration > 1
+	}
+
+	var lines []string
+	for _, result := range results {
+		badge := styles.StatusGreen.Render("PASS")
+		if len(result.Failures) > 0 {
+			badge = styles.StatusRed.Render("FAIL")
+		}
+		step := fmt.Sprintf("#%d", result.Step)
+		if showRow {
+			step = fmt.Sprintf("row %d #%d", result.Iteration, result.Step)
+		}
+		status := "-"
+		if result.StatusCode > 0 {
+			status = fmt.Sprint(result.StatusCode)
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s %s  %s  %d ms", badge, step, result.Method, result.URL, status, result.Duration.Milliseconds()))
+		for _, failure := range result.Failures {
+			lines = append(lines, "       "+failure)
+		}
+	}
+
+	if report == nil {
+		lines = append(lines, "", "Running... Ctrl+X: stop")
+		return strings.Join(lines, "\n")
+	}
+
+	passed, failed := services.RunCounts(report.Results)
+	summary := fmt.Sprintf("%d passed, %d failed in %.3fs", passed, failed, report.Duration.Seconds())
+	if report.Iterations > 1 {
+		summary += fmt.Sprintf(" (%d requests × %d rows)", report.Steps, report.Iterations)
+	}
+	if report.Cancelled {
+		summary += ", stopped before the end"
+	}
+	lines = append(lines, "", summary)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,63 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"postty/src/types"
+)
+
+// scriptTabNames labels the script editors, in BodyTab order after the request body
+var scriptTabNames = []string{"Pre-request", "Post-response"}
+
+// renderScriptEditor renders the active script editor with key hints below it
+func renderScriptEditor(m types.Model) string {
+	editor := m.PreScriptInput.View()
+	if m.BodyTab == types.PostScriptTab {
+		editor = m.PostScriptInput.View()
+	}
+	return editor + "\n" + "  Ctrl+T: next editor | Alt+Enter: send | output in Result › Console"
+}
+
+// scriptsHint names the scripts a request has, shown under the body editor, or "" when none
+func scriptsHint(m types.Model) string {
+	var names []string
+	if strings.TrimSpace(m.PreScriptInput.Value()) != "" {
+		names = append(names, "pre-request")
+	}
+	if strings.TrimSpace(m.PostScriptInput.Value()) != "" {
+		names = append(names, "post-response")
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return "  Scripts: " + strings.Join(names, ", ") + " (Ctrl+T)"
+}
+
+// RenderScriptConsole renders the output of the scripts run for a request, one entry per line
+func RenderScriptConsole(entries []types.ScriptLogEntry) string {
+	if len(entries) == 0 {
+		return "No script output."
+	}
+
+	styles := NewStyles()
+	var lines []string
+	for _, entry := range entries {
+		prefix := fmt.Sprintf("%s [%s] ", entry.Time.Format("15:04:05.000"), entry.Phase)
+		indent := strings.Repeat(" ", len(prefix))
+		switch entry.Level {
+		case "error":
+			prefix += styles.StatusRed.Render("error") + " "
+		case "warn":
+			prefix += styles.StatusYellow.Render("warn") + " "
+		}
+		for i, line := range strings.Split(entry.Text, "\n") {
+			if i == 0 {
+				lines = append(lines, prefix+line)
+			} else {
+				lines = append(lines, indent+line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
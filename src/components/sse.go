@@ -0,0 +1,51 @@
+package components
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"postty/src/types"
+)
+
+// RenderEventStream renders Server-Sent Events as a timestamped list, one entry per event,
+// after a note of how many earlier events were dropped
+func RenderEventStream(events []types.SSEEvent, dropped int) string {
+	if len(events) == 0 {
+		return "Waiting for events..."
+	}
+
+	var entries []string
+	if dropped > 0 {
+		entries = append(entries, fmt.Sprintf("(%d earlier events dropped)", dropped))
+	}
+	for _, event := range events {
+		entries = append(entries, RenderSSEEvent(event))
+	}
+	return strings.Join(entries, "\n")
+}
+
+// RenderSSEEvent renders one entry of the event list: its time, type, ID and retry, then
+// its data indented below
+func RenderSSEEvent(event types.SSEEvent) string {
+	header := event.Time.Format("15:04:05.000") + " " + event.Event
+	if event.ID != "" {
+		header += " #" + event.ID
+	}
+	if event.Retry > 0 {
+		header += fmt.Sprintf(" (retry %dms)", event.Retry)
+	}
+	lines := []string{header}
+
+	data := event.Data
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, []byte(data), "", "  "); err == nil {
+		data = pretty.String()
+	}
+	indent := strings.Repeat(" ", len("15:04:05.000")+1)
+	for _, line := range strings.Split(data, "\n") {
+		lines = append(lines, indent+line)
+	}
+	return strings.Join(lines, "\n")
+}
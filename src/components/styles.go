@@ -16,6 +16,7 @@ type Styles struct {
 	SelectedItem   lipgloss.Style
 	Help           lipgloss.Style
 	Key            lipgloss.Style
+	Status         lipgloss.Style
 }
 
 // NewStyles creates and returns a new Styles instance
@@ -72,5 +73,8 @@ func NewStyles() Styles {
 		Key: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("213")).
 			Bold(true),
+
+		Status: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214")),
 	}
 }
@@ -7,6 +7,9 @@ import (
 // RenderURLPane renders the URL input pane
 func RenderURLPane(m types.Model, styles Styles, width, height int) string {
 	urlTitle := styles.PaneNumber.Render("[1] ") + styles.Title.Render("URL")
+	if m.ActiveEnvironment >= 0 && m.ActiveEnvironment < len(m.Environments) {
+		urlTitle += " " + styles.PaneNumber.Render("env: "+m.Environments[m.ActiveEnvironment].Name)
+	}
 	urlContent := urlTitle + "\n" + m.URLInput.View()
 
 	style := styles.Border
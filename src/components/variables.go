@@ -0,0 +1,96 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// highlightUnresolved renders text with every reference to an unresolved variable highlighted
+func highlightUnresolved(text string, unresolved map[string]bool, styles Styles) string {
+	ranges, names := services.FindVariables(text)
+
+	var b strings.Builder
+	last := 0
+	for i, r := range ranges {
+		if !unresolved[names[i]] {
+			continue
+		}
+		b.WriteString(text[last:r[0]])
+		b.WriteString(styles.StatusRed.UnsetPadding().Render(text[r[0]:r[1]]))
+		last = r[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// RenderUnresolvedVariables explains which variables could not be resolved and where they are used
+func RenderUnresolvedVariables(req types.Request, unresolved []string, environment string) string {
+	styles := NewStyles()
+
+	missing := map[string]bool{}
+	for _, name := range unresolved {
+		missing[name] = true
+	}
+
+	if environment == "" {
+		environment = "(none)"
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("Error: unresolved variables: %s", strings.Join(unresolved, ", ")))
+	lines = append(lines, fmt.Sprintf("Active environment: %s", environment))
+	lines = append(lines, "The request was not sent.", "")
+
+	if _, names := services.FindVariables(req.URL); hasAny(names, missing) {
+		lines = append(lines, "URL:", "  "+highlightUnresolved(req.URL, missing, styles), "")
+	}
+
+	for _, header := range req.Headers {
+		_, keyNames := services.FindVariables(header.Key)
+		_, valueNames := services.FindVariables(header.Value)
+		if hasAny(keyNames, missing) || hasAny(valueNames, missing) {
+			lines = append(lines, "Header:", "  "+highlightUnresolved(header.Key, missing, styles)+": "+highlightUnresolved(header.Value, missing, styles), "")
+		}
+	}
+
+	if _, names := services.FindVariables(req.Body); hasAny(names, missing) {
+		lines = append(lines, "Body:")
+		for _, line := range strings.Split(req.Body, "\n") {
+			lines = append(lines, "  "+highlightUnresolved(line, missing, styles))
+		}
+	}
+
+	if _, names := services.FindVariables(req.GraphQLVariables); hasAny(names, missing) {
+		lines = append(lines, "GraphQL variables:")
+		for _, line := range strings.Split(req.GraphQLVariables, "\n") {
+			lines = append(lines, "  "+highlightUnresolved(line, missing, styles))
+		}
+	}
+
+	if _, names := services.FindVariables(req.GraphQLOperation); hasAny(names, missing) {
+		lines = append(lines, "GraphQL operation:", "  "+highlightUnresolved(req.GraphQLOperation, missing, styles), "")
+	}
+
+	for _, field := range req.Form {
+		_, keyNames := services.FindVariables(field.Key)
+		_, valueNames := services.FindVariables(field.Value)
+		if hasAny(keyNames, missing) || hasAny(valueNames, missing) {
+			lines = append(lines, "Form field:", "  "+highlightUnresolved(field.Key, missing, styles)+" = "+highlightUnresolved(field.Value, missing, styles), "")
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// hasAny reports whether any of names is in set
+func hasAny(names []string, set map[string]bool) bool {
+	for _, name := range names {
+		if set[name] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package components
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"postty/src/types"
+)
+
+// webSocketArrows marks the direction of each log entry
+var webSocketArrows = map[types.WebSocketDirection]string{
+	types.WebSocketSent:     "→",
+	types.WebSocketReceived: "←",
+	types.WebSocketEvent:    "•",
+}
+
+// RenderWebSocketLog renders the message log, one timestamped entry per frame or event
+func RenderWebSocketLog(log []types.WebSocketMessage) string {
+	if len(log) == 0 {
+		return "No messages yet."
+	}
+
+	var lines []string
+	for _, message := range log {
+		prefix := message.Time.Format("15:04:05.000") + " " + webSocketArrows[message.Direction] + " "
+		indent := strings.Repeat(" ", len("15:04:05.000")+3)
+
+		text := message.Data
+		if message.Binary {
+			text = fmt.Sprintf("[binary, %d bytes] %s", len(message.Data), hex.EncodeToString([]byte(message.Data)))
+		} else if message.Direction != types.WebSocketEvent {
+			var pretty bytes.Buffer
+			if err := json.Indent(&pretty, []byte(message.Data), "", "  "); err == nil {
+				text = pretty.String()
+			}
+		}
+
+		for i, line := range strings.Split(text, "\n") {
+			if i == 0 {
+				lines = append(lines, prefix+line)
+			} else {
+				lines = append(lines, indent+line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
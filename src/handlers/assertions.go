@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// HandleAssertionsNavigation handles up/down navigation in the assertions list
+func HandleAssertionsNavigation(m types.Model, direction string) types.Model {
+	if direction == "up" {
+		if m.SelectedAssertion > 0 {
+			m.SelectedAssertion--
+		}
+	} else if direction == "down" {
+		if m.SelectedAssertion < len(m.Assertions)-1 {
+			m.SelectedAssertion++
+		}
+	}
+	return m
+}
+
+// HandleAssertionsAdd appends an empty assertion and starts editing it
+func HandleAssertionsAdd(m types.Model) (types.Model, tea.Cmd) {
+	m.Assertions = append(m.Assertions, types.Assertion{})
+	m.SelectedAssertion = len(m.Assertions) - 1
+	return HandleAssertionsEdit(m)
+}
+
+// HandleAssertionsEdit starts editing the selected assertion as text, e.g. "status == 200"
+func HandleAssertionsEdit(m types.Model) (types.Model, tea.Cmd) {
+	if len(m.Assertions) == 0 {
+		return m, nil
+	}
+
+	value := ""
+	if assertion := m.Assertions[m.SelectedAssertion]; assertion.Kind != "" {
+		value = services.FormatAssertion(assertion)
+	}
+
+	m.HeadersMode = types.HeadersEditMode
+	m.HeaderEditInput.SetValue(value)
+	m.HeaderEditInput.CursorEnd()
+	m.HeaderEditInput.Focus()
+	return m, textinput.Blink
+}
+
+// HandleAssertionEditSave stores the edited assertion, staying in the editor when it cannot be read
+func HandleAssertionEditSave(m types.Model) types.Model {
+	if len(m.Assertions) == 0 {
+		m.HeadersMode = types.HeadersViewMode
+		m.HeaderEditInput.Blur()
+		return m
+	}
+	if m.HeaderEditInput.Value() == "" {
+		return HandleAssertionEditCancel(m)
+	}
+
+	assertion, err := services.ParseAssertion(m.HeaderEditInput.Value())
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Assertion: %v", err)
+		return m
+	}
+	assertion.Disabled = m.Assertions[m.SelectedAssertion].Disabled
+	m.Assertions[m.SelectedAssertion] = assertion
+	m.HeadersMode = types.HeadersViewMode
+	m.HeaderEditInput.Blur()
+	m.StatusMessage = ""
+	return m
+}
+
+// HandleAssertionEditCancel leaves editing, dropping an assertion that was added but never filled in
+func HandleAssertionEditCancel(m types.Model) types.Model {
+	m.HeadersMode = types.HeadersViewMode
+	m.HeaderEditInput.Blur()
+	m.StatusMessage = ""
+	if len(m.Assertions) > 0 && m.Assertions[m.SelectedAssertion].Kind == "" {
+		return HandleAssertionsDelete(m)
+	}
+	return m
+}
+
+// HandleAssertionsDelete removes the selected assertion
+func HandleAssertionsDelete(m types.Model) types.Model {
+	if len(m.Assertions) == 0 {
+		return m
+	}
+	m.Assertions = append(m.Assertions[:m.SelectedAssertion], m.Assertions[m.SelectedAssertion+1:]...)
+	if m.SelectedAssertion >= len(m.Assertions) && m.SelectedAssertion > 0 {
+		m.SelectedAssertion--
+	}
+	return m
+}
+
+// HandleAssertionsToggle enables or disables the selected assertion
+func HandleAssertionsToggle(m types.Model) types.Model {
+	if len(m.Assertions) == 0 {
+		return m
+	}
+	m.Assertions[m.SelectedAssertion].Disabled = !m.Assertions[m.SelectedAssertion].Disabled
+	return m
+}
+
+// HandleAssertionsMove moves the selected assertion up or down
+func HandleAssertionsMove(m types.Model, direction string) types.Model {
+	i := m.SelectedAssertion
+	j := i - 1
+	if direction == "down" {
+		j = i + 1
+	}
+	if len(m.Assertions) == 0 || j < 0 || j >= len(m.Assertions) {
+		return m
+	}
+
+	m.Assertions[i], m.Assertions[j] = m.Assertions[j], m.Assertions[i]
+	m.SelectedAssertion = j
+	return m
+}
+
+// testSummary describes how many assertions passed, or "" when none were checked
+func testSummary(results []types.AssertionResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+	passed := 0
+	for _, result := range results {
+		if result.Passed {
+			passed++
+		}
+	}
+	return fmt.Sprintf("Tests: %d/%d passed", passed, len(results))
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// HandleAuthNavigation handles up/down navigation in the auth fields
+func HandleAuthNavigation(m types.Model, direction string) types.Model {
+	fields := services.AuthFields(m.Auth)
+	if direction == "up" {
+		if m.SelectedAuthField > 0 {
+			m.SelectedAuthField--
+		}
+	} else if direction == "down" {
+		if m.SelectedAuthField < len(fields)-1 {
+			m.SelectedAuthField++
+		}
+	}
+	return m
+}
+
+// HandleAuthEdit starts editing the selected auth field. Fields with a fixed set of values,
+// such as the type, step to their next value instead.
+func HandleAuthEdit(m types.Model) (types.Model, tea.Cmd) {
+	field, ok := selectedAuthField(m)
+	if !ok {
+		return m, nil
+	}
+	if field.Name == "type" || len(field.Choices) > 0 {
+		return HandleAuthCycle(m), nil
+	}
+
+	m.HeadersMode = types.HeadersEditMode
+	if field.Secret {
+		m.HeaderEditInput.EchoMode = textinput.EchoPassword
+	}
+	m.HeaderEditInput.SetValue(services.AuthFieldValue(m.Auth, field.Name))
+	m.HeaderEditInput.CursorEnd()
+	m.HeaderEditInput.Focus()
+	return m, textinput.Blink
+}
+
+// HandleAuthCycle steps the selected type or choice field to its next value
+func HandleAuthCycle(m types.Model) types.Model {
+	field, ok := selectedAuthField(m)
+	if !ok {
+		return m
+	}
+
+	if field.Name == "type" {
+		next := 0
+		for i, t := range types.AuthTypes {
+			if t == m.Auth.Type {
+				next = (i + 1) % len(types.AuthTypes)
+			}
+		}
+		m.Auth.Type = types.AuthTypes[next]
+		return m
+	}
+
+	if len(field.Choices) == 0 {
+		return m
+	}
+	current := services.AuthFieldValue(m.Auth, field.Name)
+	next := 0
+	for i, choice := range field.Choices {
+		if choice == current {
+			next = (i + 1) % len(field.Choices)
+		}
+	}
+	m.Auth = services.SetAuthField(m.Auth, field.Name, field.Choices[next])
+	return m
+}
+
+// HandleAuthClear empties the selected auth field; on the type it turns auth off
+func HandleAuthClear(m types.Model) types.Model {
+	field, ok := selectedAuthField(m)
+	if !ok {
+		return m
+	}
+	if field.Name == "type" {
+		m.Auth.Type = types.AuthNone
+		m.SelectedAuthField = 0
+		return m
+	}
+	m.Auth = services.SetAuthField(m.Auth, field.Name, "")
+	return m
+}
+
+// HandleAuthEditSave stores the edited auth field
+func HandleAuthEditSave(m types.Model) types.Model {
+	if field, ok := selectedAuthField(m); ok {
+		m.Auth = services.SetAuthField(m.Auth, field.Name, m.HeaderEditInput.Value())
+	}
+	return HandleAuthEditCancel(m)
+}
+
+// HandleAuthEditCancel leaves editing an auth field
+func HandleAuthEditCancel(m types.Model) types.Model {
+	m.HeadersMode = types.HeadersViewMode
+	m.HeaderEditInput.EchoMode = textinput.EchoNormal
+	m.HeaderEditInput.Blur()
+	return m
+}
+
+// selectedAuthField returns the auth field under the cursor
+func selectedAuthField(m types.Model) (services.AuthField, bool) {
+	fields := services.AuthFields(m.Auth)
+	if m.SelectedAuthField < 0 || m.SelectedAuthField >= len(fields) {
+		return services.AuthField{}, false
+	}
+	return fields[m.SelectedAuthField], true
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// ReloadCollections re-reads the collections tree from disk
+func ReloadCollections(m types.Model) types.Model {
+	root, err := services.LoadCollections(m.CollectionsDir)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Collections: %v", err)
+	}
+	m.Collections = root
+	m.CollectionNodes = services.CollectionNodes(&m.Collections, m.CollapsedFolders)
+
+	if m.SelectedCollection >= len(m.CollectionNodes) {
+		m.SelectedCollection = len(m.CollectionNodes) - 1
+	}
+	if m.SelectedCollection < 0 {
+		m.SelectedCollection = 0
+	}
+	return m
+}
+
+// HandleCollectionsNavigation handles up/down navigation in collections pane
+func HandleCollectionsNavigation(m types.Model, direction string) types.Model {
+	if direction == "up" {
+		if m.SelectedCollection > 0 {
+			m.SelectedCollection--
+		}
+	} else if direction == "down" {
+		if m.SelectedCollection < len(m.CollectionNodes)-1 {
+			m.SelectedCollection++
+		}
+	}
+	return m
+}
+
+// HandleCollectionsSelect toggles the selected folder or loads the selected request into the form
+func HandleCollectionsSelect(m types.Model) (types.Model, tea.Cmd) {
+	if len(m.CollectionNodes) == 0 {
+		return m, nil
+	}
+
+	node := m.CollectionNodes[m.SelectedCollection]
+	if node.Folder != nil {
+		if m.CollapsedFolders == nil {
+			m.CollapsedFolders = map[string]bool{}
+		}
+		m.CollapsedFolders[node.Folder.Path] = node.Expanded
+		m.CollectionNodes = services.CollectionNodes(&m.Collections, m.CollapsedFolders)
+		return m, nil
+	}
+
+	m = applyRequestToForm(m, node.Request.Request)
+	m.StatusMessage = fmt.Sprintf("Loaded %s", node.Request.Name)
+
+	return HandleJumpToPane(m, types.URLPane)
+}
+
+// HandleCollectionsSaveStart asks for a name to save the current request under
+func HandleCollectionsSaveStart(m types.Model) (types.Model, tea.Cmd) {
+	m, cmd := HandleJumpToPane(m, types.CollectionsPane)
+	m.CollectionsMode = types.CollectionsSaveMode
+	m.CollectionNameInput.Placeholder = "Request name"
+	m.CollectionNameInput.SetValue(suggestedRequestName(currentRequest(m)))
+	m.CollectionNameInput.CursorEnd()
+	m.CollectionNameInput.Focus()
+	return m, tea.Batch(cmd, textinput.Blink)
+}
+
+// HandleCollectionsFolderStart asks for the name of a new folder
+func HandleCollectionsFolderStart(m types.Model) (types.Model, tea.Cmd) {
+	m.CollectionsMode = types.CollectionsFolderMode
+	m.CollectionNameInput.Placeholder = "Folder name"
+	m.CollectionNameInput.SetValue("")
+	m.CollectionNameInput.Focus()
+	return m, textinput.Blink
+}
+
+// HandleCollectionsInputSubmit saves the request or creates the folder named in the input
+func HandleCollectionsInputSubmit(m types.Model) types.Model {
+	name := strings.TrimSpace(m.CollectionNameInput.Value())
+	mode := m.CollectionsMode
+	m = HandleCollectionsInputCancel(m)
+	if name == "" {
+		return m
+	}
+
+	dir := selectedCollectionFolder(m)
+
+	var path string
+	var err error
+	switch mode {
+	case types.CollectionsSaveMode:
+		saved := types.SavedRequest{Name: name, Request: currentRequest(m), Path: savedRequestPath(m, dir, name)}
+		path, err = services.SaveRequest(dir, saved)
+		if _, redacted := services.RedactSecrets(saved.Request); err == nil && redacted {
+			m.StatusMessage = fmt.Sprintf("Saved %s without its secrets, use {{variables}} to keep them", path)
+		} else if err == nil {
+			m.StatusMessage = fmt.Sprintf("Saved %s", path)
+		}
+	case types.CollectionsFolderMode:
+		path, err = services.CreateCollectionFolder(dir, name)
+		if err == nil {
+			m.StatusMessage = fmt.Sprintf("Created %s", path)
+		}
+	}
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Collections: %v", err)
+		return m
+	}
+
+	m = ReloadCollections(m)
+	m = selectCollectionPath(m, path)
+	return m
+}
+
+// HandleCollectionsInputCancel leaves the name prompt without saving
+func HandleCollectionsInputCancel(m types.Model) types.Model {
+	m.CollectionsMode = types.CollectionsViewMode
+	m.CollectionNameInput.Blur()
+	return m
+}
+
+// HandleCollectionsDelete deletes the selected request file or empty folder
+func HandleCollectionsDelete(m types.Model) types.Model {
+	if len(m.CollectionNodes) == 0 {
+		return m
+	}
+
+	node := m.CollectionNodes[m.SelectedCollection]
+	path := ""
+	if node.Folder != nil {
+		path = node.Folder.Path
+	} else {
+		path = node.Request.Path
+	}
+
+	if err := services.DeleteCollectionEntry(path); err != nil {
+		m.StatusMessage = fmt.Sprintf("Collections: %v", err)
+		return m
+	}
+
+	m.StatusMessage = fmt.Sprintf("Deleted %s", path)
+	return ReloadCollections(m)
+}
+
+// selectedCollectionFolder returns the folder new entries are created in:
+// the selected folder, the folder holding the selected request, or the root
+func selectedCollectionFolder(m types.Model) string {
+	if len(m.CollectionNodes) == 0 {
+		return m.CollectionsDir
+	}
+
+	node := m.CollectionNodes[m.SelectedCollection]
+	if node.Folder != nil {
+		return node.Folder.Path
+	}
+	return filepath.Dir(node.Request.Path)
+}
+
+// savedRequestPath returns the file of the request named name in dir, if there is one
+func savedRequestPath(m types.Model, dir, name string) string {
+	for _, node := range m.CollectionNodes {
+		if node.Request != nil && node.Request.Name == name && filepath.Dir(node.Request.Path) == filepath.Clean(dir) {
+			return node.Request.Path
+		}
+	}
+	return ""
+}
+
+// selectCollectionPath moves the selection to the row for path, if it is visible
+func selectCollectionPath(m types.Model, path string) types.Model {
+	for i, node := range m.CollectionNodes {
+		if (node.Folder != nil && node.Folder.Path == path) || (node.Request != nil && node.Request.Path == path) {
+			m.SelectedCollection = i
+			break
+		}
+	}
+	return m
+}
+
+// suggestedRequestName proposes a name such as "/users" for a request
+func suggestedRequestName(req types.Request) string {
+	if u, err := url.Parse(req.URL); err == nil && u.Path != "" {
+		return u.Path
+	}
+	return req.URL
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/components"
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// HandleCookiesOpen lists the cookie jar in the response pane
+func HandleCookiesOpen(m types.Model) (types.Model, tea.Cmd) {
+	m, cmd := HandleJumpToPane(m, types.ResponsePane)
+	m.BrowsingCookies = true
+	m.SelectedCookie = 0
+	m = refreshCookiesView(m)
+	m.ResponseViewport.GotoTop()
+	return m, cmd
+}
+
+// HandleCookiesNavigation moves the selection in the cookie list
+func HandleCookiesNavigation(m types.Model, direction string) types.Model {
+	if direction == "up" && m.SelectedCookie > 0 {
+		m.SelectedCookie--
+	} else if direction == "down" && m.SelectedCookie < len(m.Cookies)-1 {
+		m.SelectedCookie++
+	}
+	return refreshCookiesView(m)
+}
+
+// HandleCookieEdit starts editing the selected cookie in its Set-Cookie form
+func HandleCookieEdit(m types.Model) (types.Model, tea.Cmd) {
+	if m.SelectedCookie >= len(m.Cookies) {
+		return m, nil
+	}
+	cookie := m.Cookies[m.SelectedCookie]
+	m.EditedCookie = &cookie
+	m.EditingCookie = true
+	m.CookieInput.SetValue(services.CookieLine(cookie))
+	m.CookieInput.CursorEnd()
+	m.CookieInput.Focus()
+	return m, textinput.Blink
+}
+
+// HandleCookieAdd starts writing a new cookie, set for the request's host unless it names
+// a Domain
+func HandleCookieAdd(m types.Model) (types.Model, tea.Cmd) {
+	m.EditedCookie = nil
+	m.EditingCookie = true
+	m.CookieInput.SetValue("")
+	m.CookieInput.Focus()
+	return m, textinput.Blink
+}
+
+// HandleCookieEditSave stores the edited or added cookie; a cookie that does not parse
+// stays in the editor
+func HandleCookieEditSave(m types.Model) types.Model {
+	host := ""
+	if u, err := url.Parse(resolvedURL(m)); err == nil {
+		host = u.Hostname()
+	}
+	if err := services.SetCookie(m.CookieInput.Value(), m.EditedCookie, host); err != nil {
+		m.StatusMessage = err.Error()
+		return m
+	}
+	m.StatusMessage = "Cookie saved"
+	m = HandleCookieEditCancel(m)
+	return refreshCookiesView(m)
+}
+
+// HandleCookieEditCancel leaves the cookie editor
+func HandleCookieEditCancel(m types.Model) types.Model {
+	m.EditingCookie = false
+	m.EditedCookie = nil
+	m.CookieInput.Blur()
+	return m
+}
+
+// HandleCookieDelete removes the selected cookie from the jar
+func HandleCookieDelete(m types.Model) types.Model {
+	if m.SelectedCookie >= len(m.Cookies) {
+		return m
+	}
+	cookie := m.Cookies[m.SelectedCookie]
+	if err := services.DeleteCookie(cookie); err != nil {
+		m.StatusMessage = fmt.Sprintf("Cookies: %v", err)
+	} else {
+		m.StatusMessage = fmt.Sprintf("Deleted cookie %s of %s", cookie.Name, cookie.Domain)
+	}
+	return refreshCookiesView(m)
+}
+
+// HandleCookiesClear removes every cookie of the selected cookie's domain
+func HandleCookiesClear(m types.Model) types.Model {
+	if m.SelectedCookie >= len(m.Cookies) {
+		return m
+	}
+	domain := m.Cookies[m.SelectedCookie].Domain
+	if err := services.ClearCookies(domain); err != nil {
+		m.StatusMessage = fmt.Sprintf("Cookies: %v", err)
+	} else {
+		m.StatusMessage = fmt.Sprintf("Cleared the cookies of %s", domain)
+	}
+	return refreshCookiesView(m)
+}
+
+// HandleCookiesClose leaves the cookie list and shows the response again
+func HandleCookiesClose(m types.Model) types.Model {
+	m = HandleCookieEditCancel(m)
+	m.BrowsingCookies = false
+	m = refreshResponseView(m)
+	m.ResponseViewport.GotoTop()
+	return m
+}
+
+// refreshCookiesView reads the jar again and fills the response viewport with it
+func refreshCookiesView(m types.Model) types.Model {
+	m.Cookies = services.Cookies()
+	if m.SelectedCookie >= len(m.Cookies) {
+		m.SelectedCookie = max(len(m.Cookies)-1, 0)
+	}
+
+	content, line := components.RenderCookies(m.Cookies, m.SelectedCookie)
+	m.ResponseViewport.SetContent(content)
+	if line < m.ResponseViewport.YOffset {
+		m.ResponseViewport.SetYOffset(line)
+	} else if line+1 >= m.ResponseViewport.YOffset+m.ResponseViewport.Height {
+		// Keep the attributes below the cookie in view too
+		m.ResponseViewport.SetYOffset(line - m.ResponseViewport.Height + 2)
+	}
+	return m
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// HandleCurlImportStart opens the cURL import editor in place of the body pane
+func HandleCurlImportStart(m types.Model) (types.Model, tea.Cmd) {
+	m, _ = HandleJumpToPane(m, types.BodyPane)
+	m = blurBodyEditors(m)
+	m.ImportingCurl = true
+	m.CurlInput.Reset()
+	m.CurlInput.Focus()
+	return m, textarea.Blink
+}
+
+// HandleCurlImportCancel closes the cURL import editor without changing the form
+func HandleCurlImportCancel(m types.Model) (types.Model, tea.Cmd) {
+	m.ImportingCurl = false
+	m.CurlInput.Blur()
+	return focusBodyEditor(m)
+}
+
+// HandleCurlImport parses the pasted command and fills the form with it
+func HandleCurlImport(m types.Model) (types.Model, tea.Cmd) {
+	imported, err := services.ParseCurl(m.CurlInput.Value())
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("cURL import failed: %v", err)
+		return m, nil
+	}
+
+	m.ImportingCurl = false
+	m.CurlInput.Blur()
+	m.RequestTimeout = 0
+	m = applyRequestToForm(m, imported.Request)
+
+	if limit := m.URLInput.CharLimit; limit > 0 && len([]rune(imported.Request.URL)) > limit {
+		imported.Unsupported = append(imported.Unsupported, fmt.Sprintf("URL truncated to %d characters", limit))
+	}
+
+	if len(imported.Unsupported) > 0 {
+		m.StatusMessage = fmt.Sprintf("Imported cURL; ignored unsupported options: %s", strings.Join(imported.Unsupported, ", "))
+	} else {
+		m.StatusMessage = "Imported cURL command"
+	}
+
+	return HandleJumpToPane(m, types.URLPane)
+}
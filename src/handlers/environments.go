@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// ReloadEnvironments re-reads the environment files from disk, keeping the active
+// environment selected when it still exists
+func ReloadEnvironments(m types.Model) types.Model {
+	activeName := ""
+	if m.ActiveEnvironment >= 0 && m.ActiveEnvironment < len(m.Environments) {
+		activeName = m.Environments[m.ActiveEnvironment].Name
+	}
+
+	environments, err := services.LoadEnvironments(m.EnvironmentsDir)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Environments: %v", err)
+	}
+	m.Environments = environments
+
+	m.ActiveEnvironment = -1
+	for i, env := range m.Environments {
+		if env.Name == activeName {
+			m.ActiveEnvironment = i
+			break
+		}
+	}
+
+	if m.SelectedEnvironment >= len(m.Environments) {
+		m.SelectedEnvironment = len(m.Environments) - 1
+	}
+	if m.SelectedEnvironment < 0 {
+		m.SelectedEnvironment = 0
+	}
+	return m
+}
+
+// HandleEnvironmentsNavigation handles up/down navigation in environments pane
+func HandleEnvironmentsNavigation(m types.Model, direction string) types.Model {
+	if direction == "up" {
+		if m.SelectedEnvironment > 0 {
+			m.SelectedEnvironment--
+		}
+	} else if direction == "down" {
+		if m.SelectedEnvironment < len(m.Environments)-1 {
+			m.SelectedEnvironment++
+		}
+	}
+	return m
+}
+
+// HandleEnvironmentSelect activates the selected environment, or deactivates it if it is already active
+func HandleEnvironmentSelect(m types.Model) types.Model {
+	if len(m.Environments) == 0 {
+		return m
+	}
+
+	if m.ActiveEnvironment == m.SelectedEnvironment {
+		m.ActiveEnvironment = -1
+		m.StatusMessage = "No environment active"
+		return m
+	}
+
+	m.ActiveEnvironment = m.SelectedEnvironment
+	m.StatusMessage = fmt.Sprintf("Environment: %s", m.Environments[m.ActiveEnvironment].Name)
+	return m
+}
+
+// activeVariables returns the variables of the active environment
+func activeVariables(m types.Model) map[string]string {
+	if m.ActiveEnvironment < 0 || m.ActiveEnvironment >= len(m.Environments) {
+		return map[string]string{}
+	}
+	return m.Environments[m.ActiveEnvironment].Variables
+}
+
+// activeEnvironmentName returns the name of the active environment, or "" when none is active
+func activeEnvironmentName(m types.Model) string {
+	if m.ActiveEnvironment < 0 || m.ActiveEnvironment >= len(m.Environments) {
+		return ""
+	}
+	return m.Environments[m.ActiveEnvironment].Name
+}
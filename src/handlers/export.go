@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// HandleExportStart opens the export preview in the response pane. From the history
+// pane the selected history item is exported, otherwise the request in the form.
+func HandleExportStart(m types.Model) (types.Model, tea.Cmd) {
+	req := currentRequest(m)
+	if m.ActivePane == types.HistoryPane && len(m.History) > 0 {
+		req = m.History[m.SelectedHistory].Request
+	}
+
+	if req.URL == "" {
+		m.StatusMessage = "Nothing to export: the URL is empty"
+		return m, nil
+	}
+	if req.Method == types.WebSocketMethod {
+		m.StatusMessage = "WebSocket connections cannot be exported as code"
+		return m, nil
+	}
+	if req.Method == types.GRPCMethod {
+		m.StatusMessage = "gRPC calls cannot be exported as code"
+		return m, nil
+	}
+
+	// Fill in environment variables; unresolved references are exported as written
+	req, _ = services.InterpolateRequest(req, activeVariables(m))
+	req = services.WithStaticAuth(req)
+
+	m, cmd := HandleJumpToPane(m, types.ResponsePane)
+	m.Exporting = true
+	m.ExportRequest = req
+	m = refreshExportView(m)
+	return m, cmd
+}
+
+// HandleExportFormat switches the export preview to the previous or next format
+func HandleExportFormat(m types.Model, direction string) types.Model {
+	if direction == "left" {
+		if m.ExportFormat > 0 {
+			m.ExportFormat--
+		}
+	} else if direction == "right" {
+		if m.ExportFormat < len(services.SnippetFormats)-1 {
+			m.ExportFormat++
+		}
+	}
+	return refreshExportView(m)
+}
+
+// HandleExportCopy copies the previewed snippet to the clipboard
+func HandleExportCopy(m types.Model) types.Model {
+	format := services.SnippetFormats[m.ExportFormat]
+	if err := services.CopyToClipboard(format.Generate(m.ExportRequest)); err != nil {
+		m.StatusMessage = fmt.Sprintf("Clipboard unavailable (%v); press w to write a file instead", err)
+		return m
+	}
+	m.StatusMessage = fmt.Sprintf("Copied %s snippet to clipboard", format.Name)
+	return HandleExportClose(m)
+}
+
+// HandleExportWrite writes the previewed snippet to a file in the working directory,
+// leaving an existing file alone
+func HandleExportWrite(m types.Model) types.Model {
+	format := services.SnippetFormats[m.ExportFormat]
+	path := format.FileName()
+	if err := services.WriteSnippet(path, format.Generate(m.ExportRequest)); err != nil {
+		m.StatusMessage = fmt.Sprintf("Export failed: %v", err)
+		return m
+	}
+	m.StatusMessage = fmt.Sprintf("Wrote %s snippet to %s", format.Name, path)
+	return HandleExportClose(m)
+}
+
+// HandleExportClose leaves the export preview and shows the response again
+func HandleExportClose(m types.Model) types.Model {
+	m.Exporting = false
+	m = refreshResponseView(m)
+	m.ResponseViewport.GotoTop()
+	return m
+}
+
+// refreshExportView fills the response viewport with the snippet for the selected format
+func refreshExportView(m types.Model) types.Model {
+	format := services.SnippetFormats[m.ExportFormat]
+	m.ResponseViewport.SetContent(format.Generate(m.ExportRequest))
+	m.ResponseViewport.GotoTop()
+	return m
+}
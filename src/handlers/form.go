@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// bodyFormMode reports whether the body pane shows the form field editor
+func bodyFormMode(m types.Model) bool {
+	return !m.ImportingCurl && m.BodyTab == types.RequestBodyTab && services.IsFormContentType(types.ContentTypes[m.SelectedHeader])
+}
+
+// HandleFormNavigation handles up/down navigation in the form field list
+func HandleFormNavigation(m types.Model, direction string) types.Model {
+	if direction == "up" {
+		if m.SelectedFormField > 0 {
+			m.SelectedFormField--
+		}
+	} else if direction == "down" {
+		if m.SelectedFormField < len(m.FormFields)-1 {
+			m.SelectedFormField++
+		}
+	}
+	return m
+}
+
+// HandleFormAdd appends an empty field and starts editing it
+func HandleFormAdd(m types.Model) (types.Model, tea.Cmd) {
+	m.FormFields = append(m.FormFields, types.FormField{})
+	m.SelectedFormField = len(m.FormFields) - 1
+	return HandleFormEdit(m)
+}
+
+// HandleFormEdit starts editing the selected field in curl's -F syntax
+func HandleFormEdit(m types.Model) (types.Model, tea.Cmd) {
+	if len(m.FormFields) == 0 {
+		return m, nil
+	}
+
+	field := m.FormFields[m.SelectedFormField]
+	value := ""
+	if field.Key != "" {
+		value = services.FormFieldSpec(field)
+	}
+
+	m.EditingFormField = true
+	m.FormFieldInput.SetValue(value)
+	m.FormFieldInput.CursorEnd()
+	m.FormFieldInput.Focus()
+	return m, textinput.Blink
+}
+
+// HandleFormEditSave parses the edited field, keeping the editor open if it is invalid
+func HandleFormEditSave(m types.Model) types.Model {
+	if len(m.FormFields) == 0 {
+		return HandleFormEditCancel(m)
+	}
+
+	field, err := services.ParseFormField(m.FormFieldInput.Value())
+	if err != nil {
+		m.StatusMessage = err.Error()
+		return m
+	}
+	if field.File && types.ContentTypes[m.SelectedHeader] != services.FormMultipart {
+		m.StatusMessage = fmt.Sprintf("File fields need %s", services.FormMultipart)
+		return m
+	}
+
+	field.Disabled = m.FormFields[m.SelectedFormField].Disabled
+	m.FormFields[m.SelectedFormField] = field
+	m.StatusMessage = ""
+	m.EditingFormField = false
+	m.FormFieldInput.Blur()
+	return m
+}
+
+// HandleFormEditCancel leaves editing, dropping a field that was added but never filled in
+func HandleFormEditCancel(m types.Model) types.Model {
+	m.EditingFormField = false
+	m.FormFieldInput.Blur()
+	if len(m.FormFields) > 0 && m.FormFields[m.SelectedFormField].Key == "" {
+		m = HandleFormDelete(m)
+	}
+	return m
+}
+
+// HandleFormDelete removes the selected field
+func HandleFormDelete(m types.Model) types.Model {
+	if len(m.FormFields) == 0 {
+		return m
+	}
+	m.FormFields = append(m.FormFields[:m.SelectedFormField], m.FormFields[m.SelectedFormField+1:]...)
+	if m.SelectedFormField >= len(m.FormFields) && len(m.FormFields) > 0 {
+		m.SelectedFormField = len(m.FormFields) - 1
+	}
+	if len(m.FormFields) == 0 {
+		m.SelectedFormField = 0
+	}
+	return m
+}
+
+// HandleFormToggle enables or disables the selected field
+func HandleFormToggle(m types.Model) types.Model {
+	if len(m.FormFields) > 0 {
+		m.FormFields[m.SelectedFormField].Disabled = !m.FormFields[m.SelectedFormField].Disabled
+	}
+	return m
+}
+
+// HandleFormMove moves the selected field up or down
+func HandleFormMove(m types.Model, direction string) types.Model {
+	i := m.SelectedFormField
+	j := i - 1
+	if direction == "down" {
+		j = i + 1
+	}
+	if len(m.FormFields) == 0 || j < 0 || j >= len(m.FormFields) {
+		return m
+	}
+
+	m.FormFields[i], m.FormFields[j] = m.FormFields[j], m.FormFields[i]
+	m.SelectedFormField = j
+	return m
+}
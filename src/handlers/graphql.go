@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/components"
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// bodyGraphQLMode reports whether the body pane shows the GraphQL editors
+func bodyGraphQLMode(m types.Model) bool {
+	return !m.ImportingCurl && m.BodyTab == types.RequestBodyTab && types.ContentTypes[m.SelectedHeader] == services.GraphQLContentType
+}
+
+// focusBodyEditor focuses the editor the body pane shows
+func focusBodyEditor(m types.Model) (types.Model, tea.Cmd) {
+	if bodyScriptMode(m) {
+		if m.BodyTab == types.PreScriptTab {
+			m.PreScriptInput.Focus()
+		} else {
+			m.PostScriptInput.Focus()
+		}
+		return m, textarea.Blink
+	}
+	if bodyGraphQLMode(m) {
+		switch m.GraphQLEditor {
+		case types.GraphQLVariablesEditor:
+			m.GraphQLVariables.Focus()
+			return m, textarea.Blink
+		case types.GraphQLOperationEditor:
+			m.GraphQLOperation.Focus()
+			return m, textinput.Blink
+		}
+	}
+	m.BodyInput.Focus()
+	return m, textarea.Blink
+}
+
+// blurBodyEditors blurs the body input, the GraphQL editors and the script editors
+func blurBodyEditors(m types.Model) types.Model {
+	m.BodyInput.Blur()
+	m.PreScriptInput.Blur()
+	m.PostScriptInput.Blur()
+	m.GraphQLVariables.Blur()
+	m.GraphQLOperation.Blur()
+	m.GraphQLCompletion = nil
+	return m
+}
+
+// HandleGraphQLEditorSwitch cycles between the query, variables and operation name editors
+func HandleGraphQLEditorSwitch(m types.Model) (types.Model, tea.Cmd) {
+	m = blurBodyEditors(m)
+	m.GraphQLEditor = (m.GraphQLEditor + 1) % (types.GraphQLOperationEditor + 1)
+	return focusBodyEditor(m)
+}
+
+// HandleGraphQLIntrospect fetches the schema of the endpoint in the URL for completion
+func HandleGraphQLIntrospect(m types.Model) (types.Model, tea.Cmd) {
+	if m.URLInput.Value() == "" {
+		m.StatusMessage = "Enter the GraphQL endpoint URL to load its schema"
+		return m, nil
+	}
+
+	// Introspection is sent to the same URL with the same headers and auth, but its own query
+	req := currentRequest(m)
+	resolved, unresolved := services.InterpolateRequest(types.Request{URL: req.URL, Headers: req.Headers, Auth: req.Auth, TLS: req.TLS}, activeVariables(m))
+	if len(unresolved) > 0 {
+		m.StatusMessage = fmt.Sprintf("Unresolved variables: %s", strings.Join(unresolved, ", "))
+		return m, nil
+	}
+	resolved.Timeout = effectiveTimeout(m, req)
+	resolved.TLS = services.MergeTLS(m.Config.TLS, resolved.TLS)
+	resolved.Proxy = services.ProxySettingsFor(m.Config, activeEnvironmentName(m))
+
+	m.StatusMessage = "Loading GraphQL schema..."
+	return m, services.IntrospectGraphQL(context.Background(), resolved)
+}
+
+// HandleGraphQLSchema stores an introspected schema, or reports why it could not be loaded
+func HandleGraphQLSchema(m types.Model, msg types.GraphQLSchemaMsg) types.Model {
+	if msg.Err != nil {
+		m.StatusMessage = fmt.Sprintf("GraphQL schema: %v", msg.Err)
+		return m
+	}
+
+	m.GraphQLSchema = msg.Schema
+	m.GraphQLSchemaURL = msg.URL
+	m.StatusMessage = fmt.Sprintf("Loaded GraphQL schema with %d types", len(msg.Schema.Types))
+	return m
+}
+
+// HandleGraphQLComplete completes the word before the cursor in the query editor. Pressing
+// it again replaces the completion with the next candidate. Without a schema for the URL,
+// the schema is loaded first.
+func HandleGraphQLComplete(m types.Model) (types.Model, tea.Cmd) {
+	if m.GraphQLEditor != types.GraphQLQueryEditor {
+		return m, nil
+	}
+	if m.GraphQLSchema == nil || m.GraphQLSchemaURL != resolvedURL(m) {
+		return HandleGraphQLIntrospect(m)
+	}
+
+	completion := m.GraphQLCompletion
+	removed := ""
+	if completion != nil {
+		removed = completion.Inserted
+		completion.Index = (completion.Index + 1) % len(completion.Candidates)
+	} else {
+		prefix, candidates := services.GraphQLCompletions(m.GraphQLSchema, components.TextBeforeCursor(m.BodyInput))
+		if len(candidates) == 0 {
+			m.StatusMessage = "No completions"
+			return m, nil
+		}
+		removed = prefix
+		completion = &types.GraphQLCompletion{Candidates: candidates}
+	}
+
+	for range []rune(removed) {
+		m.BodyInput, _ = m.BodyInput.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	completion.Inserted = completion.Candidates[completion.Index]
+	m.BodyInput.InsertString(completion.Inserted)
+	m.GraphQLCompletion = completion
+	m.StatusMessage = ""
+	return m, nil
+}
+
+// resolvedURL returns the URL with environment variables substituted
+func resolvedURL(m types.Model) string {
+	url, _ := services.Interpolate(m.URLInput.Value(), activeVariables(m))
+	return url
+}
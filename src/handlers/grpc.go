@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/components"
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// isGRPCMode reports whether GRPC is selected in the Method pane
+func isGRPCMode(m types.Model) bool {
+	return types.Methods[m.SelectedMethod] == types.GRPCMethod
+}
+
+// HandleGRPCStarted shows the response headers of a call and starts receiving its messages.
+// The request stays in flight until the call ends, so Ctrl+X cancels it.
+func HandleGRPCStarted(m types.Model, msg types.GRPCStartedMsg) (types.Model, tea.Cmd) {
+	meta := msg.Meta
+	m.GRPCCall = msg.Call
+	m.GRPCReplies = nil
+	m.StatusCode = 0
+	m.ResponseHeaders = msg.Headers
+	m.ResponseMeta = &meta
+	m.ResponseTab = types.ResponseBodyTab
+	m = setResponseBody(m, components.RenderGRPCResponse(nil, nil))
+	return m, services.WaitGRPC(msg.Call)
+}
+
+// HandleGRPCReply adds a response message and waits for the next one
+func HandleGRPCReply(m types.Model, msg types.GRPCReplyMsg) (types.Model, tea.Cmd) {
+	if msg.Call != m.GRPCCall {
+		return m, services.WaitGRPC(msg.Call)
+	}
+
+	following := m.ResponseViewport.AtBottom()
+	m.GRPCReplies = append(m.GRPCReplies, msg.Reply)
+	m.ResponseBody = components.RenderGRPCResponse(m.GRPCReplies, nil)
+	m = refreshResponseView(m)
+	if following && m.ResponseTab == types.ResponseBodyTab {
+		m.ResponseViewport.GotoBottom()
+	}
+	return m, services.WaitGRPC(msg.Call)
+}
+
+// HandleGRPCEnded shows the status and trailers of a finished call and records it in history
+func HandleGRPCEnded(m types.Model, msg types.GRPCEndedMsg) types.Model {
+	if msg.Call != m.GRPCCall {
+		return m
+	}
+
+	m.Executing = false
+	if m.CancelRequest != nil {
+		m.CancelRequest()
+		m.CancelRequest = nil
+	}
+
+	result := msg.Call.Result()
+	m.GRPCCall = nil
+	if m.ResponseMeta != nil {
+		meta := *m.ResponseMeta
+		meta.GRPCStatus = result.Code
+		meta.Status = fmt.Sprintf("%s (%d)", result.Code, result.Number)
+		m.ResponseMeta = &meta
+	}
+	m = setResponseBody(m, components.RenderGRPCResponse(m.GRPCReplies, &result))
+	m.StatusMessage = ""
+
+	if m.PendingRequest != nil {
+		item := *m.PendingRequest
+		item.ResponseBody = m.ResponseBody
+		item.ResponseHeaders = m.ResponseHeaders
+		item.ResponseMeta = m.ResponseMeta
+		m = AddToHistory(m, item)
+		m.PendingRequest = nil
+	}
+	return m
+}
+
+// HandleGRPCListMethods asks the server in the URL for its methods
+func HandleGRPCListMethods(m types.Model) (types.Model, tea.Cmd) {
+	if m.URLInput.Value() == "" {
+		m.StatusMessage = "Enter the server address, e.g. grpc://localhost:50051"
+		return m, nil
+	}
+
+	req := currentRequest(m)
+	resolved, unresolved := services.InterpolateRequest(types.Request{URL: req.URL, Headers: req.Headers, TLS: req.TLS}, activeVariables(m))
+	if len(unresolved) > 0 {
+		m.StatusMessage = fmt.Sprintf("Unresolved variables: %s", strings.Join(unresolved, ", "))
+		return m, nil
+	}
+	resolved.Timeout = effectiveTimeout(m, req)
+	resolved.TLS = services.MergeTLS(m.Config.TLS, resolved.TLS)
+
+	m.StatusMessage = "Listing gRPC methods..."
+	return m, services.ListGRPCMethods(context.Background(), resolved, m.Config.ProtoFiles, m.Config.ProtoImportPaths)
+}
+
+// HandleGRPCMethods lists the methods found in the response pane for picking
+func HandleGRPCMethods(m types.Model, msg types.GRPCMethodsMsg) (types.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.StatusMessage = fmt.Sprintf("gRPC methods: %v", msg.Err)
+		return m, nil
+	}
+	if len(msg.Methods) == 0 {
+		m.StatusMessage = fmt.Sprintf("No gRPC methods found through %s", msg.Source)
+		return m, nil
+	}
+
+	m, cmd := HandleJumpToPane(m, types.ResponsePane)
+	m.GRPCMethods = msg.Methods
+	m.SelectedGRPCMethod = 0
+	m.BrowsingGRPC = true
+	m.StatusMessage = fmt.Sprintf("%d methods found through %s", len(msg.Methods), msg.Source)
+	m = refreshGRPCMethodsView(m)
+	return m, cmd
+}
+
+// HandleGRPCMethodsNavigation moves the selection in the method list
+func HandleGRPCMethodsNavigation(m types.Model, direction string) types.Model {
+	if direction == "up" && m.SelectedGRPCMethod > 0 {
+		m.SelectedGRPCMethod--
+	} else if direction == "down" && m.SelectedGRPCMethod < len(m.GRPCMethods)-1 {
+		m.SelectedGRPCMethod++
+	}
+	return refreshGRPCMethodsView(m)
+}
+
+// HandleGRPCMethodSelect puts the selected method in the URL and its request template in the body
+func HandleGRPCMethodSelect(m types.Model) (types.Model, tea.Cmd) {
+	method := m.GRPCMethods[m.SelectedGRPCMethod]
+
+	base := m.URLInput.Value()
+	if i := strings.Index(base, "://"); i >= 0 {
+		if j := strings.Index(base[i+3:], "/"); j >= 0 {
+			base = base[:i+3+j]
+		}
+	} else if j := strings.Index(base, "/"); j >= 0 {
+		base = base[:j]
+	}
+	m.URLInput.SetValue(base + method.FullName)
+	m.URLInput.CursorEnd()
+	m.BodyInput.SetValue(method.Template)
+
+	m = HandleGRPCMethodsClose(m)
+	m.StatusMessage = "Selected " + method.FullName
+	return HandleJumpToPane(m, types.BodyPane)
+}
+
+// HandleGRPCMethodsClose closes the method list
+func HandleGRPCMethodsClose(m types.Model) types.Model {
+	m.BrowsingGRPC = false
+	m = refreshResponseView(m)
+	m.ResponseViewport.GotoTop()
+	return m
+}
+
+// refreshGRPCMethodsView fills the response viewport with the method list
+func refreshGRPCMethodsView(m types.Model) types.Model {
+	m.ResponseViewport.SetContent(components.RenderGRPCMethods(m.GRPCMethods, m.SelectedGRPCMethod))
+	if m.SelectedGRPCMethod < m.ResponseViewport.YOffset {
+		m.ResponseViewport.SetYOffset(m.SelectedGRPCMethod)
+	} else if m.SelectedGRPCMethod >= m.ResponseViewport.YOffset+m.ResponseViewport.Height {
+		m.ResponseViewport.SetYOffset(m.SelectedGRPCMethod - m.ResponseViewport.Height + 1)
+	}
+	return m
+}
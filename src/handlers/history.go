@@ -6,6 +6,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"postty/src/services"
 	"postty/src/types"
 )
 
@@ -32,43 +33,91 @@ func HandleHistoryLoad(m types.Model) (types.Model, tea.Cmd) {
 
 	item := m.History[m.SelectedHistory]
 
-	// Set URL
-	m.URLInput.SetValue(item.URL)
+	// Fill the form with the stored request
+	m = applyRequestToForm(m, item.Request)
+
+	// Set response, the console output of its scripts and its test results
+	m.ScriptLog = item.ScriptLog
+	m.TestResults = item.TestResults
+	if item.ResponseBody != "" {
+		m.StatusCode = item.StatusCode
+		m.ResponseHeaders = item.ResponseHeaders
+		m.ResponseMeta = item.ResponseMeta
+		m = setResponseBody(m, responseBodyView(item.Request, item.ResponseBody))
+	}
+
+	return HandleJumpToPane(m, types.URLPane)
+}
+
+// applyRequestToForm copies a stored request into the request form
+func applyRequestToForm(m types.Model, req types.Request) types.Model {
+	// Set URL and its params, including any that were disabled
+	m.URLInput.SetValue(req.URL)
+	if len(req.Params) > 0 {
+		m.QueryParams = make([]types.QueryParam, len(req.Params))
+		copy(m.QueryParams, req.Params)
+	} else {
+		m.QueryParams = services.ParseQueryParams(req.URL)
+	}
+	m.SelectedParam = 0
 
 	// Set method
-	for i, method := range types.HTTPMethods {
-		if method == item.Method {
+	for i, method := range types.Methods {
+		if method == req.Method {
 			m.SelectedMethod = i
 			break
 		}
 	}
 
-	// Set body
-	m.BodyInput.SetValue(item.Body)
+	// Set body, and the GraphQL editors that go with it
+	m.BodyInput.SetValue(req.Body)
+	m.GraphQLVariables.SetValue(req.GraphQLVariables)
+	m.GraphQLOperation.SetValue(req.GraphQLOperation)
+
+	// Set scripts
+	m.PreScriptInput.SetValue(req.PreScript)
+	m.PostScriptInput.SetValue(req.PostScript)
 
 	// Set content type
 	for i, ct := range types.ContentTypes {
-		if ct == item.ContentType {
+		if ct == req.ContentType {
 			m.SelectedHeader = i
 			break
 		}
 	}
 
+	// Set form fields, recovering them from the raw body for requests saved without them
+	if len(req.Form) > 0 {
+		m.FormFields = make([]types.FormField, len(req.Form))
+		copy(m.FormFields, req.Form)
+	} else {
+		m.FormFields = services.ParseFormBody(req.Body, req.ContentType)
+	}
+	m.SelectedFormField = 0
+
 	// Set custom headers
-	m.CustomHeaders = make([]types.Header, len(item.Headers))
-	copy(m.CustomHeaders, item.Headers)
+	m.CustomHeaders = make([]types.Header, len(req.Headers))
+	copy(m.CustomHeaders, req.Headers)
+	m.SelectedCustomHeader = 0
 
-	// Set response
-	if item.ResponseBody != "" {
-		m.ResponseViewport.SetContent(item.ResponseBody)
-		m.StatusCode = item.StatusCode
-	}
+	// Set timeout
+	m.RequestTimeout = req.Timeout
 
-	// Switch to URL pane
-	m.ActivePane = types.URLPane
-	m.URLInput.Focus()
+	// Set assertions
+	m.Assertions = make([]types.Assertion, len(req.Assertions))
+	copy(m.Assertions, req.Assertions)
+	m.SelectedAssertion = 0
 
-	return m, nil
+	// Set auth
+	m.Auth = req.Auth
+	m.SelectedAuthField = 0
+
+	// Set TLS settings and cookie handling
+	m.TLS = req.TLS
+	m.NoCookies = req.NoCookies
+	m.Redirects = req.Redirects
+
+	return m
 }
 
 // HandleHistoryDelete deletes the selected history item
@@ -77,8 +126,17 @@ func HandleHistoryDelete(m types.Model) types.Model {
 		return m
 	}
 
-	// Remove the selected item
+	// Remove the selected item, keeping the marks of the others
 	m.History = append(m.History[:m.SelectedHistory], m.History[m.SelectedHistory+1:]...)
+	m = shiftHistoryMarks(m, m.SelectedHistory, -1)
+
+	// Rewrite the log so the deleted item does not come back on the next start
+	if m.HistoryPath != "" {
+		if err := services.SaveHistory(m.HistoryPath, m.History); err != nil {
+			m.StatusMessage = fmt.Sprintf("Could not save history: %v", err)
+		}
+		m.HistoryLogEntries = len(m.History)
+	}
 
 	// Adjust selection
 	if m.SelectedHistory >= len(m.History) && len(m.History) > 0 {
@@ -91,33 +149,41 @@ func HandleHistoryDelete(m types.Model) types.Model {
 	return m
 }
 
-// AddToHistory adds a request to the history
-func AddToHistory(m types.Model, method, url, body, contentType string, headers []types.Header, statusCode int, responseBody string) types.Model {
+// AddToHistory adds a completed request to the history
+func AddToHistory(m types.Model, item types.HistoryItem) types.Model {
 	// Create timestamp
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	if item.Timestamp == "" {
+		item.Timestamp = time.Now().Format("2006-01-02 15:04:05")
+	}
 
 	// Copy headers
-	headersCopy := make([]types.Header, len(headers))
-	copy(headersCopy, headers)
-
-	// Create history item
-	item := types.HistoryItem{
-		Method:       method,
-		URL:          url,
-		Body:         body,
-		ContentType:  contentType,
-		Headers:      headersCopy,
-		StatusCode:   statusCode,
-		Timestamp:    timestamp,
-		ResponseBody: responseBody,
-	}
+	item.Headers = append([]types.Header(nil), item.Headers...)
 
 	// Add to beginning of history (most recent first)
 	m.History = append([]types.HistoryItem{item}, m.History...)
 
-	// Limit history to 50 items
-	if len(m.History) > 50 {
-		m.History = m.History[:50]
+	// Limit history to the configured retention
+	limit := m.Config.HistoryLimit
+	if limit <= 0 {
+		limit = types.DefaultHistoryLimit
+	}
+	if len(m.History) > limit {
+		m.History = m.History[:limit]
+	}
+	m = shiftHistoryMarks(m, 0, 1)
+
+	// Persist the new item so history survives restarts. Items that dropped off the end
+	// stay in the log until it holds twice the limit, then it is rewritten in one go.
+	if m.HistoryPath != "" {
+		err := services.AppendHistory(m.HistoryPath, item)
+		m.HistoryLogEntries++
+		if err == nil && m.HistoryLogEntries > 2*limit {
+			err = services.SaveHistory(m.HistoryPath, m.History)
+			m.HistoryLogEntries = len(m.History)
+		}
+		if err != nil {
+			m.StatusMessage = fmt.Sprintf("Could not save history: %v", err)
+		}
 	}
 
 	// Reset selection to the newest item
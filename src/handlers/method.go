@@ -13,7 +13,7 @@ func HandleMethodNavigation(m types.Model, direction string) types.Model {
 			m.SelectedMethod--
 		}
 	} else if direction == "down" {
-		if m.SelectedMethod < len(types.HTTPMethods)-1 {
+		if m.SelectedMethod < len(types.Methods)-1 {
 			m.SelectedMethod++
 		}
 	}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 
@@ -11,21 +10,39 @@ import (
 // HandleTab handles Tab key navigation
 func HandleTab(m types.Model) (types.Model, tea.Cmd) {
 	m.ActivePane++
-	if m.ActivePane > types.HistoryPane {
+	if m.ActivePane > types.EnvironmentsPane {
 		m.ActivePane = types.URLPane
 	}
 
 	m.URLInput.Blur()
-	m.BodyInput.Blur()
+	m = blurBodyEditors(m)
 	m.HeaderEditInput.Blur()
 	m.HeadersMode = types.HeadersViewMode
+	m.CollectionNameInput.Blur()
+	m.CollectionsMode = types.CollectionsViewMode
+	m.CurlInput.Blur()
+	m.ImportingCurl = false
+	if m.EditingFormField {
+		m = HandleFormEditCancel(m)
+	}
+	if m.EditingMaxRedirects {
+		m = HandleMaxRedirectsEditCancel(m)
+	}
+	if m.Exporting {
+		m = HandleExportClose(m)
+	}
+	if m.BrowsingGRPC {
+		m = HandleGRPCMethodsClose(m)
+	}
+	if m.BrowsingCookies {
+		m = HandleCookiesClose(m)
+	}
 
 	if m.ActivePane == types.URLPane {
 		m.URLInput.Focus()
 		return m, textinput.Blink
 	} else if m.ActivePane == types.BodyPane {
-		m.BodyInput.Focus()
-		return m, textarea.Blink
+		return focusBodyEditor(m)
 	}
 	return m, nil
 }
@@ -34,20 +51,38 @@ func HandleTab(m types.Model) (types.Model, tea.Cmd) {
 func HandleShiftTab(m types.Model) (types.Model, tea.Cmd) {
 	m.ActivePane--
 	if m.ActivePane < types.URLPane {
-		m.ActivePane = types.HistoryPane
+		m.ActivePane = types.EnvironmentsPane
 	}
 
 	m.URLInput.Blur()
-	m.BodyInput.Blur()
+	m = blurBodyEditors(m)
 	m.HeaderEditInput.Blur()
 	m.HeadersMode = types.HeadersViewMode
+	m.CollectionNameInput.Blur()
+	m.CollectionsMode = types.CollectionsViewMode
+	m.CurlInput.Blur()
+	m.ImportingCurl = false
+	if m.EditingFormField {
+		m = HandleFormEditCancel(m)
+	}
+	if m.EditingMaxRedirects {
+		m = HandleMaxRedirectsEditCancel(m)
+	}
+	if m.Exporting {
+		m = HandleExportClose(m)
+	}
+	if m.BrowsingGRPC {
+		m = HandleGRPCMethodsClose(m)
+	}
+	if m.BrowsingCookies {
+		m = HandleCookiesClose(m)
+	}
 
 	if m.ActivePane == types.URLPane {
 		m.URLInput.Focus()
 		return m, textinput.Blink
 	} else if m.ActivePane == types.BodyPane {
-		m.BodyInput.Focus()
-		return m, textarea.Blink
+		return focusBodyEditor(m)
 	}
 	return m, nil
 }
@@ -56,16 +91,34 @@ func HandleShiftTab(m types.Model) (types.Model, tea.Cmd) {
 func HandleJumpToPane(m types.Model, pane types.Pane) (types.Model, tea.Cmd) {
 	m.ActivePane = pane
 	m.URLInput.Blur()
-	m.BodyInput.Blur()
+	m = blurBodyEditors(m)
 	m.HeaderEditInput.Blur()
 	m.HeadersMode = types.HeadersViewMode
+	m.CollectionNameInput.Blur()
+	m.CollectionsMode = types.CollectionsViewMode
+	m.CurlInput.Blur()
+	m.ImportingCurl = false
+	if m.EditingFormField {
+		m = HandleFormEditCancel(m)
+	}
+	if m.EditingMaxRedirects {
+		m = HandleMaxRedirectsEditCancel(m)
+	}
+	if m.Exporting {
+		m = HandleExportClose(m)
+	}
+	if m.BrowsingGRPC {
+		m = HandleGRPCMethodsClose(m)
+	}
+	if m.BrowsingCookies {
+		m = HandleCookiesClose(m)
+	}
 
 	if m.ActivePane == types.URLPane {
 		m.URLInput.Focus()
 		return m, textinput.Blink
 	} else if m.ActivePane == types.BodyPane {
-		m.BodyInput.Focus()
-		return m, textarea.Blink
+		return focusBodyEditor(m)
 	}
 	return m, nil
 }
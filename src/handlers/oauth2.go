@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/components"
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// HandleOAuth2Fetch gets a new OAuth2 token for the request's auth: the authorization code
+// grant opens the sign-in page in the browser, the other grants ask the token endpoint
+func HandleOAuth2Fetch(m types.Model) (types.Model, tea.Cmd) {
+	if m.Auth.Type != types.AuthOAuth2 {
+		return m, nil
+	}
+
+	// The token endpoint is reached with the TLS and proxy settings of the request
+	resolved, unresolved := services.InterpolateRequest(types.Request{Auth: m.Auth, TLS: m.TLS, NoCookies: m.NoCookies}, activeVariables(m))
+	if len(unresolved) > 0 {
+		m.StatusMessage = fmt.Sprintf("OAuth2: unresolved variables: %s", strings.Join(unresolved, ", "))
+		return m, nil
+	}
+	resolved.TLS = services.MergeTLS(m.Config.TLS, resolved.TLS)
+	resolved.Proxy = services.ProxySettingsFor(m.Config, activeEnvironmentName(m))
+
+	if resolved.Auth.Grant != services.OAuth2AuthorizationCode {
+		m.StatusMessage = "OAuth2: fetching a token..."
+		return m, services.FetchOAuth2Token(resolved)
+	}
+
+	if m.OAuth2Login != nil {
+		m.OAuth2Login.Close()
+	}
+	login, err := services.StartOAuth2Login(resolved)
+	if err != nil {
+		m.OAuth2Login = nil
+		m.StatusMessage = err.Error()
+		return m, nil
+	}
+	m.OAuth2Login = login
+	m.ResponseTab = types.ResponseBodyTab
+	copied := services.CopyToClipboard(login.URL()) == nil
+	m = setResponseBody(m, components.RenderOAuth2Login(login.URL(), m.ResponseViewport.Width, copied))
+	m.StatusMessage = "OAuth2: sign in with the browser"
+	return m, services.WaitOAuth2Login(login)
+}
+
+// HandleOAuth2Token reports a token fetched from the Auth tab; it is already cached for sending
+func HandleOAuth2Token(m types.Model, msg types.OAuth2TokenMsg) types.Model {
+	if msg.Login != nil {
+		if msg.Login != m.OAuth2Login {
+			return m
+		}
+		m.OAuth2Login = nil
+	}
+
+	if msg.Err != nil {
+		m.StatusMessage = msg.Err.Error()
+		if msg.Login != nil {
+			m = setResponseBody(m, "Sign-in failed: "+msg.Err.Error())
+		}
+		return m
+	}
+
+	validity := "that does not expire"
+	if !msg.Token.Expiry.IsZero() {
+		validity = "valid for " + time.Until(msg.Token.Expiry).Round(time.Second).String()
+	}
+	m.StatusMessage = fmt.Sprintf("OAuth2: got a token %s", validity)
+	if msg.Login != nil {
+		m = setResponseBody(m, "Signed in. Requests with this auth now send the token.")
+	}
+	return m
+}
+
+// HandleOAuth2LoginCancel stops waiting for the browser to finish signing in
+func HandleOAuth2LoginCancel(m types.Model) types.Model {
+	if m.OAuth2Login == nil {
+		return m
+	}
+	m.OAuth2Login.Close()
+	return m
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// HandleHeadersPaneTab switches between the custom headers, query params, auth and assertions tabs
+func HandleHeadersPaneTab(m types.Model, direction string) types.Model {
+	if direction == "left" && m.HeadersPaneTab > types.CustomHeadersTab {
+		m.HeadersPaneTab--
+	} else if direction == "right" && m.HeadersPaneTab < types.AssertionsTab {
+		m.HeadersPaneTab++
+	}
+	return m
+}
+
+// SyncParamsFromURL re-reads the query params after the URL was edited
+func SyncParamsFromURL(m types.Model) types.Model {
+	m.QueryParams = services.MergeQueryParams(m.QueryParams, services.ParseQueryParams(m.URLInput.Value()))
+	m = clampSelectedParam(m)
+	return m
+}
+
+// syncURLFromParams rewrites the URL's query from the params list
+func syncURLFromParams(m types.Model) types.Model {
+	m.URLInput.SetValue(services.SetQueryParams(m.URLInput.Value(), m.QueryParams))
+	m.URLInput.CursorEnd()
+	return m
+}
+
+// HandleParamsNavigation handles up/down navigation in the params list
+func HandleParamsNavigation(m types.Model, direction string) types.Model {
+	if direction == "up" {
+		if m.SelectedParam > 0 {
+			m.SelectedParam--
+		}
+	} else if direction == "down" {
+		if m.SelectedParam < len(m.QueryParams)-1 {
+			m.SelectedParam++
+		}
+	}
+	return m
+}
+
+// HandleParamsAdd appends an empty param and starts editing it
+func HandleParamsAdd(m types.Model) (types.Model, tea.Cmd) {
+	m.QueryParams = append(m.QueryParams, types.QueryParam{})
+	m.SelectedParam = len(m.QueryParams) - 1
+	return HandleParamsEdit(m)
+}
+
+// HandleParamsEdit starts editing the selected param as key=value
+func HandleParamsEdit(m types.Model) (types.Model, tea.Cmd) {
+	if len(m.QueryParams) == 0 {
+		return m, nil
+	}
+
+	param := m.QueryParams[m.SelectedParam]
+	value := param.Key
+	if param.Value != "" || param.Equals {
+		value += "=" + param.Value
+	}
+
+	m.HeadersMode = types.HeadersEditMode
+	m.HeaderEditInput.SetValue(value)
+	m.HeaderEditInput.CursorEnd()
+	m.HeaderEditInput.Focus()
+	return m, textinput.Blink
+}
+
+// HandleParamEditSave stores the edited key=value and updates the URL
+func HandleParamEditSave(m types.Model) types.Model {
+	m.HeadersMode = types.HeadersViewMode
+	m.HeaderEditInput.Blur()
+	if len(m.QueryParams) == 0 {
+		return m
+	}
+
+	key, value, equals := strings.Cut(m.HeaderEditInput.Value(), "=")
+	param := &m.QueryParams[m.SelectedParam]
+	param.Key = strings.TrimSpace(key)
+	param.Value = value
+	param.Equals = equals && value == ""
+	if param.Key == "" && param.Value == "" {
+		return HandleParamsDelete(m)
+	}
+	return syncURLFromParams(m)
+}
+
+// HandleParamEditCancel leaves editing, dropping a param that was added but never filled in
+func HandleParamEditCancel(m types.Model) types.Model {
+	m.HeadersMode = types.HeadersViewMode
+	m.HeaderEditInput.Blur()
+	if len(m.QueryParams) > 0 {
+		param := m.QueryParams[m.SelectedParam]
+		if param.Key == "" && param.Value == "" {
+			return HandleParamsDelete(m)
+		}
+	}
+	return m
+}
+
+// HandleParamsDelete removes the selected param
+func HandleParamsDelete(m types.Model) types.Model {
+	if len(m.QueryParams) == 0 {
+		return m
+	}
+	m.QueryParams = append(m.QueryParams[:m.SelectedParam], m.QueryParams[m.SelectedParam+1:]...)
+	m = clampSelectedParam(m)
+	return syncURLFromParams(m)
+}
+
+// HandleParamsToggle enables or disables the selected param
+func HandleParamsToggle(m types.Model) types.Model {
+	if len(m.QueryParams) == 0 {
+		return m
+	}
+	m.QueryParams[m.SelectedParam].Disabled = !m.QueryParams[m.SelectedParam].Disabled
+	return syncURLFromParams(m)
+}
+
+// HandleParamsMove moves the selected param up or down
+func HandleParamsMove(m types.Model, direction string) types.Model {
+	i := m.SelectedParam
+	j := i - 1
+	if direction == "down" {
+		j = i + 1
+	}
+	if len(m.QueryParams) == 0 || j < 0 || j >= len(m.QueryParams) {
+		return m
+	}
+
+	m.QueryParams[i], m.QueryParams[j] = m.QueryParams[j], m.QueryParams[i]
+	m.SelectedParam = j
+	return syncURLFromParams(m)
+}
+
+// clampSelectedParam keeps the selection within the params list
+func clampSelectedParam(m types.Model) types.Model {
+	if m.SelectedParam >= len(m.QueryParams) {
+		m.SelectedParam = len(m.QueryParams) - 1
+	}
+	if m.SelectedParam < 0 {
+		m.SelectedParam = 0
+	}
+	return m
+}
@@ -1,42 +1,296 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 
+	"postty/src/components"
 	"postty/src/services"
 	"postty/src/types"
 )
 
+// currentRequest builds a request from the values in the form
+func currentRequest(m types.Model) types.Request {
+	headers := make([]types.Header, len(m.CustomHeaders))
+	copy(headers, m.CustomHeaders)
+
+	req := types.Request{
+		Method:      types.Methods[m.SelectedMethod],
+		URL:         m.URLInput.Value(),
+		Body:        m.BodyInput.Value(),
+		ContentType: types.ContentTypes[m.SelectedHeader],
+		Headers:     headers,
+		Timeout:     m.RequestTimeout,
+		PreScript:   m.PreScriptInput.Value(),
+		PostScript:  m.PostScriptInput.Value(),
+		Auth:        m.Auth,
+		TLS:         m.TLS,
+		NoCookies:   m.NoCookies,
+		Redirects:   m.Redirects,
+	}
+
+	// Form content types send the field list instead of the raw body
+	if services.IsFormContentType(req.ContentType) && len(m.FormFields) > 0 {
+		req.Body = ""
+		req.Form = make([]types.FormField, len(m.FormFields))
+		copy(req.Form, m.FormFields)
+	}
+
+	// GraphQL requests send the query in Body along with the other editors
+	if req.ContentType == services.GraphQLContentType {
+		req.GraphQLVariables = m.GraphQLVariables.Value()
+		req.GraphQLOperation = m.GraphQLOperation.Value()
+	}
+
+	if len(m.Assertions) > 0 {
+		req.Assertions = make([]types.Assertion, len(m.Assertions))
+		copy(req.Assertions, m.Assertions)
+	}
+
+	// Enabled params are already in the URL; the list is only needed to remember disabled ones
+	if services.HasDisabledParams(m.QueryParams) {
+		req.Params = make([]types.QueryParam, len(m.QueryParams))
+		copy(req.Params, m.QueryParams)
+	}
+	return req
+}
+
+// effectiveTimeout returns the timeout in seconds that applies to req, 0 meaning none
+func effectiveTimeout(m types.Model, req types.Request) int {
+	if req.Timeout > 0 {
+		return req.Timeout
+	}
+	return m.Config.RequestTimeout
+}
+
 // ExecuteRequestWithHistory executes an HTTP request and stores it for history tracking
 func ExecuteRequestWithHistory(m types.Model) (types.Model, tea.Cmd) {
 	if m.URLInput.Value() == "" || m.Executing {
 		return m, nil
 	}
 
+	// With a WebSocket open, sending writes the body as a frame
+	if isWebSocketMode(m) && m.WebSocket != nil {
+		return HandleWebSocketSend(m)
+	}
+
 	// Get request details
-	method := types.HTTPMethods[m.SelectedMethod]
-	url := m.URLInput.Value()
-	body := m.BodyInput.Value()
-	contentType := types.ContentTypes[m.SelectedHeader]
-	headers := make([]types.Header, len(m.CustomHeaders))
-	copy(headers, m.CustomHeaders)
+	req := currentRequest(m)
+
+	// Let the pre-request script change the request and set variables before they are
+	// substituted; it runs in the background so a slow script does not hold up the UI
+	m.ScriptLog = nil
+	m.TestResults = nil
+	if strings.TrimSpace(req.PreScript) == "" {
+		return sendScriptedRequest(m, req, types.ScriptRun{})
+	}
+	// Cancelling interrupts the script, and the request is not sent
+	ctx, cancel := context.WithCancel(context.Background())
+	m.CancelRequest = cancel
+	m.Executing = true
+	m.StatusMessage = ""
+	m = setResponseBody(m, "Running the pre-request script...")
+	return m, services.PreRequestScript(ctx, req, activeVariables(m))
+}
+
+// HandlePreScript sends the request once its pre-request script has run
+func HandlePreScript(m types.Model, msg types.PreScriptMsg) (types.Model, tea.Cmd) {
+	m.Executing = false
+	if m.CancelRequest != nil {
+		m.CancelRequest()
+		m.CancelRequest = nil
+	}
+	if errors.Is(msg.Run.Err, services.ErrScriptCancelled) {
+		m = applyScriptRun(m, types.PreRequestPhase, msg.Run)
+		m.StatusMessage = ""
+		m = setResponseBody(m, "Request cancelled")
+		return m, nil
+	}
+	return sendScriptedRequest(m, msg.Request, msg.Run)
+}
+
+// sendScriptedRequest resolves and sends scripted, the request as its pre-request script
+// left it
+func sendScriptedRequest(m types.Model, scripted types.Request, run types.ScriptRun) (types.Model, tea.Cmd) {
+	m = applyScriptRun(m, types.PreRequestPhase, run)
+	if run.Err != nil {
+		m.StatusCode = 0
+		m.ResponseHeaders = nil
+		m.ResponseMeta = nil
+		m.ResponseTab = types.ResponseConsoleTab
+		m.StatusMessage = "Request not sent: the pre-request script failed"
+		m = setResponseBody(m, fmt.Sprintf("Error: %v", run.Err))
+		return m, nil
+	}
+
+	// Fill in values taken from earlier responses, then environment variables; anything left
+	// unresolved is reported instead of sent
+	referenced, err := services.ResolveReferences(scripted, m.History)
+	if err != nil {
+		m.StatusCode = 0
+		m.ResponseHeaders = nil
+		m.ResponseMeta = nil
+		m.ResponseTab = types.ResponseBodyTab
+		m.StatusMessage = "Request not sent: a response reference did not resolve"
+		m = setResponseBody(m, fmt.Sprintf("Error: %v\n\nThe request was not sent.", err))
+		return m, nil
+	}
+	resolved, unresolved := services.InterpolateRequest(referenced, withVariables(activeVariables(m), run.Variables))
+	if len(unresolved) > 0 {
+		m.StatusCode = 0
+		m.ResponseHeaders = nil
+		m.ResponseMeta = nil
+		m.ResponseTab = types.ResponseBodyTab
+		m.StatusMessage = fmt.Sprintf("Unresolved variables: %s", strings.Join(unresolved, ", "))
+		m = setResponseBody(m, components.RenderUnresolvedVariables(scripted, unresolved, activeEnvironmentName(m)))
+		return m, nil
+	}
 
 	// Store pending request for history
 	m.PendingRequest = &types.HistoryItem{
-		Method:      method,
-		URL:         url,
-		Body:        body,
-		ContentType: contentType,
-		Headers:     headers,
-		Timestamp:   time.Now().Format("2006-01-02 15:04:05"),
+		Request:   scripted,
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		ScriptLog: m.ScriptLog,
 	}
 
 	// Mark as executing
 	m.Executing = true
-	m.ResponseViewport.SetContent("Executing request...")
+	m.StatusMessage = ""
+	m = setResponseBody(m, "Executing request...")
+
+	// Abort the request when it is cancelled; the timeout is applied while it is sent
+	ctx, cancel := context.WithCancel(context.Background())
+	m.CancelRequest = cancel
+	resolved.Timeout = effectiveTimeout(m, scripted)
+	resolved.TLS = services.MergeTLS(m.Config.TLS, resolved.TLS)
+	resolved.Proxy = services.ProxySettingsFor(m.Config, activeEnvironmentName(m))
+
+	if scripted.Method == types.WebSocketMethod {
+		m.WebSocketLog = nil
+		m = setResponseBody(m, "Connecting...")
+		return m, services.ConnectWebSocket(ctx, resolved)
+	}
+
+	if scripted.Method == types.GRPCMethod {
+		m.GRPCReplies = nil
+		m = setResponseBody(m, "Calling...")
+		return m, services.CallGRPC(ctx, resolved, m.Config.ProtoFiles, m.Config.ProtoImportPaths)
+	}
 
 	// Execute the request
-	return m, services.ExecuteRequest(method, url, body, contentType, m.CustomHeaders)
+	return m, services.ExecuteRequest(ctx, resolved)
+}
+
+// HandleCancelRequest aborts the request in flight; its response arrives as a cancelled result
+func HandleCancelRequest(m types.Model) types.Model {
+	if !m.Executing || m.CancelRequest == nil {
+		return m
+	}
+	m.CancelRequest()
+	m.StatusMessage = "Cancelling request..."
+	return m
+}
+
+// HandleTimeoutAdjust raises or lowers the per-request timeout in RequestTimeoutStep increments
+func HandleTimeoutAdjust(m types.Model, direction string) types.Model {
+	if direction == "up" {
+		m.RequestTimeout += types.RequestTimeoutStep
+	} else if direction == "down" {
+		m.RequestTimeout -= types.RequestTimeoutStep
+		if m.RequestTimeout < 0 {
+			m.RequestTimeout = 0
+		}
+	}
+	return m
+}
+
+// HandleTLSVerifyToggle turns checking the server's TLS certificate off or back on for the
+// request being edited
+func HandleTLSVerifyToggle(m types.Model) types.Model {
+	m.TLS.Insecure = !m.TLS.Insecure
+	switch {
+	case m.Config.TLS.Insecure:
+		m.StatusMessage = "TLS: the config turns off verifying server certificates for every request"
+	case m.TLS.Insecure:
+		m.StatusMessage = "TLS: the server certificate is not verified for this request"
+	default:
+		m.StatusMessage = "TLS: the server certificate is verified"
+	}
+	return m
+}
+
+// HandleCookiesToggle turns the cookie jar off or back on for the request being edited
+func HandleCookiesToggle(m types.Model) types.Model {
+	m.NoCookies = !m.NoCookies
+	if m.NoCookies {
+		m.StatusMessage = "Cookies: this request neither sends nor keeps cookies"
+	} else {
+		m.StatusMessage = "Cookies: this request uses the cookie jar"
+	}
+	return m
+}
+
+// HandleMaxRedirectsEdit starts editing how many redirects the request being edited follows
+func HandleMaxRedirectsEdit(m types.Model) (types.Model, tea.Cmd) {
+	m.EditingMaxRedirects = true
+	m.MaxRedirectsInput.SetValue("")
+	if m.Redirects.Max > 0 {
+		m.MaxRedirectsInput.SetValue(strconv.Itoa(m.Redirects.Max))
+	}
+	m.MaxRedirectsInput.CursorEnd()
+	m.MaxRedirectsInput.Focus()
+	return m, textinput.Blink
+}
+
+// HandleMaxRedirectsEditSave sets the redirect limit typed; left empty or 0 the default
+// limit applies. A limit that is not a number stays in the editor.
+func HandleMaxRedirectsEditSave(m types.Model) types.Model {
+	value := strings.TrimSpace(m.MaxRedirectsInput.Value())
+	limit := 0
+	if value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			m.StatusMessage = fmt.Sprintf("Redirects: the limit must be a number, got %q", value)
+			return m
+		}
+		limit = n
+	}
+	m.Redirects.Max = limit
+	if limit == 0 {
+		limit = types.DefaultMaxRedirects
+	}
+	m.StatusMessage = fmt.Sprintf("Redirects: up to %d are followed", limit)
+	return HandleMaxRedirectsEditCancel(m)
+}
+
+// HandleMaxRedirectsEditCancel leaves the redirect limit editor
+func HandleMaxRedirectsEditCancel(m types.Model) types.Model {
+	m.EditingMaxRedirects = false
+	m.MaxRedirectsInput.Blur()
+	return m
+}
+
+// HandleRedirectsToggle cycles the request being edited through following redirects,
+// following them with the method and body kept, and not following them
+func HandleRedirectsToggle(m types.Model) types.Model {
+	switch {
+	case m.Redirects.NoFollow:
+		m.Redirects.NoFollow = false
+		m.Redirects.KeepMethod = false
+		m.StatusMessage = "Redirects: followed, 301, 302 and 303 turn into a GET"
+	case m.Redirects.KeepMethod:
+		m.Redirects.NoFollow = true
+		m.StatusMessage = "Redirects: not followed, the redirect response is shown"
+	default:
+		m.Redirects.KeepMethod = true
+		m.StatusMessage = "Redirects: followed with the method and body kept on 301 and 302"
+	}
+	return m
 }
@@ -1,52 +1,148 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/components"
+	"postty/src/services"
 	"postty/src/types"
 )
 
 // HandleResponse handles HTTP response messages
-func HandleResponse(m types.Model, msg types.ResponseMsg) types.Model {
+func HandleResponse(m types.Model, msg types.ResponseMsg) (types.Model, tea.Cmd) {
 	m.Executing = false
+
+	// Release the request context
+	if m.CancelRequest != nil {
+		m.CancelRequest()
+		m.CancelRequest = nil
+	}
+
 	if msg.Err != nil {
-		m.ResponseViewport.SetContent(fmt.Sprintf("Error: %v", msg.Err))
+		result := failureText(m, msg)
 		m.StatusCode = 0
+		m.ResponseHeaders = nil
+		m.ResponseMeta = nil
+		m.StatusMessage = ""
+		m = setResponseBody(m, result)
 
 		// Still add to history even if there was an error
 		if m.PendingRequest != nil {
+			m = checkAssertions(m, msg)
 			m.PendingRequest.StatusCode = 0
-			m.PendingRequest.ResponseBody = fmt.Sprintf("Error: %v", msg.Err)
-			m = AddToHistory(m,
-				m.PendingRequest.Method,
-				m.PendingRequest.URL,
-				m.PendingRequest.Body,
-				m.PendingRequest.ContentType,
-				m.PendingRequest.Headers,
-				0,
-				fmt.Sprintf("Error: %v", msg.Err),
-			)
+			m.PendingRequest.Outcome = msg.Outcome
+			m.PendingRequest.ResponseBody = result
+			m = AddToHistory(m, *m.PendingRequest)
 		}
 	} else {
-		m.ResponseViewport.SetContent(msg.Body)
+		meta := msg.Meta
 		m.StatusCode = msg.StatusCode
-
-		// Add successful request to history
+		m.ResponseHeaders = msg.Headers
+		m.ResponseMeta = &meta
+		body := msg.Body
 		if m.PendingRequest != nil {
-			m = AddToHistory(m,
-				m.PendingRequest.Method,
-				m.PendingRequest.URL,
-				m.PendingRequest.Body,
-				m.PendingRequest.ContentType,
-				m.PendingRequest.Headers,
-				msg.StatusCode,
-				msg.Body,
-			)
+			body = responseBodyView(m.PendingRequest.Request, msg.Body)
+		}
+		m = setResponseBody(m, body)
+
+		// Let the post-response script read the response and set variables; the request
+		// stays in progress, and can be cancelled, until the script has run in the background.
+		// The script sees the request as it was sent, with its response references resolved.
+		if m.PendingRequest != nil && strings.TrimSpace(m.PendingRequest.PostScript) != "" {
+			sent, err := services.ResolveReferences(m.PendingRequest.Request, m.History)
+			if err != nil {
+				sent = m.PendingRequest.Request
+			}
+			sent, _ = services.InterpolateRequest(sent, activeVariables(m))
+			ctx, cancel := context.WithCancel(context.Background())
+			m.CancelRequest = cancel
+			m.Executing = true
+			m.StatusMessage = "Running the post-response script..."
+			return m, services.PostResponseScript(ctx, m.PendingRequest.PostScript, sent, msg, activeVariables(m))
 		}
+		m = recordResponse(m, msg)
 	}
 
 	// Clear pending request
 	m.PendingRequest = nil
 
-	return m
+	return m, nil
+}
+
+// HandlePostScript shows the console output of the post-response script and adds the
+// response it ran on to history
+func HandlePostScript(m types.Model, msg types.PostScriptMsg) types.Model {
+	m.Executing = false
+	if m.CancelRequest != nil {
+		m.CancelRequest()
+		m.CancelRequest = nil
+	}
+	if m.PendingRequest == nil {
+		return m
+	}
+	m.StatusMessage = ""
+	m = applyScriptRun(m, types.PostResponsePhase, msg.Run)
+	if errors.Is(msg.Run.Err, services.ErrScriptCancelled) {
+		m.StatusMessage = "The post-response script was cancelled"
+	} else if msg.Run.Err != nil {
+		m.StatusMessage = "The post-response script failed, see the Console tab"
+	}
+	m.PendingRequest.ScriptLog = m.ScriptLog
+	m = recordResponse(m, msg.Response)
+	m.PendingRequest = nil
+	return refreshResponseView(m)
+}
+
+// recordResponse checks the assertions of the pending request against its successful
+// response and adds both to history
+func recordResponse(m types.Model, msg types.ResponseMsg) types.Model {
+	if m.PendingRequest == nil {
+		return m
+	}
+	meta := msg.Meta
+	m = checkAssertions(m, msg)
+	m.PendingRequest.StatusCode = msg.StatusCode
+	m.PendingRequest.ResponseBody = msg.Body
+	m.PendingRequest.ResponseHeaders = msg.Headers
+	m.PendingRequest.ResponseMeta = &meta
+	return AddToHistory(m, *m.PendingRequest)
+}
+
+// checkAssertions checks the assertions of the pending request against its response
+func checkAssertions(m types.Model, msg types.ResponseMsg) types.Model {
+	m.TestResults = services.EvaluateAssertions(m.PendingRequest.Assertions, msg)
+	m.PendingRequest.TestResults = m.TestResults
+	if summary := testSummary(m.TestResults); summary != "" {
+		m.StatusMessage = summary
+	}
+	return refreshResponseView(m)
+}
+
+// failureText describes why a request produced no response
+func failureText(m types.Model, msg types.ResponseMsg) string {
+	switch msg.Outcome {
+	case types.OutcomeCancelled:
+		return "Request cancelled"
+	case types.OutcomeTimedOut:
+		if m.PendingRequest != nil {
+			return fmt.Sprintf("Request timed out after %ds", effectiveTimeout(m, m.PendingRequest.Request))
+		}
+		return "Request timed out"
+	default:
+		return fmt.Sprintf("Error: %v", msg.Err)
+	}
+}
+
+// responseBodyView returns the response body as shown for req; GraphQL errors are listed
+// apart from the data
+func responseBodyView(req types.Request, body string) string {
+	if req.ContentType == services.GraphQLContentType {
+		return components.RenderGraphQLResponse(body)
+	}
+	return body
 }
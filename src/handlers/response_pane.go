@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"postty/src/components"
 	"postty/src/types"
 )
 
@@ -22,3 +23,54 @@ func HandleResponseScroll(m types.Model, action string) types.Model {
 	}
 	return m
 }
+
+// HandleResponseTab switches between the body, headers, info, tests and console views of the response pane
+func HandleResponseTab(m types.Model, direction string) types.Model {
+	if direction == "left" {
+		if m.ResponseTab > types.ResponseBodyTab {
+			m.ResponseTab--
+		}
+	} else if direction == "right" {
+		if m.ResponseTab < types.ResponseConsoleTab {
+			m.ResponseTab++
+		}
+	}
+	m = refreshResponseView(m)
+	m.ResponseViewport.GotoTop()
+	return m
+}
+
+// setResponseBody replaces the body shown in the response pane
+func setResponseBody(m types.Model, body string) types.Model {
+	m.ResponseBody = body
+	m = refreshResponseView(m)
+	m.ResponseViewport.GotoTop()
+	return m
+}
+
+// refreshResponseView fills the response viewport with the content of the active tab
+func refreshResponseView(m types.Model) types.Model {
+	if m.Exporting {
+		return refreshExportView(m)
+	}
+	if m.BrowsingGRPC {
+		return refreshGRPCMethodsView(m)
+	}
+	if m.BrowsingCookies {
+		return refreshCookiesView(m)
+	}
+
+	switch m.ResponseTab {
+	case types.ResponseHeadersTab:
+		m.ResponseViewport.SetContent(components.RenderResponseHeaders(m.ResponseHeaders))
+	case types.ResponseInfoTab:
+		m.ResponseViewport.SetContent(components.RenderResponseInfo(m.ResponseMeta))
+	case types.ResponseTestsTab:
+		m.ResponseViewport.SetContent(components.RenderTestResults(m.TestResults, len(m.Assertions)))
+	case types.ResponseConsoleTab:
+		m.ResponseViewport.SetContent(components.RenderScriptConsole(m.ScriptLog))
+	default:
+		m.ResponseViewport.SetContent(m.ResponseBody)
+	}
+	return m
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/components"
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// HandleHistoryMark adds the selected history item to the sequence, or takes it out again
+func HandleHistoryMark(m types.Model) types.Model {
+	if len(m.History) == 0 {
+		return m
+	}
+	if m.MarkedHistory == nil {
+		m.MarkedHistory = map[int]bool{}
+	}
+	if m.MarkedHistory[m.SelectedHistory] {
+		delete(m.MarkedHistory, m.SelectedHistory)
+	} else {
+		m.MarkedHistory[m.SelectedHistory] = true
+	}
+	return m
+}
+
+// HandleHistoryExport writes the marked history items to a sequence file for "postty run"
+func HandleHistoryExport(m types.Model) types.Model {
+	seq, ok := markedSequence(m)
+	if !ok {
+		return m
+	}
+
+	dir := m.Config.SequencesDir
+	if dir == "" {
+		dir = types.DefaultSequencesDir
+	}
+	path, err := services.SaveSequence(dir, seq)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Could not export sequence: %v", err)
+		return m
+	}
+	m.StatusMessage = fmt.Sprintf("Saved %d requests to %s for postty run", len(seq.Requests), path)
+	for _, req := range seq.Requests {
+		if _, redacted := services.RedactSecrets(req); redacted {
+			m.StatusMessage += " without their secrets, use {{variables}} to keep them"
+			break
+		}
+	}
+	return m
+}
+
+// HandleHistoryRun replays the marked history items in order, showing progress in the response pane
+func HandleHistoryRun(m types.Model) (types.Model, tea.Cmd) {
+	if m.Executing {
+		return m, nil
+	}
+	seq, ok := markedSequence(m)
+	if !ok {
+		return m, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := services.StartSequence(ctx, seq, services.RunOptions{
+		Variables:        activeVariables(m),
+		Timeout:          m.Config.RequestTimeout,
+		ProtoFiles:       m.Config.ProtoFiles,
+		ProtoImportPaths: m.Config.ProtoImportPaths,
+		TLS:              m.Config.TLS,
+		Proxy:            services.ProxySettingsFor(m.Config, activeEnvironmentName(m)),
+	})
+
+	m.Executing = true
+	m.CancelRequest = cancel
+	m.SequenceRun = run
+	m.RunResults = nil
+	m.StatusCode = 0
+	m.ResponseHeaders = nil
+	m.ResponseMeta = nil
+	m.TestResults = nil
+	m.ScriptLog = nil
+	m.ResponseTab = types.ResponseBodyTab
+	m.StatusMessage = fmt.Sprintf("Running %d requests...", len(seq.Requests))
+	m = setResponseBody(m, components.RenderRunProgress(nil, nil))
+	return m, services.WaitSequence(run)
+}
+
+// HandleRunStep adds the result of the next request of the run and waits for the one after
+func HandleRunStep(m types.Model, msg types.RunStepMsg) (types.Model, tea.Cmd) {
+	if msg.Run != m.SequenceRun {
+		return m, services.WaitSequence(msg.Run)
+	}
+
+	following := m.ResponseViewport.AtBottom()
+	m.RunResults = append(m.RunResults, msg.Result)
+	m.ResponseBody = components.RenderRunProgress(m.RunResults, nil)
+	m = refreshResponseView(m)
+	if following && m.ResponseTab == types.ResponseBodyTab {
+		m.ResponseViewport.GotoBottom()
+	}
+	return m, services.WaitSequence(msg.Run)
+}
+
+// HandleRunEnded shows the summary of a finished or cancelled run
+func HandleRunEnded(m types.Model, msg types.RunEndedMsg) types.Model {
+	if msg.Run != m.SequenceRun {
+		return m
+	}
+
+	m.Executing = false
+	if m.CancelRequest != nil {
+		m.CancelRequest()
+		m.CancelRequest = nil
+	}
+	m.SequenceRun = nil
+
+	report := msg.Report
+	m.ResponseBody = components.RenderRunProgress(report.Results, &report)
+	m = refreshResponseView(m)
+
+	passed, failed := services.RunCounts(report.Results)
+	verb := "finished"
+	if report.Cancelled {
+		verb = "stopped"
+	}
+	m.StatusMessage = fmt.Sprintf("Run %s: %d passed, %d failed", verb, passed, failed)
+	return m
+}
+
+// markedSequence builds a sequence from the marked history items, oldest first, or from the
+// selected item when none are marked
+func markedSequence(m types.Model) (types.Sequence, bool) {
+	if len(m.History) == 0 {
+		return types.Sequence{}, false
+	}
+
+	var indexes []int
+	for i := range m.MarkedHistory {
+		if i < len(m.History) {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		indexes = []int{m.SelectedHistory}
+	}
+
+	// History is most recent first
+	sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+	seq := types.Sequence{Name: "history-" + time.Now().Format("20060102-150405")}
+	for _, i := range indexes {
+		seq.Requests = append(seq.Requests, m.History[i].Request)
+	}
+	return seq, true
+}
+
+// shiftHistoryMarks keeps the marks on their items when an item is added at index 0
+// (delta 1) or removed at index at (delta -1)
+func shiftHistoryMarks(m types.Model, at, delta int) types.Model {
+	if len(m.MarkedHistory) == 0 {
+		return m
+	}
+	marks := map[int]bool{}
+	for i := range m.MarkedHistory {
+		switch {
+		case i < at:
+			marks[i] = true
+		case delta < 0 && i == at:
+			// The marked item itself was removed
+		case i+delta < len(m.History):
+			marks[i+delta] = true
+		}
+	}
+	m.MarkedHistory = marks
+	return m
+}
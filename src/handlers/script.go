@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// bodyScriptMode reports whether the body pane shows one of the script editors
+func bodyScriptMode(m types.Model) bool {
+	return !m.ImportingCurl && m.BodyTab != types.RequestBodyTab
+}
+
+// HandleBodyTab cycles the body pane between the request body and the two script editors
+func HandleBodyTab(m types.Model) (types.Model, tea.Cmd) {
+	m = blurBodyEditors(m)
+	m.BodyTab = (m.BodyTab + 1) % (types.PostScriptTab + 1)
+	return focusBodyEditor(m)
+}
+
+// applyScriptRun shows the console output of a script and keeps the variables it set in
+// the active environment, saving them to its file
+func applyScriptRun(m types.Model, phase types.ScriptPhase, run types.ScriptRun) types.Model {
+	m.ScriptLog = append(m.ScriptLog, run.Log...)
+	if len(run.Variables) == 0 {
+		return m
+	}
+
+	names := make([]string, 0, len(run.Variables))
+	for name := range run.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if m.ActiveEnvironment < 0 || m.ActiveEnvironment >= len(m.Environments) {
+		m.ScriptLog = append(m.ScriptLog, types.ScriptLogEntry{
+			Time:  time.Now(),
+			Phase: phase,
+			Level: "warn",
+			Text:  fmt.Sprintf("No environment is active, so %s was not kept", strings.Join(names, ", ")),
+		})
+		return m
+	}
+
+	env := m.Environments[m.ActiveEnvironment]
+	variables := make(map[string]string, len(env.Variables)+len(run.Variables))
+	for name, value := range env.Variables {
+		variables[name] = value
+	}
+	for name, value := range run.Variables {
+		variables[name] = value
+	}
+	env.Variables = variables
+	m.Environments[m.ActiveEnvironment] = env
+
+	if err := services.SaveEnvironment(env); err != nil {
+		m.ScriptLog = append(m.ScriptLog, types.ScriptLogEntry{
+			Time:  time.Now(),
+			Phase: phase,
+			Level: "error",
+			Text:  fmt.Sprintf("Saving environment %s: %v", env.Name, err),
+		})
+	}
+	return m
+}
+
+// withVariables returns variables with overrides applied, leaving both maps unchanged
+func withVariables(variables, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return variables
+	}
+	merged := make(map[string]string, len(variables)+len(overrides))
+	for name, value := range variables {
+		merged[name] = value
+	}
+	for name, value := range overrides {
+		merged[name] = value
+	}
+	return merged
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/components"
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// HandleStreamStarted shows the headers of an event stream and starts reading its events.
+// The request stays in flight until the stream ends, so Ctrl+X stops it.
+func HandleStreamStarted(m types.Model, msg types.StreamStartedMsg) (types.Model, tea.Cmd) {
+	meta := msg.Meta
+	m.EventStream = msg.Stream
+	m.SSEEvents = nil
+	m.SSEDropped = 0
+	m.StreamPaused = false
+	m.StatusCode = msg.StatusCode
+	m.ResponseHeaders = msg.Headers
+	m.ResponseMeta = &meta
+	m.ResponseTab = types.ResponseBodyTab
+	m.StatusMessage = "Streaming events. p pauses the list, Ctrl+X stops"
+	m = setResponseBody(m, components.RenderEventStream(nil, 0))
+	return m, services.WaitEventStream(msg.Stream)
+}
+
+// HandleSSEEvent adds an event to the list and waits for the next one. Only the new event
+// is rendered, unless the list is full and the oldest tenth of it is dropped.
+func HandleSSEEvent(m types.Model, msg types.SSEEventMsg) (types.Model, tea.Cmd) {
+	if msg.Stream != m.EventStream {
+		return m, services.WaitEventStream(msg.Stream)
+	}
+
+	m.SSEEvents = append(m.SSEEvents, msg.Event)
+	if len(m.SSEEvents) > types.MaxSSEEvents {
+		drop := len(m.SSEEvents) - types.MaxSSEEvents + types.MaxSSEEvents/10
+		m.SSEEvents = append([]types.SSEEvent(nil), m.SSEEvents[drop:]...)
+		m.SSEDropped += drop
+		if !m.StreamPaused {
+			m = refreshEventList(m)
+		}
+	} else if !m.StreamPaused {
+		m = appendEventList(m, msg.Event)
+	}
+	return m, services.WaitEventStream(msg.Stream)
+}
+
+// HandleStreamEnded records a finished stream in history with its events. Stopping the
+// stream with Ctrl+X ends it like the server closing it, keeping what was received.
+func HandleStreamEnded(m types.Model, msg types.StreamEndedMsg) types.Model {
+	if msg.Stream != m.EventStream {
+		return m
+	}
+
+	m.Executing = false
+	if m.CancelRequest != nil {
+		m.CancelRequest()
+		m.CancelRequest = nil
+	}
+
+	m.EventStream = nil
+	m.StreamPaused = false
+	m = refreshEventList(m)
+
+	switch {
+	case msg.Outcome == types.OutcomeCancelled:
+		m.StatusMessage = fmt.Sprintf("Stream stopped after %d events", len(m.SSEEvents)+m.SSEDropped)
+	case msg.Err != nil:
+		m.StatusMessage = fmt.Sprintf("Stream failed after %d events: %v", len(m.SSEEvents)+m.SSEDropped, msg.Err)
+	default:
+		m.StatusMessage = fmt.Sprintf("Stream ended after %d events", len(m.SSEEvents)+m.SSEDropped)
+	}
+
+	if m.PendingRequest != nil {
+		item := *m.PendingRequest
+		item.StatusCode = m.StatusCode
+		item.ResponseBody = m.ResponseBody
+		item.ResponseHeaders = m.ResponseHeaders
+		item.ResponseMeta = m.ResponseMeta
+		if msg.Outcome != types.OutcomeCancelled {
+			item.Outcome = msg.Outcome
+		}
+		m = AddToHistory(m, item)
+		m.PendingRequest = nil
+	}
+	return m
+}
+
+// HandleStreamPause freezes or resumes the event list of the open stream
+func HandleStreamPause(m types.Model) types.Model {
+	if m.EventStream == nil {
+		return m
+	}
+
+	m.StreamPaused = !m.StreamPaused
+	m.StreamShown = len(m.SSEEvents) + m.SSEDropped
+	if !m.StreamPaused {
+		m = refreshEventList(m)
+	}
+	return m
+}
+
+// refreshEventList re-renders the event list, following its end unless it has been scrolled up
+func refreshEventList(m types.Model) types.Model {
+	return showEventList(m, components.RenderEventStream(m.SSEEvents, m.SSEDropped))
+}
+
+// appendEventList adds event, the last of SSEEvents, to the event list as it is shown
+func appendEventList(m types.Model, event types.SSEEvent) types.Model {
+	if len(m.SSEEvents) == 1 && m.SSEDropped == 0 {
+		return showEventList(m, components.RenderSSEEvent(event))
+	}
+	return showEventList(m, m.ResponseBody+"\n"+components.RenderSSEEvent(event))
+}
+
+// showEventList shows body as the event list, following its end unless it has been scrolled up
+func showEventList(m types.Model, body string) types.Model {
+	following := m.ResponseViewport.AtBottom()
+	m.ResponseBody = body
+	m = refreshResponseView(m)
+	if following && m.ResponseTab == types.ResponseBodyTab {
+		m.ResponseViewport.GotoBottom()
+	}
+	return m
+}
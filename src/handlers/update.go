@@ -17,13 +17,87 @@ func Update(msg tea.Msg, m types.Model) (types.Model, tea.Cmd) {
 		return m, nil
 
 	case types.ResponseMsg:
-		m = HandleResponse(m, msg)
+		return HandleResponse(m, msg)
+
+	case types.PreScriptMsg:
+		return HandlePreScript(m, msg)
+
+	case types.PostScriptMsg:
+		m = HandlePostScript(m, msg)
+		return m, nil
+
+	case types.WebSocketConnectedMsg:
+		return HandleWebSocketConnected(m, msg)
+
+	case types.WebSocketMessageMsg:
+		return HandleWebSocketMessage(m, msg)
+
+	case types.WebSocketSentMsg:
+		m = HandleWebSocketSent(m, msg)
+		return m, nil
+
+	case types.WebSocketClosedMsg:
+		m = HandleWebSocketClosed(m, msg)
+		return m, nil
+
+	case types.StreamStartedMsg:
+		return HandleStreamStarted(m, msg)
+
+	case types.SSEEventMsg:
+		return HandleSSEEvent(m, msg)
+
+	case types.StreamEndedMsg:
+		m = HandleStreamEnded(m, msg)
+		return m, nil
+
+	case types.GRPCStartedMsg:
+		return HandleGRPCStarted(m, msg)
+
+	case types.GRPCReplyMsg:
+		return HandleGRPCReply(m, msg)
+
+	case types.GRPCEndedMsg:
+		m = HandleGRPCEnded(m, msg)
+		return m, nil
+
+	case types.GRPCMethodsMsg:
+		return HandleGRPCMethods(m, msg)
+
+	case types.RunStepMsg:
+		return HandleRunStep(m, msg)
+
+	case types.RunEndedMsg:
+		m = HandleRunEnded(m, msg)
+		return m, nil
+
+	case types.OAuth2TokenMsg:
+		m = HandleOAuth2Token(m, msg)
+		return m, nil
+
+	case types.GraphQLSchemaMsg:
+		m = HandleGraphQLSchema(m, msg)
 		return m, nil
 
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c":
 			return m, tea.Quit
+		case "ctrl+s":
+			return HandleCollectionsSaveStart(m)
+		case "ctrl+x":
+			if m.WebSocket != nil {
+				return HandleWebSocketClose(m)
+			}
+			if m.OAuth2Login != nil && !m.Executing {
+				m = HandleOAuth2LoginCancel(m)
+				return m, nil
+			}
+			m = HandleCancelRequest(m)
+			return m, nil
+		case "ctrl+o":
+			return HandleCurlImportStart(m)
+		case "ctrl+y":
+			return HandleExportStart(m)
 		}
 
 		// Tab navigation (works from any pane)
@@ -35,25 +109,106 @@ func Update(msg tea.Msg, m types.Model) (types.Model, tea.Cmd) {
 		}
 
 		// Handle keys based on active pane
-		if m.ActivePane == types.URLPane || m.ActivePane == types.BodyPane || (m.ActivePane == types.HeadersPane && m.HeadersMode == types.HeadersEditMode) {
+		if m.ActivePane == types.URLPane || (m.ActivePane == types.BodyPane && (!bodyFormMode(m) || m.EditingFormField)) ||
+			(m.ActivePane == types.HeadersPane && m.HeadersMode == types.HeadersEditMode) ||
+			(m.ActivePane == types.CollectionsPane && m.CollectionsMode != types.CollectionsViewMode) ||
+			(m.ActivePane == types.ResponsePane && m.EditingCookie) ||
+			(m.ActivePane == types.MethodPane && m.EditingMaxRedirects) {
 			// Text input panes - handle Alt+Enter for body pane execution
 			if msg.Type == tea.KeyEnter && msg.Alt {
+				if m.ActivePane == types.BodyPane && m.ImportingCurl {
+					return HandleCurlImport(m)
+				}
 				if m.ActivePane == types.BodyPane {
+					if m.EditingFormField {
+						if m = HandleFormEditSave(m); m.EditingFormField {
+							return m, nil
+						}
+					}
 					return ExecuteRequestWithHistory(m)
 				}
 				return m, nil
 			}
 
+			// Switch between the body and script editors
+			if m.ActivePane == types.BodyPane && msg.String() == "ctrl+t" && !m.ImportingCurl && !m.EditingFormField {
+				return HandleBodyTab(m)
+			}
+
+			// GraphQL editor keys
+			if m.ActivePane == types.BodyPane && bodyGraphQLMode(m) {
+				switch msg.String() {
+				case "ctrl+g":
+					return HandleGraphQLEditorSwitch(m)
+				case "ctrl+n":
+					return HandleGraphQLComplete(m)
+				case "ctrl+r":
+					return HandleGraphQLIntrospect(m)
+				}
+				m.GraphQLCompletion = nil
+			}
+
 			switch msg.String() {
 			case "esc":
 				if m.ActivePane == types.HeadersPane && m.HeadersMode == types.HeadersEditMode {
-					m = HandleHeaderEditCancel(m)
+					if m.HeadersPaneTab == types.QueryParamsTab {
+						m = HandleParamEditCancel(m)
+					} else if m.HeadersPaneTab == types.AuthTab {
+						m = HandleAuthEditCancel(m)
+					} else if m.HeadersPaneTab == types.AssertionsTab {
+						m = HandleAssertionEditCancel(m)
+					} else {
+						m = HandleHeaderEditCancel(m)
+					}
+					return m, nil
+				}
+				if m.ActivePane == types.CollectionsPane {
+					m = HandleCollectionsInputCancel(m)
+					return m, nil
+				}
+				if m.ActivePane == types.BodyPane && m.EditingFormField {
+					m = HandleFormEditCancel(m)
+					return m, nil
+				}
+				if m.ActivePane == types.BodyPane && m.ImportingCurl {
+					return HandleCurlImportCancel(m)
+				}
+				if m.ActivePane == types.ResponsePane {
+					m = HandleCookieEditCancel(m)
+					return m, nil
+				}
+				if m.ActivePane == types.MethodPane {
+					m = HandleMaxRedirectsEditCancel(m)
 					return m, nil
 				}
 				return m, tea.Quit
 			case "enter":
 				if m.ActivePane == types.HeadersPane && m.HeadersMode == types.HeadersEditMode {
-					m = HandleHeaderEditSave(m)
+					if m.HeadersPaneTab == types.QueryParamsTab {
+						m = HandleParamEditSave(m)
+					} else if m.HeadersPaneTab == types.AuthTab {
+						m = HandleAuthEditSave(m)
+					} else if m.HeadersPaneTab == types.AssertionsTab {
+						m = HandleAssertionEditSave(m)
+					} else {
+						m = HandleHeaderEditSave(m)
+					}
+					return m, nil
+				}
+				if m.ActivePane == types.CollectionsPane {
+					m = HandleCollectionsInputSubmit(m)
+					return m, nil
+				}
+				if m.ActivePane == types.BodyPane && m.EditingFormField {
+					m = HandleFormEditSave(m)
+					return m, nil
+				}
+				if m.ActivePane == types.ResponsePane {
+					m = HandleCookieEditSave(m)
+					return m, nil
+				}
+				if m.ActivePane == types.MethodPane {
+					m = HandleMaxRedirectsEditSave(m)
 					return m, nil
 				}
 
@@ -61,6 +216,57 @@ func Update(msg tea.Msg, m types.Model) (types.Model, tea.Cmd) {
 					return ExecuteRequestWithHistory(m)
 				}
 			}
+		} else if m.ActivePane == types.ResponsePane && m.Exporting {
+			// Export preview in the response pane
+			switch msg.String() {
+			case "left", "h":
+				m = HandleExportFormat(m, "left")
+			case "right", "l":
+				m = HandleExportFormat(m, "right")
+			case "up", "k":
+				m = HandleResponseScroll(m, "up")
+			case "down", "j":
+				m = HandleResponseScroll(m, "down")
+			case "enter", "c", "y":
+				m = HandleExportCopy(m)
+			case "w":
+				m = HandleExportWrite(m)
+			case "esc", "q":
+				m = HandleExportClose(m)
+			}
+			return m, nil
+		} else if m.ActivePane == types.ResponsePane && m.BrowsingGRPC {
+			// gRPC method list in the response pane
+			switch msg.String() {
+			case "up", "k":
+				m = HandleGRPCMethodsNavigation(m, "up")
+			case "down", "j":
+				m = HandleGRPCMethodsNavigation(m, "down")
+			case "enter":
+				return HandleGRPCMethodSelect(m)
+			case "esc", "q":
+				m = HandleGRPCMethodsClose(m)
+			}
+			return m, nil
+		} else if m.ActivePane == types.ResponsePane && m.BrowsingCookies {
+			// Cookie jar in the response pane
+			switch msg.String() {
+			case "up", "k":
+				m = HandleCookiesNavigation(m, "up")
+			case "down", "j":
+				m = HandleCookiesNavigation(m, "down")
+			case "enter", "e":
+				return HandleCookieEdit(m)
+			case "a", "n":
+				return HandleCookieAdd(m)
+			case "d", "x":
+				m = HandleCookieDelete(m)
+			case "D":
+				m = HandleCookiesClear(m)
+			case "esc", "q":
+				m = HandleCookiesClose(m)
+			}
+			return m, nil
 		} else {
 			// Non-text-input panes
 			switch msg.String() {
@@ -84,6 +290,10 @@ func Update(msg tea.Msg, m types.Model) (types.Model, tea.Cmd) {
 				return HandleJumpToPane(m, types.HeadersPane)
 			case "7":
 				return HandleJumpToPane(m, types.HistoryPane)
+			case "8":
+				return HandleJumpToPane(m, types.CollectionsPane)
+			case "9":
+				return HandleJumpToPane(m, types.EnvironmentsPane)
 			}
 
 			// Pane-specific handlers
@@ -106,10 +316,95 @@ func Update(msg tea.Msg, m types.Model) (types.Model, tea.Cmd) {
 					}
 					return m, nil
 
+				case "+", "=":
+					if m.ActivePane == types.MethodPane {
+						m = HandleTimeoutAdjust(m, "up")
+					}
+					return m, nil
+
+				case "-":
+					if m.ActivePane == types.MethodPane {
+						m = HandleTimeoutAdjust(m, "down")
+					}
+					return m, nil
+
+				case "v":
+					if m.ActivePane == types.MethodPane {
+						m = HandleTLSVerifyToggle(m)
+					}
+					return m, nil
+
+				case "o":
+					if m.ActivePane == types.MethodPane {
+						m = HandleCookiesToggle(m)
+					}
+					return m, nil
+
+				case "f":
+					if m.ActivePane == types.MethodPane {
+						m = HandleRedirectsToggle(m)
+					}
+					return m, nil
+
+				case "m":
+					if m.ActivePane == types.MethodPane {
+						return HandleMaxRedirectsEdit(m)
+					}
+					return m, nil
+
+				case "b":
+					if m.ActivePane == types.MethodPane && isWebSocketMode(m) {
+						m = HandleWebSocketFrameType(m)
+					}
+					return m, nil
+
+				case "c":
+					if m.ActivePane == types.MethodPane && isWebSocketMode(m) {
+						m = HandleWebSocketCloseCode(m)
+					}
+					return m, nil
+
+				case "r":
+					if m.ActivePane == types.MethodPane && isGRPCMode(m) {
+						return HandleGRPCListMethods(m)
+					}
+					return m, nil
+
 				case "enter":
 					return HandleMethodExecute(m)
 				}
 
+			case types.BodyPane:
+				// Form field list for urlencoded and multipart bodies
+				switch msg.String() {
+				case "up", "k":
+					m = HandleFormNavigation(m, "up")
+					return m, nil
+				case "down", "j":
+					m = HandleFormNavigation(m, "down")
+					return m, nil
+				case "shift+up", "K":
+					m = HandleFormMove(m, "up")
+					return m, nil
+				case "shift+down", "J":
+					m = HandleFormMove(m, "down")
+					return m, nil
+				case "a", "n":
+					return HandleFormAdd(m)
+				case "e", "enter":
+					return HandleFormEdit(m)
+				case "d", "x":
+					m = HandleFormDelete(m)
+					return m, nil
+				case " ":
+					m = HandleFormToggle(m)
+					return m, nil
+				case "alt+enter":
+					return ExecuteRequestWithHistory(m)
+				case "ctrl+t":
+					return HandleBodyTab(m)
+				}
+
 			case types.ResponsePane:
 				switch msg.String() {
 				case "enter":
@@ -132,11 +427,109 @@ func Update(msg tea.Msg, m types.Model) (types.Model, tea.Cmd) {
 				case "end", "G":
 					m = HandleResponseScroll(m, "bottom")
 					return m, nil
+				case "left", "h":
+					m = HandleResponseTab(m, "left")
+					return m, nil
+				case "right", "l":
+					m = HandleResponseTab(m, "right")
+					return m, nil
+				case "p":
+					m = HandleStreamPause(m)
+					return m, nil
+				case "c":
+					return HandleCookiesOpen(m)
 				}
 
 			case types.HeadersPane:
 				switch m.HeadersMode {
 				case types.HeadersViewMode:
+					switch msg.String() {
+					case "left", "h":
+						m = HandleHeadersPaneTab(m, "left")
+						return m, nil
+					case "right", "l":
+						m = HandleHeadersPaneTab(m, "right")
+						return m, nil
+					}
+
+					if m.HeadersPaneTab == types.QueryParamsTab {
+						switch msg.String() {
+						case "up", "k":
+							m = HandleParamsNavigation(m, "up")
+							return m, nil
+						case "down", "j":
+							m = HandleParamsNavigation(m, "down")
+							return m, nil
+						case "shift+up", "K":
+							m = HandleParamsMove(m, "up")
+							return m, nil
+						case "shift+down", "J":
+							m = HandleParamsMove(m, "down")
+							return m, nil
+						case "a", "n":
+							return HandleParamsAdd(m)
+						case "d", "x":
+							m = HandleParamsDelete(m)
+							return m, nil
+						case " ":
+							m = HandleParamsToggle(m)
+							return m, nil
+						case "e", "enter":
+							return HandleParamsEdit(m)
+						}
+						break
+					}
+
+					if m.HeadersPaneTab == types.AuthTab {
+						switch msg.String() {
+						case "up", "k":
+							m = HandleAuthNavigation(m, "up")
+							return m, nil
+						case "down", "j":
+							m = HandleAuthNavigation(m, "down")
+							return m, nil
+						case " ":
+							m = HandleAuthCycle(m)
+							return m, nil
+						case "d", "x":
+							m = HandleAuthClear(m)
+							return m, nil
+						case "f":
+							return HandleOAuth2Fetch(m)
+						case "e", "enter":
+							return HandleAuthEdit(m)
+						}
+						break
+					}
+
+					if m.HeadersPaneTab == types.AssertionsTab {
+						switch msg.String() {
+						case "up", "k":
+							m = HandleAssertionsNavigation(m, "up")
+							return m, nil
+						case "down", "j":
+							m = HandleAssertionsNavigation(m, "down")
+							return m, nil
+						case "shift+up", "K":
+							m = HandleAssertionsMove(m, "up")
+							return m, nil
+						case "shift+down", "J":
+							m = HandleAssertionsMove(m, "down")
+							return m, nil
+						case "a", "n":
+							return HandleAssertionsAdd(m)
+						case "d", "x":
+							m = HandleAssertionsDelete(m)
+							return m, nil
+						case " ":
+							m = HandleAssertionsToggle(m)
+							return m, nil
+						case "e", "enter":
+							return HandleAssertionsEdit(m)
+						}
+						break
+					}
+
 					switch msg.String() {
 					case "up", "k":
 						m = HandleCustomHeadersNavigation(m, "up")
@@ -209,12 +602,58 @@ func Update(msg tea.Msg, m types.Model) (types.Model, tea.Cmd) {
 					return m, nil
 				case "enter":
 					return HandleHistoryLoad(m)
+				case " ":
+					m = HandleHistoryMark(m)
+					return m, nil
+				case "w":
+					m = HandleHistoryExport(m)
+					return m, nil
+				case "r":
+					return HandleHistoryRun(m)
 				case "d", "x":
 					m = HandleHistoryDelete(m)
 					return m, nil
 				case "esc":
 					return m, tea.Quit
 				}
+
+			case types.CollectionsPane:
+				switch msg.String() {
+				case "up", "k":
+					m = HandleCollectionsNavigation(m, "up")
+					return m, nil
+				case "down", "j":
+					m = HandleCollectionsNavigation(m, "down")
+					return m, nil
+				case "enter":
+					return HandleCollectionsSelect(m)
+				case "s":
+					return HandleCollectionsSaveStart(m)
+				case "f":
+					return HandleCollectionsFolderStart(m)
+				case "d", "x":
+					m = HandleCollectionsDelete(m)
+					return m, nil
+				case "r":
+					m = ReloadCollections(m)
+					return m, nil
+				}
+
+			case types.EnvironmentsPane:
+				switch msg.String() {
+				case "up", "k":
+					m = HandleEnvironmentsNavigation(m, "up")
+					return m, nil
+				case "down", "j":
+					m = HandleEnvironmentsNavigation(m, "down")
+					return m, nil
+				case "enter":
+					m = HandleEnvironmentSelect(m)
+					return m, nil
+				case "r":
+					m = ReloadEnvironments(m)
+					return m, nil
+				}
 			}
 		}
 	}
@@ -222,16 +661,51 @@ func Update(msg tea.Msg, m types.Model) (types.Model, tea.Cmd) {
 	// Update the active input component
 	switch m.ActivePane {
 	case types.URLPane:
+		previousURL := m.URLInput.Value()
 		m.URLInput, cmd = m.URLInput.Update(msg)
 		cmds = append(cmds, cmd)
+		if m.URLInput.Value() != previousURL {
+			m = SyncParamsFromURL(m)
+		}
 	case types.BodyPane:
-		m.BodyInput, cmd = m.BodyInput.Update(msg)
+		if m.ImportingCurl {
+			m.CurlInput, cmd = m.CurlInput.Update(msg)
+		} else if bodyScriptMode(m) && m.BodyTab == types.PreScriptTab {
+			m.PreScriptInput, cmd = m.PreScriptInput.Update(msg)
+		} else if bodyScriptMode(m) {
+			m.PostScriptInput, cmd = m.PostScriptInput.Update(msg)
+		} else if bodyFormMode(m) {
+			if m.EditingFormField {
+				m.FormFieldInput, cmd = m.FormFieldInput.Update(msg)
+			}
+		} else if bodyGraphQLMode(m) && m.GraphQLEditor == types.GraphQLVariablesEditor {
+			m.GraphQLVariables, cmd = m.GraphQLVariables.Update(msg)
+		} else if bodyGraphQLMode(m) && m.GraphQLEditor == types.GraphQLOperationEditor {
+			m.GraphQLOperation, cmd = m.GraphQLOperation.Update(msg)
+		} else {
+			m.BodyInput, cmd = m.BodyInput.Update(msg)
+		}
 		cmds = append(cmds, cmd)
 	case types.HeadersPane:
 		if m.HeadersMode == types.HeadersEditMode {
 			m.HeaderEditInput, cmd = m.HeaderEditInput.Update(msg)
 			cmds = append(cmds, cmd)
 		}
+	case types.CollectionsPane:
+		if m.CollectionsMode != types.CollectionsViewMode {
+			m.CollectionNameInput, cmd = m.CollectionNameInput.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	case types.ResponsePane:
+		if m.EditingCookie {
+			m.CookieInput, cmd = m.CookieInput.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	case types.MethodPane:
+		if m.EditingMaxRedirects {
+			m.MaxRedirectsInput, cmd = m.MaxRedirectsInput.Update(msg)
+			cmds = append(cmds, cmd)
+		}
 	}
 
 	return m, tea.Batch(cmds...)
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/components"
+	"postty/src/services"
+	"postty/src/types"
+)
+
+// isWebSocketMode reports whether WS is selected in the Method pane
+func isWebSocketMode(m types.Model) bool {
+	return types.Methods[m.SelectedMethod] == types.WebSocketMethod
+}
+
+// HandleWebSocketConnected stores the new connection and starts listening for frames
+func HandleWebSocketConnected(m types.Model, msg types.WebSocketConnectedMsg) (types.Model, tea.Cmd) {
+	m.Executing = false
+	if m.CancelRequest != nil {
+		m.CancelRequest()
+		m.CancelRequest = nil
+	}
+
+	meta := msg.Meta
+	m.WebSocket = msg.Session
+	m.WebSocketRequest = m.PendingRequest
+	m.PendingRequest = nil
+	m.StatusCode = msg.StatusCode
+	m.ResponseHeaders = msg.Headers
+	m.ResponseMeta = &meta
+	m.ResponseTab = types.ResponseBodyTab
+	m.StatusMessage = "Connected. Alt+Enter in Body sends a frame, Ctrl+X closes"
+
+	url := ""
+	if m.WebSocketRequest != nil {
+		url = m.WebSocketRequest.URL
+	}
+	m = appendWebSocketLog(m, types.WebSocketMessage{
+		Time:      time.Now(),
+		Direction: types.WebSocketEvent,
+		Data:      fmt.Sprintf("Connected to %s in %s", services.WebSocketURL(url), meta.Duration.Round(time.Millisecond)),
+	})
+	return m, services.WaitWebSocket(msg.Session)
+}
+
+// HandleWebSocketMessage logs a received frame and waits for the next one
+func HandleWebSocketMessage(m types.Model, msg types.WebSocketMessageMsg) (types.Model, tea.Cmd) {
+	if msg.Session != m.WebSocket {
+		return m, services.WaitWebSocket(msg.Session)
+	}
+	m = appendWebSocketLog(m, msg.Message)
+	return m, services.WaitWebSocket(msg.Session)
+}
+
+// HandleWebSocketSent logs a frame sent, or a close started, from the form
+func HandleWebSocketSent(m types.Model, msg types.WebSocketSentMsg) types.Model {
+	if msg.Session != m.WebSocket {
+		return m
+	}
+	return appendWebSocketLog(m, msg.Message)
+}
+
+// HandleWebSocketClosed forgets the connection and records it in history with its log
+func HandleWebSocketClosed(m types.Model, msg types.WebSocketClosedMsg) types.Model {
+	if msg.Session != m.WebSocket {
+		return m
+	}
+
+	m.WebSocket = nil
+	m.StatusMessage = "WebSocket closed"
+	if m.WebSocketRequest != nil {
+		item := *m.WebSocketRequest
+		item.StatusCode = m.StatusCode
+		item.ResponseBody = components.RenderWebSocketLog(m.WebSocketLog)
+		item.ResponseHeaders = m.ResponseHeaders
+		item.ResponseMeta = m.ResponseMeta
+		m = AddToHistory(m, item)
+		m.WebSocketRequest = nil
+	}
+	return m
+}
+
+// HandleWebSocketSend sends the body as a text or binary frame on the open connection
+func HandleWebSocketSend(m types.Model) (types.Model, tea.Cmd) {
+	body, unresolved := services.Interpolate(m.BodyInput.Value(), activeVariables(m))
+	if len(unresolved) > 0 {
+		m.StatusMessage = fmt.Sprintf("Unresolved variables: %s", strings.Join(unresolved, ", "))
+		return m, nil
+	}
+
+	data, err := services.WebSocketPayload(body, m.WebSocketBinary)
+	if err != nil {
+		m.StatusMessage = err.Error()
+		return m, nil
+	}
+
+	m.StatusMessage = ""
+	return m, services.SendWebSocket(m.WebSocket, m.WebSocketBinary, data)
+}
+
+// HandleWebSocketClose starts closing the open connection with the selected close code
+func HandleWebSocketClose(m types.Model) (types.Model, tea.Cmd) {
+	code := types.WebSocketCloseCodes[m.WebSocketCloseCode].Code
+	m.StatusMessage = "Closing WebSocket..."
+	return m, services.CloseWebSocket(m.WebSocket, code)
+}
+
+// HandleWebSocketFrameType switches between sending text and binary frames
+func HandleWebSocketFrameType(m types.Model) types.Model {
+	m.WebSocketBinary = !m.WebSocketBinary
+	return m
+}
+
+// HandleWebSocketCloseCode cycles through the close codes offered for closing
+func HandleWebSocketCloseCode(m types.Model) types.Model {
+	m.WebSocketCloseCode = (m.WebSocketCloseCode + 1) % len(types.WebSocketCloseCodes)
+	return m
+}
+
+// appendWebSocketLog adds an entry to the message log, following the end of the log
+// unless it has been scrolled up
+func appendWebSocketLog(m types.Model, message types.WebSocketMessage) types.Model {
+	following := m.ResponseViewport.AtBottom()
+	m.WebSocketLog = append(m.WebSocketLog, message)
+	m.ResponseBody = components.RenderWebSocketLog(m.WebSocketLog)
+	m = refreshResponseView(m)
+	if following && m.ResponseTab == types.ResponseBodyTab {
+		m.ResponseViewport.GotoBottom()
+	}
+	return m
+}
@@ -36,12 +36,33 @@ func HandleWindowSize(m types.Model, msg tea.WindowSizeMsg) types.Model {
 	}
 	m.BodyInput.SetHeight(bodyContentHeight)
 
+	// The cURL import editor takes the place of the body input
+	m.CurlInput.SetWidth(bodyInputWidth)
+	m.CurlInput.SetHeight(bodyContentHeight)
+
+	// So does the form field list, with its input on the footer line
+	m.FormViewport.Width = bodyInputWidth
+	m.FormViewport.Height = bodyContentHeight
+	m.FormFieldInput.Width = bodyInputWidth - 4
+
+	// And the GraphQL variables and operation name editors
+	m.GraphQLVariables.SetWidth(bodyInputWidth)
+	m.GraphQLVariables.SetHeight(bodyContentHeight)
+	m.GraphQLOperation.Width = bodyInputWidth - 4
+
+	// And the script editors
+	m.PreScriptInput.SetWidth(bodyInputWidth)
+	m.PreScriptInput.SetHeight(bodyContentHeight)
+	m.PostScriptInput.SetWidth(bodyInputWidth)
+	m.PostScriptInput.SetHeight(bodyContentHeight)
+
 	// Update Response viewport
 	viewportWidth := dims.MiddleColumnWidth - 4
 	if viewportWidth < 20 {
 		viewportWidth = 20
 	}
 	m.ResponseViewport.Width = viewportWidth
+	m.CookieInput.Width = viewportWidth - 4
 
 	// Response height: pane height minus border (2) and title line (1) and padding (1)
 	responseViewportHeight := dims.ResultHeight - 4
@@ -57,13 +78,34 @@ func HandleWindowSize(m types.Model, msg tea.WindowSizeMsg) types.Model {
 	}
 	m.HistoryViewport.Width = historyViewportWidth
 
-	// History height: pane height minus border (2) and title (1) and help text (1) and padding (2)
-	historyViewportHeight := dims.HistoryHeight - 6
+	// History height: pane height minus border (2) and title (1) and help text (2) and padding (2)
+	historyViewportHeight := dims.HistoryHeight - 7
 	if historyViewportHeight < 5 {
 		historyViewportHeight = 5
 	}
 	m.HistoryViewport.Height = historyViewportHeight
 
+	// Update Collections viewport and name prompt
+	m.CollectionsViewport.Width = historyViewportWidth
+	m.CollectionNameInput.Width = historyViewportWidth - 4
+
+	// Collections height: pane height minus border (2) and title (1) and help text (1)
+	collectionsViewportHeight := dims.CollectionsHeight - 4
+	if collectionsViewportHeight < 3 {
+		collectionsViewportHeight = 3
+	}
+	m.CollectionsViewport.Height = collectionsViewportHeight
+
+	// Update Environments viewport
+	m.EnvironmentsViewport.Width = historyViewportWidth
+
+	// Environments height: pane height minus border (2) and title (1) and help text (1)
+	environmentsViewportHeight := dims.EnvironmentsHeight - 4
+	if environmentsViewportHeight < 3 {
+		environmentsViewportHeight = 3
+	}
+	m.EnvironmentsViewport.Height = environmentsViewportHeight
+
 	// Update Method viewport
 	methodViewportWidth := dims.RightColumnWidth - 4
 	if methodViewportWidth < 15 {
@@ -71,8 +113,8 @@ func HandleWindowSize(m types.Model, msg tea.WindowSizeMsg) types.Model {
 	}
 	m.MethodViewport.Width = methodViewportWidth
 
-	// Method height: pane height minus border (2) and title (1) and padding (1)
-	methodViewportHeight := dims.MethodHeight - 4
+	// Method height: pane height minus border (2) and title (1) and timeout, TLS and redirect lines (4) and padding (1)
+	methodViewportHeight := dims.MethodHeight - 8
 	if methodViewportHeight < 3 {
 		methodViewportHeight = 3
 	}
@@ -1,11 +1,14 @@
 package model
 
 import (
+	"fmt"
+
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 
+	"postty/src/services"
 	"postty/src/types"
 )
 
@@ -14,7 +17,7 @@ func New() types.Model {
 	ti := textinput.New()
 	ti.Placeholder = "https://api.example.com/endpoint"
 	ti.Focus()
-	ti.CharLimit = 500
+	ti.CharLimit = types.MaxURLLength
 	ti.Width = 40
 
 	ta := textarea.New()
@@ -22,6 +25,31 @@ func New() types.Model {
 	ta.SetWidth(40)
 	ta.SetHeight(8)
 
+	ci := textarea.New()
+	ci.Placeholder = "Paste a curl command"
+	ci.SetWidth(40)
+	ci.SetHeight(8)
+
+	gvi := textarea.New()
+	gvi.Placeholder = `GraphQL variables as a JSON object, e.g. {"id": 1}`
+	gvi.SetWidth(40)
+	gvi.SetHeight(8)
+
+	psi := textarea.New()
+	psi.Placeholder = "Pre-request script, e.g. request.setHeader('X-Signature', crypto.hmacSHA256(env.get('secret'), request.body))"
+	psi.SetWidth(40)
+	psi.SetHeight(8)
+
+	rsi := textarea.New()
+	rsi.Placeholder = "Post-response script, e.g. env.set('token', response.json().token)"
+	rsi.SetWidth(40)
+	rsi.SetHeight(8)
+
+	goi := textinput.New()
+	goi.Placeholder = "operationName, needed when the query has several operations"
+	goi.CharLimit = 200
+	goi.Width = 40
+
 	vp := viewport.New(40, 10)
 	vp.SetContent("")
 
@@ -34,15 +62,64 @@ func New() types.Model {
 	ctvp := viewport.New(40, 6)
 	ctvp.SetContent("")
 
+	cvp := viewport.New(30, 8)
+	cvp.SetContent("")
+
+	evp := viewport.New(30, 6)
+	evp.SetContent("")
+
+	cni := textinput.New()
+	cni.CharLimit = 200
+	cni.Width = 25
+
 	hei := textinput.New()
 	hei.Placeholder = "Enter header value"
 	hei.CharLimit = 500
 	hei.Width = 30
 
+	ffi := textinput.New()
+	ffi.Placeholder = "name=value or name=@path;type=image/png"
+	ffi.CharLimit = 2000
+	ffi.Width = 40
+
+	fvp := viewport.New(40, 5)
+	fvp.SetContent("")
+
+	mri := textinput.New()
+	mri.Placeholder = fmt.Sprint(types.DefaultMaxRedirects)
+	mri.CharLimit = 3
+	mri.Width = 4
+
+	cki := textinput.New()
+	cki.Placeholder = "name=value; Domain=example.com; Path=/"
+	cki.CharLimit = 2000
+	cki.Width = 40
+
 	defaultHeaders := []types.Header{}
+
+	// Load settings and persisted history; problems are reported but never fatal
+	statusMessage := ""
+	config, err := services.LoadConfig()
+	if err != nil {
+		statusMessage = fmt.Sprintf("Config error: %v", err)
+	}
+
 	history := []types.HistoryItem{}
+	historyPath, err := services.HistoryPath()
+	if err != nil {
+		statusMessage = fmt.Sprintf("History disabled: %v", err)
+		historyPath = ""
+	} else {
+		loaded, skipped, err := services.LoadHistory(historyPath, config.HistoryLimit)
+		history = loaded
+		if err != nil {
+			statusMessage = fmt.Sprintf("History error: %v", err)
+		} else if skipped > 0 {
+			statusMessage = fmt.Sprintf("Skipped %d corrupt history entries", skipped)
+		}
+	}
 
-	return types.Model{
+	m := types.Model{
 		ActivePane:           types.URLPane,
 		SelectedMethod:       0,
 		SelectedHeader:       0,
@@ -64,7 +141,54 @@ func New() types.Model {
 		SelectedHistory:      0,
 		HistoryViewport:      hvp,
 		PendingRequest:       nil,
+		Config:               config,
+		HistoryPath:          historyPath,
+		HistoryLogEntries:    len(history),
+		StatusMessage:        statusMessage,
+		CollectionsDir:       config.CollectionsDir,
+		CollapsedFolders:     map[string]bool{},
+		SelectedCollection:   0,
+		CollectionsMode:      types.CollectionsViewMode,
+		CollectionNameInput:  cni,
+		CollectionsViewport:  cvp,
+		EnvironmentsDir:      config.EnvironmentsDir,
+		SelectedEnvironment:  0,
+		ActiveEnvironment:    -1,
+		EnvironmentsViewport: evp,
+		FormFieldInput:       ffi,
+		FormViewport:         fvp,
+		GraphQLVariables:     gvi,
+		GraphQLOperation:     goi,
+		PreScriptInput:       psi,
+		PostScriptInput:      rsi,
+		ImportingCurl:        false,
+		CurlInput:            ci,
+		CookieInput:          cki,
+		MaxRedirectsInput:    mri,
+	}
+
+	collections, err := services.LoadCollections(m.CollectionsDir)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Collections: %v", err)
+	}
+	m.Collections = collections
+	m.CollectionNodes = services.CollectionNodes(&m.Collections, m.CollapsedFolders)
+
+	environments, err := services.LoadEnvironments(m.EnvironmentsDir)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Environments: %v", err)
 	}
+	m.Environments = environments
+
+	if config.PersistCookies {
+		if cookiesPath, err := services.CookiesPath(); err != nil {
+			m.StatusMessage = fmt.Sprintf("Cookies: %v", err)
+		} else if err := services.LoadCookies(cookiesPath); err != nil {
+			m.StatusMessage = fmt.Sprintf("Cookies: %v", err)
+		}
+	}
+
+	return m
 }
 
 // Init returns the initial command for the application
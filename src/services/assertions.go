@@ -0,0 +1,238 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"postty/src/types"
+)
+
+// ParseAssertion reads an assertion written as "<subject> <operator> <value>":
+//
+//	status == 200
+//	header Content-Type ~ ^application/json
+//	$.data.id == 42
+//	$.tags contains "admin"
+//	time < 500
+//	body ~ "ok":\s*true
+func ParseAssertion(text string) (types.Assertion, error) {
+	text = strings.TrimSpace(text)
+	subject, rest, _ := strings.Cut(text, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch {
+	case subject == "status":
+		value, ok := strings.CutPrefix(rest, "==")
+		value = strings.TrimSpace(value)
+		if _, err := strconv.Atoi(value); !ok || err != nil {
+			return types.Assertion{}, fmt.Errorf("write a status assertion as: status == 200")
+		}
+		return types.Assertion{Kind: types.AssertStatus, Value: value}, nil
+
+	case subject == "header":
+		name, pattern, ok := strings.Cut(rest, " ~ ")
+		if !ok || strings.TrimSpace(name) == "" {
+			return types.Assertion{}, fmt.Errorf("write a header assertion as: header Content-Type ~ json")
+		}
+		assertion := types.Assertion{Kind: types.AssertHeader, Target: strings.TrimSpace(name), Value: strings.TrimSpace(pattern)}
+		return assertion, checkPattern(assertion.Value)
+
+	case strings.HasPrefix(subject, "$"):
+		// The path may hold spaces inside quoted names, so look for the operator instead
+		for _, operator := range []struct {
+			text string
+			kind types.AssertionKind
+		}{{" == ", types.AssertJSONEquals}, {" contains ", types.AssertJSONContains}} {
+			if path, value, ok := strings.Cut(text, operator.text); ok {
+				if _, err := parseJSONPath(path); err != nil {
+					return types.Assertion{}, err
+				}
+				return types.Assertion{Kind: operator.kind, Target: strings.TrimSpace(path), Value: strings.TrimSpace(value)}, nil
+			}
+		}
+		return types.Assertion{}, fmt.Errorf("write a JSONPath assertion as: $.id == 42 or $.tags contains \"x\"")
+
+	case subject == "time":
+		value, ok := strings.CutPrefix(rest, "<")
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "ms"))
+		if _, err := strconv.Atoi(value); !ok || err != nil {
+			return types.Assertion{}, fmt.Errorf("write a response time assertion as: time < 500")
+		}
+		return types.Assertion{Kind: types.AssertTime, Value: value}, nil
+
+	case subject == "body":
+		pattern, ok := strings.CutPrefix(rest, "~")
+		if !ok {
+			return types.Assertion{}, fmt.Errorf("write a body assertion as: body ~ pattern")
+		}
+		assertion := types.Assertion{Kind: types.AssertBody, Value: strings.TrimSpace(pattern)}
+		return assertion, checkPattern(assertion.Value)
+	}
+	return types.Assertion{}, fmt.Errorf("assertions start with status, header, $, time or body")
+}
+
+// FormatAssertion writes an assertion the way ParseAssertion reads it
+func FormatAssertion(assertion types.Assertion) string {
+	switch assertion.Kind {
+	case types.AssertStatus:
+		return "status == " + assertion.Value
+	case types.AssertHeader:
+		return "header " + assertion.Target + " ~ " + assertion.Value
+	case types.AssertJSONEquals:
+		return assertion.Target + " == " + assertion.Value
+	case types.AssertJSONContains:
+		return assertion.Target + " contains " + assertion.Value
+	case types.AssertTime:
+		return "time < " + assertion.Value
+	case types.AssertBody:
+		return "body ~ " + assertion.Value
+	}
+	return string(assertion.Kind)
+}
+
+// checkPattern reports whether pattern is a valid regular expression
+func checkPattern(pattern string) error {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	return nil
+}
+
+// EvaluateAssertions checks the enabled assertions against a response. When the request
+// failed, every assertion fails.
+func EvaluateAssertions(assertions []types.Assertion, resp types.ResponseMsg) []types.AssertionResult {
+	var results []types.AssertionResult
+	var document any
+	var documentErr error
+	decoded := false
+
+	for _, assertion := range assertions {
+		if assertion.Disabled {
+			continue
+		}
+		result := types.AssertionResult{Assertion: FormatAssertion(assertion)}
+		if resp.Err != nil {
+			result.Message = "no response"
+			results = append(results, result)
+			continue
+		}
+
+		switch assertion.Kind {
+		case types.AssertStatus:
+			result.Passed = strconv.Itoa(resp.StatusCode) == assertion.Value
+			result.Message = fmt.Sprintf("got %d", resp.StatusCode)
+
+		case types.AssertHeader:
+			result.Message = fmt.Sprintf("no %s header", assertion.Target)
+			for _, h := range resp.Headers {
+				if strings.EqualFold(h.Key, assertion.Target) {
+					result.Passed, result.Message = matchPattern(assertion.Value, h.Value)
+					break
+				}
+			}
+
+		case types.AssertJSONEquals, types.AssertJSONContains:
+			if !decoded {
+				decoded = true
+				documentErr = json.Unmarshal([]byte(resp.Body), &document)
+			}
+			if documentErr != nil {
+				result.Message = "body is not JSON"
+				break
+			}
+			actual, err := EvaluateJSONPath(document, assertion.Target)
+			if err != nil {
+				result.Message = err.Error()
+				break
+			}
+			expected := assertionValue(assertion.Value)
+			if assertion.Kind == types.AssertJSONEquals {
+				result.Passed = reflect.DeepEqual(actual, expected)
+			} else {
+				result.Passed = jsonContains(actual, expected)
+			}
+			result.Message = "got " + compactJSON(actual)
+
+		case types.AssertTime:
+			limit, _ := strconv.Atoi(assertion.Value)
+			took := resp.Meta.Duration.Milliseconds()
+			result.Passed = took < int64(limit)
+			result.Message = fmt.Sprintf("took %d ms", took)
+
+		case types.AssertBody:
+			result.Passed, result.Message = matchPattern(assertion.Value, resp.Body)
+		}
+
+		if result.Passed {
+			result.Message = ""
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// matchPattern matches text against a regular expression, describing a mismatch
+func matchPattern(pattern, text string) (bool, string) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, fmt.Sprintf("invalid pattern: %v", err)
+	}
+	if re.MatchString(text) {
+		return true, ""
+	}
+	if len(text) > 80 {
+		text = text[:80] + "..."
+	}
+	return false, fmt.Sprintf("got %q", text)
+}
+
+// assertionValue reads the expected value of a JSONPath assertion as JSON, falling back to
+// a plain string so that $.name == Alice works without quotes
+func assertionValue(text string) any {
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return text
+	}
+	return value
+}
+
+// jsonContains reports whether a string contains a substring, an array holds an element
+// or an object has a key
+func jsonContains(actual, expected any) bool {
+	switch value := actual.(type) {
+	case string:
+		text, ok := expected.(string)
+		if !ok {
+			text = compactJSON(expected)
+		}
+		return strings.Contains(value, text)
+	case []any:
+		for _, element := range value {
+			if reflect.DeepEqual(element, expected) {
+				return true
+			}
+		}
+	case map[string]any:
+		if key, ok := expected.(string); ok {
+			_, found := value[key]
+			return found
+		}
+	}
+	return false
+}
+
+// compactJSON renders a decoded value as one line of JSON
+func compactJSON(value any) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"postty/src/types"
+)
+
+func TestParseAssertion(t *testing.T) {
+	tests := []struct {
+		text string
+		want types.Assertion
+	}{
+		{"status == 200", types.Assertion{Kind: types.AssertStatus, Value: "200"}},
+		{"header Content-Type ~ ^application/json", types.Assertion{Kind: types.AssertHeader, Target: "Content-Type", Value: "^application/json"}},
+		{"$.data.id == 42", types.Assertion{Kind: types.AssertJSONEquals, Target: "$.data.id", Value: "42"}},
+		{`$['a b'] contains "x"`, types.Assertion{Kind: types.AssertJSONContains, Target: "$['a b']", Value: `"x"`}},
+		{"time < 500ms", types.Assertion{Kind: types.AssertTime, Value: "500"}},
+		{`body ~ "ok":\s*true`, types.Assertion{Kind: types.AssertBody, Value: `"ok":\s*true`}},
+	}
+	for _, tt := range tests {
+		got, err := ParseAssertion(tt.text)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %+v, %v", tt.text, got, err)
+		}
+		if again, _ := ParseAssertion(FormatAssertion(got)); again != got {
+			t.Errorf("%s: FormatAssertion gave %q", tt.text, FormatAssertion(got))
+		}
+	}
+
+	for _, text := range []string{"status = 200", "status == ok", "header X", "$.a", "$.a[ == 1", "time > 5", "body ~ (", "foo"} {
+		if _, err := ParseAssertion(text); err == nil {
+			t.Errorf("%s: expected an error", text)
+		}
+	}
+}
+
+func TestEvaluateAssertions(t *testing.T) {
+	resp := types.ResponseMsg{
+		StatusCode: 200,
+		Headers:    []types.Header{{Key: "Content-Type", Value: "application/json"}},
+		Body:       `{"id":42,"tags":["admin","x"],"name":"Alice","user":{"role":"admin"}}`,
+		Meta:       types.ResponseMeta{Duration: 120 * time.Millisecond},
+	}
+	texts := []string{
+		"status == 200",
+		"status == 201",
+		"header content-type ~ json",
+		"header X-Missing ~ .",
+		"$.id == 42",
+		`$.tags contains "admin"`,
+		"$.name == Alice",
+		"$.name == Bob",
+		"$.name contains lic",
+		"$.user contains role",
+		"$.nope == 1",
+		"time < 100",
+		"time < 500",
+		"body ~ Alice",
+	}
+	var assertions []types.Assertion
+	for _, text := range texts {
+		assertion, err := ParseAssertion(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertions = append(assertions, assertion)
+	}
+	assertions = append(assertions, types.Assertion{Kind: types.AssertStatus, Value: "500", Disabled: true})
+
+	want := []types.AssertionResult{
+		{Assertion: "status == 200", Passed: true},
+		{Assertion: "status == 201", Message: "got 200"},
+		{Assertion: "header content-type ~ json", Passed: true},
+		{Assertion: "header X-Missing ~ .", Message: "no X-Missing header"},
+		{Assertion: "$.id == 42", Passed: true},
+		{Assertion: `$.tags contains "admin"`, Passed: true},
+		{Assertion: "$.name == Alice", Passed: true},
+		{Assertion: "$.name == Bob", Message: `got "Alice"`},
+		{Assertion: "$.name contains lic", Passed: true},
+		{Assertion: "$.user contains role", Passed: true},
+		{Assertion: "$.nope == 1", Message: `$.nope: no field "nope"`},
+		{Assertion: "time < 100", Message: "took 120 ms"},
+		{Assertion: "time < 500", Passed: true},
+		{Assertion: "body ~ Alice", Passed: true},
+	}
+	if got := EvaluateAssertions(assertions, resp); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v", got)
+	}
+
+	resp.Body = "<html>"
+	if got := EvaluateAssertions(assertions[4:5], resp); got[0].Message != "body is not JSON" {
+		t.Errorf("got %+v for an HTML body", got)
+	}
+	failed := EvaluateAssertions(assertions[:1], types.ResponseMsg{Err: errors.New("refused")})
+	if failed[0].Passed || failed[0].Message != "no response" {
+		t.Errorf("got %+v for a failed request", failed)
+	}
+}
@@ -0,0 +1,503 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"postty/src/types"
+)
+
+// DefaultHMACHeader carries the HMAC signature when no header is set
+const DefaultHMACHeader = "X-Signature"
+
+// Where an API key is added to the request
+const (
+	APIKeyInHeader = "header"
+	APIKeyInQuery  = "query"
+)
+
+// AuthField describes a field of the Auth tab
+type AuthField struct {
+	Name    string // Which Auth field it edits
+	Label   string
+	Secret  bool     // Masked when shown
+	Choices []string // Values cycled through instead of typed; the first is the default
+	Default string   // Used when the field is left empty
+}
+
+// authTypeNames are the labels of the auth types
+var authTypeNames = map[types.AuthType]string{
+	types.AuthNone:   "None",
+	types.AuthBasic:  "Basic",
+	types.AuthBearer: "Bearer token",
+	types.AuthAPIKey: "API key",
+	types.AuthDigest: "Digest",
+	types.AuthHMAC:   "HMAC signature",
+	types.AuthOAuth2: "OAuth 2.0",
+	types.AuthSigV4:  "AWS Signature v4",
+}
+
+// AuthTypeName returns the label of an auth type
+func AuthTypeName(t types.AuthType) string {
+	if name, ok := authTypeNames[t]; ok {
+		return name
+	}
+	return string(t)
+}
+
+// AuthFields returns the fields of the Auth tab for the type of auth, starting with the type itself
+func AuthFields(auth types.Auth) []AuthField {
+	fields := []AuthField{{Name: "type", Label: "Type"}}
+	switch auth.Type {
+	case types.AuthBasic, types.AuthDigest:
+		fields = append(fields,
+			AuthField{Name: "username", Label: "Username"},
+			AuthField{Name: "password", Label: "Password", Secret: true},
+		)
+	case types.AuthBearer:
+		fields = append(fields, AuthField{Name: "token", Label: "Token", Secret: true})
+	case types.AuthAPIKey:
+		fields = append(fields,
+			AuthField{Name: "key", Label: "Name"},
+			AuthField{Name: "value", Label: "Value", Secret: true},
+			AuthField{Name: "in", Label: "Add to", Choices: []string{APIKeyInHeader, APIKeyInQuery}},
+		)
+	case types.AuthHMAC:
+		fields = append(fields,
+			AuthField{Name: "key", Label: "Key ID"},
+			AuthField{Name: "secret", Label: "Secret", Secret: true},
+			AuthField{Name: "algorithm", Label: "Hash", Choices: []string{"sha256", "sha1", "sha512"}},
+			AuthField{Name: "header", Label: "Header", Default: DefaultHMACHeader},
+		)
+	case types.AuthOAuth2:
+		fields = append(fields, AuthField{Name: "grant", Label: "Grant", Choices: []string{OAuth2ClientCredentials, OAuth2Password, OAuth2AuthorizationCode}})
+		if auth.Grant == OAuth2AuthorizationCode {
+			fields = append(fields, AuthField{Name: "auth_url", Label: "Auth URL"})
+		}
+		fields = append(fields,
+			AuthField{Name: "token_url", Label: "Token URL"},
+			AuthField{Name: "client_id", Label: "Client ID"},
+			AuthField{Name: "client_secret", Label: "Secret", Secret: true},
+		)
+		if auth.Grant == OAuth2Password {
+			fields = append(fields,
+				AuthField{Name: "username", Label: "Username"},
+				AuthField{Name: "password", Label: "Password", Secret: true},
+			)
+		}
+		fields = append(fields, AuthField{Name: "scope", Label: "Scope"})
+		if auth.Grant == OAuth2AuthorizationCode {
+			fields = append(fields, AuthField{Name: "redirect_url", Label: "Redirect", Default: "any 127.0.0.1 port"})
+		}
+	case types.AuthSigV4:
+		fields = append(fields,
+			AuthField{Name: "access_key", Label: "Access key"},
+			AuthField{Name: "secret_key", Label: "Secret key", Secret: true},
+			AuthField{Name: "session_token", Label: "Session", Secret: true},
+			AuthField{Name: "region", Label: "Region"},
+			AuthField{Name: "service", Label: "Service"},
+		)
+	}
+	return fields
+}
+
+// AuthFieldValue returns the value of the named field of auth
+func AuthFieldValue(auth types.Auth, name string) string {
+	if field := authFieldPointer(&auth, name); field != nil {
+		return *field
+	}
+	return ""
+}
+
+// SetAuthField returns auth with the named field set to value
+func SetAuthField(auth types.Auth, name, value string) types.Auth {
+	if field := authFieldPointer(&auth, name); field != nil {
+		*field = value
+	}
+	return auth
+}
+
+// authFieldPointer returns the Auth field a field name stands for
+func authFieldPointer(auth *types.Auth, name string) *string {
+	switch name {
+	case "username":
+		return &auth.Username
+	case "password":
+		return &auth.Password
+	case "token":
+		return &auth.Token
+	case "key":
+		return &auth.Key
+	case "value":
+		return &auth.Value
+	case "in":
+		return &auth.In
+	case "secret":
+		return &auth.Secret
+	case "algorithm":
+		return &auth.Algorithm
+	case "header":
+		return &auth.Header
+	case "grant":
+		return &auth.Grant
+	case "token_url":
+		return &auth.TokenURL
+	case "auth_url":
+		return &auth.AuthURL
+	case "redirect_url":
+		return &auth.RedirectURL
+	case "client_id":
+		return &auth.ClientID
+	case "client_secret":
+		return &auth.ClientSecret
+	case "scope":
+		return &auth.Scope
+	case "access_key":
+		return &auth.AccessKey
+	case "secret_key":
+		return &auth.SecretKey
+	case "session_token":
+		return &auth.SessionToken
+	case "region":
+		return &auth.Region
+	case "service":
+		return &auth.Service
+	}
+	return nil
+}
+
+// mapAuthText applies replace to the text fields of auth
+func mapAuthText(auth types.Auth, replace func(string) string) types.Auth {
+	for _, name := range []string{"username", "password", "token", "key", "value", "secret", "header",
+		"token_url", "auth_url", "redirect_url", "client_id", "client_secret", "scope",
+		"access_key", "secret_key", "session_token", "region", "service"} {
+		field := authFieldPointer(&auth, name)
+		*field = replace(*field)
+	}
+	return auth
+}
+
+// secretAuthFields are the Auth fields holding secrets
+var secretAuthFields = []string{"password", "token", "value", "secret", "client_secret", "secret_key", "session_token"}
+
+// RedactSecrets returns req as it is written to collections, sequences and history, without
+// the secrets typed into its auth and TLS fields. Secrets given as {{name}} references are
+// kept, as their values live in the environment. It reports whether a secret was left out.
+func RedactSecrets(req types.Request) (types.Request, bool) {
+	redacted := false
+	strip := func(field *string) {
+		if *field != "" && !strings.Contains(*field, "{{") {
+			*field = ""
+			redacted = true
+		}
+	}
+	for _, name := range secretAuthFields {
+		strip(authFieldPointer(&req.Auth, name))
+	}
+	strip(&req.TLS.CertPassword)
+	return req, redacted
+}
+
+// applyAuth adds the credentials of the auth of request to req. body is the body req is sent
+// with, which an HMAC signature covers. Digest auth is applied once the server's challenge
+// arrives, and an OAuth2 token is fetched, or refreshed, first when none is cached or it is
+// about to expire.
+func applyAuth(req *http.Request, request types.Request, body string) error {
+	auth := request.Auth
+	switch auth.Type {
+	case types.AuthBasic:
+		req.SetBasicAuth(auth.Username, auth.Password)
+
+	case types.AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+
+	case types.AuthAPIKey:
+		if auth.Key == "" {
+			return errors.New("auth: the API key has no name")
+		}
+		if auth.In == APIKeyInQuery {
+			param := url.QueryEscape(auth.Key) + "=" + url.QueryEscape(auth.Value)
+			if req.URL.RawQuery == "" {
+				req.URL.RawQuery = param
+			} else {
+				req.URL.RawQuery += "&" + param
+			}
+		} else {
+			req.Header.Set(auth.Key, auth.Value)
+		}
+
+	case types.AuthHMAC:
+		return signHMAC(req, auth, body, time.Now())
+
+	case types.AuthOAuth2:
+		token, err := OAuth2Token(req.Context(), request)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", tokenType(token)+" "+token.AccessToken)
+
+	case types.AuthSigV4:
+		return signSigV4(req, auth, body, time.Now())
+	}
+	return nil
+}
+
+// signHMAC signs req with the secret of auth. The signature covers, one per line, the method,
+// the path and query, the Unix timestamp sent in X-Timestamp and the hex hash of the body.
+func signHMAC(req *http.Request, auth types.Auth, body string, now time.Time) error {
+	newHash, err := hmacHash(auth.Algorithm)
+	if err != nil {
+		return err
+	}
+	if auth.Secret == "" {
+		return errors.New("auth: the HMAC secret is empty")
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	bodyHash := newHash()
+	bodyHash.Write([]byte(body))
+	payload := strings.Join([]string{
+		req.Method,
+		req.URL.RequestURI(),
+		timestamp,
+		hex.EncodeToString(bodyHash.Sum(nil)),
+	}, "\n")
+
+	mac := hmac.New(newHash, []byte(auth.Secret))
+	mac.Write([]byte(payload))
+
+	header := auth.Header
+	if header == "" {
+		header = DefaultHMACHeader
+	}
+	req.Header.Set("X-Timestamp", timestamp)
+	req.Header.Set(header, hex.EncodeToString(mac.Sum(nil)))
+	if auth.Key != "" {
+		req.Header.Set("X-Key-Id", auth.Key)
+	}
+	return nil
+}
+
+// hmacHash returns the hash an HMAC signature is made with
+func hmacHash(algorithm string) (func() hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha512":
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("auth: unknown HMAC hash %q, expected sha256, sha1 or sha512", algorithm)
+}
+
+// answerDigestChallenge resends a request the server turned away with a Digest challenge,
+// with an Authorization header answering it. Other responses are returned as they are.
+func answerDigestChallenge(ctx context.Context, client *http.Client, resp *http.Response, request types.Request, body, contentType string) (*http.Response, error) {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	challenge, ok := digestChallenge(resp.Header)
+	if !ok {
+		return resp, nil
+	}
+
+	req, err := newHTTPRequest(ctx, request, body, contentType)
+	if err != nil {
+		return resp, nil
+	}
+	authorization, err := digestAuthorization(challenge, req.Method, req.URL.RequestURI(), body, request.Auth)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	req.Header.Set("Authorization", authorization)
+	return client.Do(req)
+}
+
+// digestChallenge returns the parameters of the Digest challenge among the WWW-Authenticate
+// headers of a response
+func digestChallenge(header http.Header) (map[string]string, bool) {
+	for _, value := range header.Values("WWW-Authenticate") {
+		scheme, params, _ := strings.Cut(strings.TrimSpace(value), " ")
+		if strings.EqualFold(scheme, "Digest") {
+			return parseAuthParams(params), true
+		}
+	}
+	return nil, false
+}
+
+// parseAuthParams reads the comma separated name=value pairs of a challenge, where values
+// may be quoted
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for s != "" {
+		s = strings.TrimLeft(s, " \t,")
+		name, rest, found := strings.Cut(s, "=")
+		if !found {
+			break
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		rest = strings.TrimLeft(rest, " \t")
+
+		var value strings.Builder
+		if strings.HasPrefix(rest, `"`) {
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				value.WriteByte(rest[i])
+			}
+			rest = rest[min(i+1, len(rest)):]
+		} else {
+			end := strings.IndexByte(rest, ',')
+			if end < 0 {
+				end = len(rest)
+			}
+			value.WriteString(strings.TrimSpace(rest[:end]))
+			rest = rest[end:]
+		}
+		params[name] = value.String()
+		s = rest
+	}
+	return params
+}
+
+// digestAuthorization computes the Authorization header answering a Digest challenge
+// (RFC 7616), preferring qop=auth over auth-int when the server offers both
+func digestAuthorization(challenge map[string]string, method, uri, body string, auth types.Auth) (string, error) {
+	algorithm := challenge["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	var newHash func() hash.Hash
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("auth: unsupported Digest algorithm %s", algorithm)
+	}
+	h := func(parts ...string) string {
+		sum := newHash()
+		sum.Write([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+
+	qop := ""
+	for _, offered := range strings.Split(challenge["qop"], ",") {
+		switch strings.TrimSpace(offered) {
+		case "auth":
+			qop = "auth"
+		case "auth-int":
+			if qop == "" {
+				qop = "auth-int"
+			}
+		}
+	}
+
+	realm, nonce := challenge["realm"], challenge["nonce"]
+	cnonce, err := randomHex(8)
+	if err != nil {
+		return "", err
+	}
+	const nc = "00000001"
+
+	ha1 := h(auth.Username, realm, auth.Password)
+	if strings.HasSuffix(strings.ToUpper(algorithm), "-SESS") {
+		ha1 = h(ha1, nonce, cnonce)
+	}
+	ha2 := h(method, uri)
+	if qop == "auth-int" {
+		ha2 = h(method, uri, h(body))
+	}
+	response := h(ha1, nonce, ha2)
+	if qop != "" {
+		response = h(ha1, nonce, nc, cnonce, qop, ha2)
+	}
+
+	parts := []string{
+		"username=" + quoteAuthParam(auth.Username),
+		"realm=" + quoteAuthParam(realm),
+		"nonce=" + quoteAuthParam(nonce),
+		"uri=" + quoteAuthParam(uri),
+		"algorithm=" + algorithm,
+		"response=" + quoteAuthParam(response),
+	}
+	if qop != "" {
+		parts = append(parts, "qop="+qop, "nc="+nc, "cnonce="+quoteAuthParam(cnonce))
+	}
+	if opaque, ok := challenge["opaque"]; ok {
+		parts = append(parts, "opaque="+quoteAuthParam(opaque))
+	}
+	return "Digest " + strings.Join(parts, ", "), nil
+}
+
+// authParamQuoter escapes the characters a quoted-string may not hold as they are
+var authParamQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// quoteAuthParam returns s as a quoted-string of an auth parameter (RFC 7616, RFC 9110)
+func quoteAuthParam(s string) string {
+	return `"` + authParamQuoter.Replace(s) + `"`
+}
+
+// randomHex returns n random bytes as hex
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// WithStaticAuth returns req with Basic, Bearer and API key credentials written into its
+// headers or URL, the way they are sent, so exported code carries them. Schemes computed for
+// each request or fetched from a server are left out.
+func WithStaticAuth(req types.Request) types.Request {
+	auth := req.Auth
+	req.Auth = types.Auth{}
+	headers := append([]types.Header(nil), req.Headers...)
+	switch auth.Type {
+	case types.AuthBasic:
+		credentials := base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+		headers = append(headers, types.Header{Key: "Authorization", Value: "Basic " + credentials})
+	case types.AuthBearer:
+		headers = append(headers, types.Header{Key: "Authorization", Value: "Bearer " + auth.Token})
+	case types.AuthAPIKey:
+		if auth.Key == "" {
+			break
+		}
+		if auth.In == APIKeyInQuery {
+			separator := "?"
+			if strings.Contains(req.URL, "?") {
+				separator = "&"
+			}
+			req.URL += separator + url.QueryEscape(auth.Key) + "=" + url.QueryEscape(auth.Value)
+		} else {
+			headers = append(headers, types.Header{Key: auth.Key, Value: auth.Value})
+		}
+	}
+	req.Headers = headers
+	return req
+}
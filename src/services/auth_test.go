@@ -0,0 +1,71 @@
+package services
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"postty/src/types"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	req := types.Request{
+		URL: "https://x.test/",
+		Auth: types.Auth{
+			Type:         types.AuthOAuth2,
+			Username:     "bob",
+			Password:     "hunter2",
+			ClientID:     "app",
+			ClientSecret: "{{client_secret}}",
+		},
+		TLS: types.TLSSettings{CertFile: "me.p12", CertPassword: "p12-pass"},
+	}
+	stored, redacted := RedactSecrets(req)
+	if !redacted {
+		t.Error("expected the typed secrets to be reported")
+	}
+	if stored.Auth.Password != "" || stored.TLS.CertPassword != "" {
+		t.Errorf("secrets kept: %+v %+v", stored.Auth, stored.TLS)
+	}
+	if stored.Auth.Username != "bob" || stored.Auth.ClientID != "app" || stored.TLS.CertFile != "me.p12" {
+		t.Errorf("other fields changed: %+v %+v", stored.Auth, stored.TLS)
+	}
+	if stored.Auth.ClientSecret != "{{client_secret}}" {
+		t.Errorf("variable reference dropped: %q", stored.Auth.ClientSecret)
+	}
+	if req.Auth.Password != "hunter2" {
+		t.Error("the request given was changed")
+	}
+
+	if _, redacted := RedactSecrets(types.Request{Auth: types.Auth{Type: types.AuthBearer, Token: "{{token}}"}}); redacted {
+		t.Error("a variable reference was reported as a secret")
+	}
+}
+
+func TestDigestAuthorization(t *testing.T) {
+	challenge := map[string]string{"realm": "testrealm@host.com", "qop": "auth,auth-int", "nonce": "dcd98b7102dd2f0e8b11d0f600bfb0c093", "opaque": "5ccc069c403ebaf9f0171e9517f40e41"}
+	auth := types.Auth{Username: `Mu"fa\sa ü`, Password: "Circle Of Life"}
+	header, err := digestAuthorization(challenge, "GET", "/dir/index.html", "", auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(header, `username="Mu\"fa\\sa ü"`) {
+		t.Errorf("username not quoted as RFC 7616 asks: %s", header)
+	}
+
+	params := parseAuthParams(strings.TrimPrefix(header, "Digest "))
+	if params["username"] != auth.Username || params["qop"] != "auth" || params["opaque"] != challenge["opaque"] {
+		t.Fatalf("got %v", params)
+	}
+	h := func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	ha1 := h(auth.Username + ":testrealm@host.com:Circle Of Life")
+	ha2 := h("GET:/dir/index.html")
+	want := h(ha1 + ":" + challenge["nonce"] + ":00000001:" + params["cnonce"] + ":auth:" + ha2)
+	if params["response"] != want {
+		t.Errorf("response %s, want %s", params["response"], want)
+	}
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"postty/src/types"
+)
+
+// LoadCollections reads the collections tree rooted at dir. Every subdirectory is a
+// folder and every .json file is a saved request. Files that cannot be decoded are
+// skipped and reported in the returned error, while the rest of the tree is still loaded.
+func LoadCollections(dir string) (types.Collection, error) {
+	root := types.Collection{Name: filepath.Base(dir), Path: dir}
+
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return root, nil
+	}
+
+	var problems []string
+	loadCollectionFolder(&root, &problems)
+
+	if len(problems) > 0 {
+		return root, fmt.Errorf("skipped %s", strings.Join(problems, ", "))
+	}
+	return root, nil
+}
+
+// loadCollectionFolder fills folder with the contents of its directory
+func loadCollectionFolder(folder *types.Collection, problems *[]string) {
+	entries, err := os.ReadDir(folder.Path)
+	if err != nil {
+		*problems = append(*problems, folder.Path)
+		return
+	}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(folder.Path, entry.Name())
+
+		if entry.IsDir() {
+			child := types.Collection{Name: entry.Name(), Path: path}
+			loadCollectionFolder(&child, problems)
+			folder.Folders = append(folder.Folders, child)
+			continue
+		}
+
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		req, err := loadSavedRequest(path)
+		if err != nil {
+			*problems = append(*problems, path)
+			continue
+		}
+		folder.Requests = append(folder.Requests, req)
+	}
+}
+
+// loadSavedRequest reads a single saved request file
+func loadSavedRequest(path string) (types.SavedRequest, error) {
+	var req types.SavedRequest
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return req, err
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return req, err
+	}
+
+	if req.Name == "" {
+		req.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+	}
+	req.Path = path
+
+	return req, nil
+}
+
+// SaveRequest writes req into dir as an indented JSON file and returns its path.
+// A request that was loaded from dir keeps its file name, so saving updates it in place.
+// A new request replaces the one of the same name; when its file name is taken by a
+// request with another name, a numbered one is used instead. Secrets are left out, see
+// RedactSecrets.
+func SaveRequest(dir string, req types.SavedRequest) (string, error) {
+	path := req.Path
+	if path == "" || filepath.Dir(path) != filepath.Clean(dir) {
+		var err error
+		if path, err = savedRequestPath(dir, req.Name); err != nil {
+			return "", err
+		}
+	}
+	req.Request, _ = RedactSecrets(req.Request)
+
+	data, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	data = append(data, '\n')
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(path, data, 0o644); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// savedRequestPath returns the file a new request named name is saved to in dir: the
+// file named after it, unless another request already has that file
+func savedRequestPath(dir, name string) (string, error) {
+	base := collectionFileName(name)
+	for n := 1; ; n++ {
+		path := filepath.Join(dir, base+".json")
+		if n > 1 {
+			path = filepath.Join(dir, fmt.Sprintf("%s-%d.json", base, n))
+		}
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return path, nil
+		} else if err != nil {
+			return "", err
+		}
+		if existing, err := loadSavedRequest(path); err == nil && existing.Name == name {
+			return path, nil
+		}
+	}
+}
+
+// CreateCollectionFolder creates a folder named name inside parent
+func CreateCollectionFolder(parent, name string) (string, error) {
+	path := filepath.Join(parent, collectionFileName(name))
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// DeleteCollectionEntry removes a saved request file or an empty folder
+func DeleteCollectionEntry(path string) error {
+	return os.Remove(path)
+}
+
+// collectionFileName turns a display name into a portable file name
+func collectionFileName(name string) string {
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '.' {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash {
+			b.WriteRune('-')
+			lastDash = true
+		}
+	}
+
+	fileName := strings.Trim(b.String(), "-.")
+	if fileName == "" {
+		fileName = "request"
+	}
+	return fileName
+}
+
+// CollectionNodes flattens the tree into the rows shown in the collections pane,
+// skipping the contents of folders whose path is marked in collapsed
+func CollectionNodes(root *types.Collection, collapsed map[string]bool) []types.CollectionNode {
+	var nodes []types.CollectionNode
+	appendCollectionNodes(&nodes, root, 0, collapsed)
+	return nodes
+}
+
+// appendCollectionNodes adds the children of folder to nodes at the given depth
+func appendCollectionNodes(nodes *[]types.CollectionNode, folder *types.Collection, depth int, collapsed map[string]bool) {
+	for i := range folder.Folders {
+		child := &folder.Folders[i]
+		expanded := !collapsed[child.Path]
+		*nodes = append(*nodes, types.CollectionNode{Depth: depth, Folder: child, Expanded: expanded})
+		if expanded {
+			appendCollectionNodes(nodes, child, depth+1, collapsed)
+		}
+	}
+	for i := range folder.Requests {
+		*nodes = append(*nodes, types.CollectionNode{Depth: depth, Request: &folder.Requests[i]})
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"postty/src/types"
+)
+
+func TestSaveRequestKeepsNamesApart(t *testing.T) {
+	dir := t.TempDir()
+	save := func(name, url string) string {
+		path, err := SaveRequest(dir, types.SavedRequest{Name: name, Request: types.Request{Method: "GET", URL: url}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return filepath.Base(path)
+	}
+
+	if got := save("Get Users", "http://a.test/1"); got != "get-users.json" {
+		t.Errorf("first save wrote %s", got)
+	}
+	if got := save("get-users", "http://a.test/2"); got != "get-users-2.json" {
+		t.Errorf("a different name with the same file name wrote %s, want get-users-2.json", got)
+	}
+	if got := save("Get Users", "http://a.test/3"); got != "get-users.json" {
+		t.Errorf("saving the same name again wrote %s, want get-users.json", got)
+	}
+
+	root, err := LoadCollections(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	urls := map[string]string{}
+	for _, req := range root.Requests {
+		urls[req.Name] = req.URL
+	}
+	if len(urls) != 2 || urls["Get Users"] != "http://a.test/3" || urls["get-users"] != "http://a.test/2" {
+		t.Errorf("loaded %v", urls)
+	}
+}
+
+func TestSaveRequestReportsStatErrors(t *testing.T) {
+	// Looking for a free file name inside a regular file fails with ENOTDIR, not ErrNotExist
+	file := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(file, nil, 0o644)
+	if _, err := SaveRequest(file, types.SavedRequest{Name: "List", Request: types.Request{URL: "http://a.test/"}}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestLoadCollectionsFolders(t *testing.T) {
+	dir := t.TempDir()
+	folder, err := CreateCollectionFolder(dir, "Users API")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveRequest(folder, types.SavedRequest{Name: "List", Request: types.Request{Method: "GET", URL: "http://a.test/users"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := LoadCollections(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(root.Folders) != 1 || root.Folders[0].Name != "users-api" || len(root.Folders[0].Requests) != 1 {
+		t.Fatalf("got %+v", root)
+	}
+	if req := root.Folders[0].Requests[0]; req.Name != "List" || req.Path != filepath.Join(folder, "list.json") {
+		t.Errorf("got request %+v", req)
+	}
+}
+
+func TestSaveRequestLeavesOutSecrets(t *testing.T) {
+	dir := t.TempDir()
+	auth := types.Auth{Type: types.AuthBasic, Username: "bob", Password: "hunter2"}
+	path, err := SaveRequest(dir, types.SavedRequest{Name: "Login", Request: types.Request{URL: "http://a.test/", Auth: auth}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "hunter2") || !strings.Contains(string(data), "bob") {
+		t.Errorf("saved %s", data)
+	}
+}
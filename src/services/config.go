@@ -0,0 +1,72 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"postty/src/types"
+)
+
+// DefaultConfig returns the settings used when no config file is present
+func DefaultConfig() types.Config {
+	return types.Config{
+		HistoryLimit:    types.DefaultHistoryLimit,
+		RequestTimeout:  types.DefaultRequestTimeout,
+		CollectionsDir:  types.DefaultCollectionsDir,
+		EnvironmentsDir: types.DefaultEnvironmentsDir,
+		SequencesDir:    types.DefaultSequencesDir,
+	}
+}
+
+// ConfigPath returns the location of the config file
+func ConfigPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// LoadConfig reads the config file, falling back to defaults for anything it does not set
+func LoadConfig() (types.Config, error) {
+	cfg := DefaultConfig()
+
+	path, err := ConfigPath()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return DefaultConfig(), fmt.Errorf("%s: %w", path, err)
+	}
+
+	if cfg.HistoryLimit <= 0 {
+		cfg.HistoryLimit = types.DefaultHistoryLimit
+	}
+	if cfg.RequestTimeout < 0 {
+		cfg.RequestTimeout = 0
+	}
+	if cfg.CollectionsDir == "" {
+		cfg.CollectionsDir = types.DefaultCollectionsDir
+	}
+	if cfg.EnvironmentsDir == "" {
+		cfg.EnvironmentsDir = types.DefaultEnvironmentsDir
+	}
+	if cfg.SequencesDir == "" {
+		cfg.SequencesDir = types.DefaultSequencesDir
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,380 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+
+	"postty/src/types"
+)
+
+// cookieJar implements http.CookieJar over a list of cookies that can be listed and
+// edited, written to path when path is set: right after an edit, and cookieSaveDelay after
+// the first of the changes responses make
+type cookieJar struct {
+	mu      sync.Mutex
+	cookies []types.Cookie
+	path    string
+	pending *time.Timer // Writes the changes responses made, nil when there are none
+	saveErr error       // Why the last write of those changes failed
+}
+
+// cookieSaveDelay gathers the cookies of responses in quick succession into one write
+const cookieSaveDelay = time.Second
+
+// sessionCookies is the jar every request shares, unless it turns cookies off
+var sessionCookies = &cookieJar{}
+
+// CookiesPath returns the location of the persisted cookie jar
+func CookiesPath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cookies.json"), nil
+}
+
+// LoadCookies fills the jar from the file at path and keeps it there from now on
+func LoadCookies(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	var cookies []types.Cookie
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &cookies); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	sessionCookies.mu.Lock()
+	defer sessionCookies.mu.Unlock()
+	sessionCookies.path = path
+	sessionCookies.cookies = cookies
+	sessionCookies.removeExpired(time.Now())
+	return nil
+}
+
+// Cookies returns the cookies in the jar sorted by domain, path and name
+func Cookies() []types.Cookie {
+	sessionCookies.mu.Lock()
+	defer sessionCookies.mu.Unlock()
+	sessionCookies.removeExpired(time.Now())
+
+	cookies := append([]types.Cookie(nil), sessionCookies.cookies...)
+	sort.Slice(cookies, func(i, j int) bool {
+		a, b := cookies[i], cookies[j]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Name < b.Name
+	})
+	return cookies
+}
+
+// SetCookie adds the cookie written in Set-Cookie form, such as "id=42; Domain=example.com;
+// Path=/", replacing replaced when it is not nil. Without a Domain attribute the cookie is
+// sent to the host of replaced, or to defaultHost, only.
+func SetCookie(line string, replaced *types.Cookie, defaultHost string) error {
+	parsed, err := http.ParseSetCookie(line)
+	if err != nil {
+		return fmt.Errorf("cookie: %w", err)
+	}
+
+	host := defaultHost
+	if replaced != nil {
+		host = replaced.Domain
+	}
+	if parsed.Domain == "" && host == "" {
+		return errors.New("cookie: add a Domain attribute")
+	}
+	if parsed.Domain != "" {
+		host = strings.TrimPrefix(parsed.Domain, ".")
+	}
+	if parsed.Path == "" {
+		parsed.Path = "/"
+	}
+
+	now := time.Now()
+	cookie, _ := newJarCookie(parsed, host, "/", now)
+
+	sessionCookies.mu.Lock()
+	defer sessionCookies.mu.Unlock()
+	if replaced != nil {
+		sessionCookies.remove(*replaced)
+	}
+	sessionCookies.store(cookie, now)
+	return sessionCookies.saveNow()
+}
+
+// DeleteCookie removes cookie from the jar
+func DeleteCookie(cookie types.Cookie) error {
+	sessionCookies.mu.Lock()
+	defer sessionCookies.mu.Unlock()
+	sessionCookies.remove(cookie)
+	return sessionCookies.saveNow()
+}
+
+// ClearCookies removes the cookies set for domain, or every cookie when domain is empty
+func ClearCookies(domain string) error {
+	sessionCookies.mu.Lock()
+	defer sessionCookies.mu.Unlock()
+	kept := sessionCookies.cookies[:0]
+	for _, cookie := range sessionCookies.cookies {
+		if domain != "" && cookie.Domain != domain {
+			kept = append(kept, cookie)
+		}
+	}
+	sessionCookies.cookies = kept
+	return sessionCookies.saveNow()
+}
+
+// SaveCookies writes the changes to the jar not written yet, and returns why that, or an
+// earlier write of the changes responses made, failed
+func SaveCookies() error {
+	sessionCookies.mu.Lock()
+	defer sessionCookies.mu.Unlock()
+	if sessionCookies.pending == nil && sessionCookies.saveErr == nil {
+		return nil
+	}
+	return sessionCookies.saveNow()
+}
+
+// CookieLine writes cookie in the Set-Cookie form SetCookie reads
+func CookieLine(cookie types.Cookie) string {
+	parts := []string{cookie.Name + "=" + cookie.Value}
+	if !cookie.HostOnly {
+		parts = append(parts, "Domain="+cookie.Domain)
+	}
+	parts = append(parts, "Path="+cookie.Path)
+	if !cookie.Expires.IsZero() {
+		parts = append(parts, "Expires="+cookie.Expires.UTC().Format(http.TimeFormat))
+	}
+	if cookie.Secure {
+		parts = append(parts, "Secure")
+	}
+	if cookie.HTTPOnly {
+		parts = append(parts, "HttpOnly")
+	}
+	if cookie.SameSite != "" {
+		parts = append(parts, "SameSite="+cookie.SameSite)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// SetCookies implements http.CookieJar, keeping the cookies a response to u sets
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	secure := u.Scheme == "https" || u.Scheme == "wss"
+	for _, c := range cookies {
+		// Only secure origins may set secure cookies
+		if c.Secure && !secure {
+			continue
+		}
+		if cookie, ok := newJarCookie(c, u.Hostname(), defaultCookiePath(u.Path), now); ok {
+			j.store(cookie, now)
+		}
+	}
+	j.saveLater()
+}
+
+// Cookies implements http.CookieJar, returning the cookies to send to u, most specific
+// path first
+func (j *cookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.removeExpired(time.Now())
+
+	host := strings.ToLower(u.Hostname())
+	secure := u.Scheme == "https" || u.Scheme == "wss"
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	var matched []types.Cookie
+	for _, cookie := range j.cookies {
+		switch {
+		case cookie.Secure && !secure:
+		case cookie.HostOnly && host != cookie.Domain:
+		case !cookie.HostOnly && !domainMatches(host, cookie.Domain):
+		case !pathMatches(path, cookie.Path):
+		default:
+			matched = append(matched, cookie)
+		}
+	}
+	sort.SliceStable(matched, func(a, b int) bool { return len(matched[a].Path) > len(matched[b].Path) })
+
+	cookies := make([]*http.Cookie, len(matched))
+	for i, cookie := range matched {
+		cookies[i] = &http.Cookie{Name: cookie.Name, Value: cookie.Value}
+	}
+	return cookies
+}
+
+// store adds cookie, replacing the one with the same name, domain and path; an expired
+// cookie only removes it
+func (j *cookieJar) store(cookie types.Cookie, now time.Time) {
+	j.remove(cookie)
+	if cookie.Expires.IsZero() || cookie.Expires.After(now) {
+		j.cookies = append(j.cookies, cookie)
+	}
+}
+
+// remove drops the cookie with the name, domain and path of cookie
+func (j *cookieJar) remove(cookie types.Cookie) {
+	for i, c := range j.cookies {
+		if c.Name == cookie.Name && c.Domain == cookie.Domain && c.Path == cookie.Path {
+			j.cookies = append(j.cookies[:i], j.cookies[i+1:]...)
+			return
+		}
+	}
+}
+
+// removeExpired drops the cookies whose expiry has passed
+func (j *cookieJar) removeExpired(now time.Time) {
+	kept := j.cookies[:0]
+	for _, cookie := range j.cookies {
+		if cookie.Expires.IsZero() || cookie.Expires.After(now) {
+			kept = append(kept, cookie)
+		}
+	}
+	j.cookies = kept
+}
+
+// saveLater writes the jar to its file after cookieSaveDelay, unless a write is already
+// waiting
+func (j *cookieJar) saveLater() {
+	if j.path == "" || j.pending != nil {
+		return
+	}
+	j.pending = time.AfterFunc(cookieSaveDelay, func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		j.pending = nil
+		j.saveErr = j.save()
+	})
+}
+
+// saveNow writes the jar to its file in place of a waiting write
+func (j *cookieJar) saveNow() error {
+	if j.pending != nil {
+		j.pending.Stop()
+		j.pending = nil
+	}
+	j.saveErr = j.save()
+	return j.saveErr
+}
+
+// save writes the jar to its file, when it has one
+func (j *cookieJar) save() error {
+	if j.path == "" {
+		return nil
+	}
+	cookies := j.cookies
+	if cookies == nil {
+		cookies = []types.Cookie{}
+	}
+	data, err := json.MarshalIndent(cookies, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(j.path, append(data, '\n'), 0o600)
+}
+
+// newJarCookie turns a cookie set by host into the cookie kept in the jar. It reports false
+// when the cookie's Domain attribute does not cover host.
+func newJarCookie(c *http.Cookie, host, defaultPath string, now time.Time) (types.Cookie, bool) {
+	host = strings.ToLower(host)
+	cookie := types.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   host,
+		Path:     c.Path,
+		Secure:   c.Secure,
+		HTTPOnly: c.HttpOnly,
+		HostOnly: true,
+	}
+
+	if c.Domain != "" {
+		domain := strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+		// A public suffix, such as com or co.uk, would cover the hosts of every site under it
+		suffix, _ := publicsuffix.PublicSuffix(domain)
+		switch {
+		case domain == host && suffix == domain:
+			// The host itself may still keep the cookie
+		case suffix == domain || !domainMatches(host, domain):
+			return types.Cookie{}, false
+		default:
+			cookie.Domain = domain
+			cookie.HostOnly = false
+		}
+	}
+	if cookie.Path == "" || !strings.HasPrefix(cookie.Path, "/") {
+		cookie.Path = defaultPath
+	}
+
+	switch {
+	case c.MaxAge < 0:
+		cookie.Expires = now.Add(-time.Second)
+	case c.MaxAge > 0:
+		cookie.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+	case !c.Expires.IsZero():
+		cookie.Expires = c.Expires
+	}
+
+	switch c.SameSite {
+	case http.SameSiteLaxMode:
+		cookie.SameSite = "Lax"
+	case http.SameSiteStrictMode:
+		cookie.SameSite = "Strict"
+	case http.SameSiteNoneMode:
+		cookie.SameSite = "None"
+	}
+	return cookie, true
+}
+
+// defaultCookiePath returns the path a cookie without a Path attribute is set for: the
+// request path up to its last slash
+func defaultCookiePath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// domainMatches reports whether host is domain or one of its subdomains
+func domainMatches(host, domain string) bool {
+	if host == domain {
+		return true
+	}
+	return net.ParseIP(host) == nil && strings.HasSuffix(host, "."+domain)
+}
+
+// pathMatches reports whether a cookie set for cookiePath is sent to path
+func pathMatches(path, cookiePath string) bool {
+	if path == cookiePath {
+		return true
+	}
+	return strings.HasPrefix(path, cookiePath) &&
+		(strings.HasSuffix(cookiePath, "/") || path[len(cookiePath)] == '/')
+}
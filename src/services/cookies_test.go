@@ -0,0 +1,113 @@
+package services
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// cookieNames returns the names of the cookies j sends to rawURL
+func cookieNames(j *cookieJar, rawURL string) []string {
+	u, _ := url.Parse(rawURL)
+	var names []string
+	for _, cookie := range j.Cookies(u) {
+		names = append(names, cookie.Name)
+	}
+	return names
+}
+
+func TestCookieJarDomainsAndPaths(t *testing.T) {
+	j := &cookieJar{}
+	u, _ := url.Parse("http://api.example.com/login")
+	j.SetCookies(u, []*http.Cookie{
+		{Name: "sid", Value: "1"},
+		{Name: "wide", Value: "1", Domain: "example.com"},
+		{Name: "deep", Value: "1", Path: "/api/v1"},
+		{Name: "tld", Value: "1", Domain: "com"},
+		{Name: "other", Value: "1", Domain: "other.org"},
+	})
+
+	tests := map[string][]string{
+		"http://api.example.com/api/v1/x": {"deep", "sid", "wide"},
+		"http://api.example.com/api/v10":  {"sid", "wide"},
+		"http://www.example.com/":         {"wide"},
+		"http://other.org/":               nil,
+	}
+	for rawURL, want := range tests {
+		if got := cookieNames(j, rawURL); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: sent %v, want %v", rawURL, got, want)
+		}
+	}
+
+	j.SetCookies(u, []*http.Cookie{{Name: "sid", MaxAge: -1}})
+	if got := cookieNames(j, "http://api.example.com/"); !reflect.DeepEqual(got, []string{"wide"}) {
+		t.Errorf("after Max-Age=-1 sent %v", got)
+	}
+}
+
+func TestCookieJarPublicSuffix(t *testing.T) {
+	j := &cookieJar{}
+	u, _ := url.Parse("https://shop.example.co.uk/")
+	j.SetCookies(u, []*http.Cookie{
+		{Name: "suffix", Value: "1", Domain: "co.uk"},
+		{Name: "site", Value: "1", Domain: "example.co.uk"},
+	})
+	if got := cookieNames(j, "https://other.co.uk/"); got != nil {
+		t.Errorf("another site under co.uk got %v", got)
+	}
+	if got := cookieNames(j, "https://www.example.co.uk/"); !reflect.DeepEqual(got, []string{"site"}) {
+		t.Errorf("sent %v, want [site]", got)
+	}
+}
+
+func TestCookieJarSecure(t *testing.T) {
+	j := &cookieJar{}
+	plain, _ := url.Parse("http://example.com/")
+	j.SetCookies(plain, []*http.Cookie{{Name: "fromhttp", Value: "1", Secure: true}})
+	secure, _ := url.Parse("https://example.com/")
+	j.SetCookies(secure, []*http.Cookie{{Name: "fromhttps", Value: "1", Secure: true}})
+
+	if got := cookieNames(j, "https://example.com/"); !reflect.DeepEqual(got, []string{"fromhttps"}) {
+		t.Errorf("sent %v over https, want [fromhttps]", got)
+	}
+	if got := cookieNames(j, "http://example.com/"); got != nil {
+		t.Errorf("sent %v over http", got)
+	}
+}
+
+func TestCookieJarSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	j := &cookieJar{path: path}
+	u, _ := url.Parse("http://example.com/")
+	j.SetCookies(u, []*http.Cookie{{Name: "a", Value: "1"}})
+	j.SetCookies(u, []*http.Cookie{{Name: "b", Value: "2"}})
+
+	// Responses only schedule a write
+	if _, err := os.Stat(path); err == nil {
+		t.Error("the jar was written on every response")
+	}
+	j.mu.Lock()
+	err := j.saveNow()
+	j.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if len(data) == 0 || j.pending != nil {
+		t.Errorf("wrote %q, pending %v", data, j.pending)
+	}
+
+	// A failed write is reported
+	blocker := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(blocker, nil, 0o600)
+	j.path = filepath.Join(blocker, "cookies.json")
+	j.mu.Lock()
+	err = j.saveNow()
+	j.mu.Unlock()
+	if err == nil || j.saveErr == nil {
+		t.Error("expected the write under a file to fail")
+	}
+}
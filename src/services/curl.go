@@ -0,0 +1,550 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"postty/src/types"
+)
+
+// curlFlagsWithValue lists the curl options that take an argument, by short and long name
+var curlFlagsWithValue = map[string]bool{
+	"-X": true, "--request": true,
+	"-H": true, "--header": true,
+	"-d": true, "--data": true, "--data-raw": true, "--data-binary": true, "--data-ascii": true, "--data-urlencode": true,
+	"-F": true, "--form": true, "--form-string": true,
+	"-u": true, "--user": true, "--oauth2-bearer": true, "--aws-sigv4": true,
+	"-A": true, "--user-agent": true,
+	"-e": true, "--referer": true,
+	"-b": true, "--cookie": true,
+	"-E": true, "--cert": true, "--key": true, "--pass": true, "--cacert": true,
+	"--cert-type": true, "--key-type": true, "--max-redirs": true,
+	"--url": true,
+	// Accepted but not applied; listed so their argument is not mistaken for the URL
+	"-o": true, "--output": true, "-x": true, "--proxy": true, "-m": true, "--max-time": true,
+	"--connect-timeout": true, "-c": true, "--cookie-jar": true,
+	"-w": true, "--write-out": true, "-T": true, "--upload-file": true,
+	"--resolve": true, "-r": true, "--range": true,
+	"-U": true, "--proxy-user": true, "--proxy-header": true, "--noproxy": true, "--preproxy": true,
+	"--socks5": true, "--socks5-hostname": true, "--connect-to": true, "--interface": true,
+	"--dns-servers": true, "--unix-socket": true, "--abstract-unix-socket": true, "--local-port": true,
+	"--retry": true, "--retry-delay": true, "--retry-max-time": true, "--limit-rate": true,
+	"-Y": true, "--speed-limit": true, "-y": true, "--speed-time": true, "--max-filesize": true,
+	"--keepalive-time": true, "--expect100-timeout": true, "--happy-eyeballs-timeout-ms": true,
+	"--capath": true, "--ciphers": true, "--tls13-ciphers": true, "--curves": true, "--crlfile": true,
+	"--pinnedpubkey": true, "--tls-max": true, "--request-target": true, "--json": true,
+	"-K": true, "--config": true, "-D": true, "--dump-header": true, "--output-dir": true,
+	"--trace": true, "--trace-ascii": true, "--stderr": true, "--netrc-file": true,
+	"-z": true, "--time-cond": true, "-C": true, "--continue-at": true, "--variable": true,
+	"--proto": true, "--proto-redir": true, "--proto-default": true, "-Q": true, "--quote": true,
+	"-P": true, "--ftp-port": true, "-t": true, "--telnet-option": true,
+}
+
+// curlIgnoredFlags are options that only change curl's own output and have no bearing on the request
+var curlIgnoredFlags = map[string]bool{
+	"-s": true, "--silent": true, "-S": true, "--show-error": true, "-v": true, "--verbose": true,
+	"-i": true, "--include": true, "-f": true, "--fail": true,
+	"--compressed": true, "-#": true, "--progress-bar": true, "-N": true, "--no-buffer": true,
+}
+
+// CurlImport is the result of parsing a curl command line
+type CurlImport struct {
+	Request     types.Request
+	Unsupported []string // Options that were recognised or seen but could not be applied
+}
+
+// ParseCurl parses a curl command line into a request. Options that cannot be
+// represented in the form are listed in Unsupported rather than failing the import.
+func ParseCurl(command string) (CurlImport, error) {
+	var result CurlImport
+
+	args, err := SplitShellWords(command)
+	if err != nil {
+		return result, err
+	}
+	if len(args) > 0 && (args[0] == "curl" || strings.HasSuffix(args[0], "/curl")) {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return result, fmt.Errorf("no curl arguments found")
+	}
+
+	method := ""
+	rawURL := ""
+	contentType := ""
+	var headers []types.Header
+	var data []string
+	var formFields []types.FormField
+	useGet := false
+	var auth types.Auth
+	digest := false
+	var sigV4 []string // provider1[:provider2[:region[:service]]]
+	var tlsSettings types.TLSSettings
+	var redirects types.RedirectPolicy
+	followRedirects := false
+
+	unsupported := func(flag string) {
+		for _, seen := range result.Unsupported {
+			if seen == flag {
+				return
+			}
+		}
+		result.Unsupported = append(result.Unsupported, flag)
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// Positional argument: the URL
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if rawURL == "" {
+				rawURL = arg
+			} else {
+				unsupported(arg)
+			}
+			continue
+		}
+
+		flag, value, hasValue := arg, "", false
+
+		// Short options may carry their value (-XPOST) or be bundled (-sSL, -sXPOST): a
+		// bundle is split into its options, the first one taking a value ending it
+		if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			short := arg[:2]
+			if curlFlagsWithValue[short] {
+				flag, value, hasValue = short, arg[2:], true
+			} else {
+				var split []string
+				for j, c := range arg[1:] {
+					split = append(split, "-"+string(c))
+					if curlFlagsWithValue["-"+string(c)] {
+						split[len(split)-1] += arg[2+j:]
+						break
+					}
+				}
+				args = append(args[:i], append(split, args[i+1:]...)...)
+				i--
+				continue
+			}
+		}
+
+		if curlFlagsWithValue[flag] && !hasValue {
+			if i+1 >= len(args) {
+				return result, fmt.Errorf("option %s needs a value", flag)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch flag {
+		case "-X", "--request":
+			method = strings.ToUpper(value)
+
+		case "--url":
+			rawURL = value
+
+		case "-H", "--header":
+			key, val, ok := strings.Cut(value, ":")
+			if !ok {
+				// "Name;" sends an empty header; "Name" alone removes one, which has no equivalent here
+				key = strings.TrimSuffix(value, ";")
+			}
+			key = strings.TrimSpace(key)
+			val = strings.TrimSpace(val)
+			if strings.EqualFold(key, "Content-Type") {
+				contentType = val
+				continue
+			}
+			headers = append(headers, types.Header{Key: key, Value: val})
+
+		case "-d", "--data", "--data-ascii", "--data-binary":
+			if strings.HasPrefix(value, "@") {
+				content, err := os.ReadFile(value[1:])
+				if err != nil {
+					return result, fmt.Errorf("%s %s: %w", flag, value, err)
+				}
+				value = string(content)
+				if flag != "--data-binary" {
+					// curl strips newlines from files read with -d
+					value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+				}
+			}
+			data = append(data, value)
+
+		case "--data-raw":
+			data = append(data, value)
+
+		case "--data-urlencode":
+			encoded, err := curlURLEncode(value)
+			if err != nil {
+				return result, err
+			}
+			data = append(data, encoded)
+
+		case "-F", "--form":
+			field, err := ParseFormField(value)
+			if err != nil {
+				return result, err
+			}
+			if strings.HasPrefix(field.Value, "<") {
+				// name=<file sends the file's content as a text field, which the form cannot express
+				unsupported(flag + " " + field.Key + "=<file")
+			}
+			formFields = append(formFields, field)
+
+		case "--form-string":
+			name, text, _ := strings.Cut(value, "=")
+			formFields = append(formFields, types.FormField{Key: name, Value: text})
+
+		case "-u", "--user":
+			username, password, _ := strings.Cut(value, ":")
+			auth = types.Auth{Type: types.AuthBasic, Username: username, Password: password}
+
+		case "--digest":
+			digest = true
+
+		case "--basic":
+			digest = false
+
+		case "--oauth2-bearer":
+			auth = types.Auth{Type: types.AuthBearer, Token: value}
+
+		case "--aws-sigv4":
+			sigV4 = strings.Split(value, ":")
+
+		case "-A", "--user-agent":
+			headers = append(headers, types.Header{Key: "User-Agent", Value: value})
+
+		case "-e", "--referer":
+			headers = append(headers, types.Header{Key: "Referer", Value: value})
+
+		case "-b", "--cookie":
+			if !strings.Contains(value, "=") {
+				// A value without '=' names a cookie file
+				unsupported(flag + " <file>")
+				continue
+			}
+			headers = append(headers, types.Header{Key: "Cookie", Value: value})
+
+		case "-G", "--get":
+			useGet = true
+
+		case "-I", "--head":
+			method = "HEAD"
+
+		case "-k", "--insecure":
+			tlsSettings.Insecure = true
+
+		case "-E", "--cert":
+			// certificate[:password]
+			tlsSettings.CertFile, tlsSettings.CertPassword, _ = strings.Cut(value, ":")
+
+		case "--key":
+			tlsSettings.KeyFile = value
+
+		case "--pass":
+			tlsSettings.CertPassword = value
+
+		case "--cacert":
+			tlsSettings.CAFiles = append(tlsSettings.CAFiles, value)
+
+		case "--cert-type", "--key-type":
+			// PEM or PKCS#12 is told from the file itself
+
+		case "--tlsv1", "--tlsv1.0", "--tlsv1.1", "--tlsv1.2", "--tlsv1.3":
+			tlsSettings.MinVersion = strings.TrimPrefix(flag, "--tlsv")
+			if tlsSettings.MinVersion == "1" {
+				tlsSettings.MinVersion = "1.0"
+			}
+
+		case "-L", "--location":
+			followRedirects = true
+
+		case "--max-redirs":
+			// -1 is curl's unlimited, which keeps the default limit here
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return result, fmt.Errorf("option --max-redirs needs a number, got %q", value)
+			}
+			redirects.NoFollow = n == 0
+			redirects.Max = max(n, 0)
+
+		case "--post301", "--post302":
+			redirects.KeepMethod = true
+
+		default:
+			if !curlIgnoredFlags[flag] {
+				unsupported(flag)
+			}
+		}
+	}
+
+	if rawURL == "" {
+		return result, fmt.Errorf("no URL found in curl command")
+	}
+
+	body := strings.Join(data, "&")
+	if useGet && body != "" {
+		separator := "?"
+		if strings.Contains(rawURL, "?") {
+			separator = "&"
+		}
+		rawURL += separator + body
+		body = ""
+	}
+
+	if len(formFields) > 0 {
+		if contentType == "" {
+			contentType = FormMultipart
+		}
+		body = ""
+	}
+
+	if method == "" {
+		method = "GET"
+		if body != "" || len(formFields) > 0 {
+			method = "POST"
+		}
+	}
+	if body != "" && contentType == "" {
+		// curl's default for -d
+		contentType = "application/x-www-form-urlencoded"
+	}
+
+	// Content types the form cannot select are kept as a custom header
+	known := false
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	for _, ct := range types.ContentTypes {
+		if strings.EqualFold(strings.TrimSpace(mediaType), ct) {
+			contentType = ct
+			known = true
+			break
+		}
+	}
+	if contentType != "" && !known {
+		headers = append(headers, types.Header{Key: "Content-Type", Value: contentType})
+		contentType = ""
+	}
+
+	supportedMethod := false
+	for _, m := range types.HTTPMethods {
+		if m == method {
+			supportedMethod = true
+			break
+		}
+	}
+	if !supportedMethod {
+		unsupported("-X " + method)
+		method = "GET"
+	}
+
+	if digest && auth.Type == types.AuthBasic {
+		auth.Type = types.AuthDigest
+	}
+	if sigV4 != nil && auth.Type == types.AuthBasic {
+		auth = types.Auth{Type: types.AuthSigV4, AccessKey: auth.Username, SecretKey: auth.Password}
+		if len(sigV4) > 2 {
+			auth.Region = sigV4[2]
+		}
+		if len(sigV4) > 3 {
+			auth.Service = sigV4[3]
+		}
+	}
+
+	// Like curl, the request only follows redirects with -L
+	if !followRedirects {
+		redirects.NoFollow = true
+	}
+
+	result.Request = types.Request{
+		Method:      method,
+		URL:         rawURL,
+		Body:        body,
+		ContentType: contentType,
+		Headers:     headers,
+		Form:        formFields,
+		Auth:        auth,
+		TLS:         tlsSettings,
+		Redirects:   redirects,
+	}
+	return result, nil
+}
+
+// curlURLEncode applies curl's --data-urlencode rules to a single value
+func curlURLEncode(value string) (string, error) {
+	readFile := func(path string) (string, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("--data-urlencode %s: %w", value, err)
+		}
+		return string(content), nil
+	}
+
+	if name, content, ok := strings.Cut(value, "="); ok {
+		if name == "" {
+			return url.QueryEscape(content), nil
+		}
+		return name + "=" + url.QueryEscape(content), nil
+	}
+	if name, path, ok := strings.Cut(value, "@"); ok {
+		content, err := readFile(path)
+		if err != nil {
+			return "", err
+		}
+		if name == "" {
+			return url.QueryEscape(content), nil
+		}
+		return name + "=" + url.QueryEscape(content), nil
+	}
+	return url.QueryEscape(value), nil
+}
+
+// SplitShellWords splits a command line the way a POSIX shell would, handling single
+// and double quotes, ANSI-C $'...' quoting, backslash escapes and line continuations
+func SplitShellWords(s string) ([]string, error) {
+	var words []string
+	var current strings.Builder
+	inWord := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == '\\':
+			if i+1 < len(s) && (s[i+1] == '\n' || s[i+1] == '\r') {
+				// Line continuation
+				i++
+				if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+					i++
+				}
+				continue
+			}
+			if i+1 < len(s) {
+				i++
+				current.WriteByte(s[i])
+				inWord = true
+			}
+
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			current.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+
+		case c == '$' && i+1 < len(s) && s[i+1] == '\'':
+			value, consumed, err := ansiCQuoted(s[i+2:])
+			if err != nil {
+				return nil, err
+			}
+			current.WriteString(value)
+			i += consumed + 1
+			inWord = true
+
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				current.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+
+		default:
+			current.WriteByte(c)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words, nil
+}
+
+// ansiCQuoted decodes the body of a $'...' string, returning the value and the number
+// of bytes consumed including the closing quote
+func ansiCQuoted(s string) (string, int, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\'' {
+			return b.String(), i + 1, nil
+		}
+		if c != '\\' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '\\', '\'', '"', '?':
+			b.WriteByte(s[i])
+		case 'x':
+			n, length := parseHexDigits(s[i+1:], 2)
+			if length == 0 {
+				b.WriteString("\\x")
+				continue
+			}
+			b.WriteByte(byte(n))
+			i += length
+		case 'u', 'U':
+			digits := 4
+			if s[i] == 'U' {
+				digits = 8
+			}
+			n, length := parseHexDigits(s[i+1:], digits)
+			if length == 0 {
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+				continue
+			}
+			var buf [utf8.UTFMax]byte
+			b.Write(buf[:utf8.EncodeRune(buf[:], rune(n))])
+			i += length
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated $' quote")
+}
+
+// parseHexDigits parses up to max hex digits at the start of s
+func parseHexDigits(s string, max int) (uint64, int) {
+	length := 0
+	for length < max && length < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[length]) >= 0 {
+		length++
+	}
+	if length == 0 {
+		return 0, 0
+	}
+	n, _ := strconv.ParseUint(s[:length], 16, 32)
+	return n, length
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"postty/src/types"
+)
+
+func TestParseCurl(t *testing.T) {
+	imported, err := ParseCurl(`curl 'https://api.example.com/v1/items?q=1' -H 'accept: application/json' ` +
+		`-H 'content-type: application/json' --data-raw $'{"name":"it\'s"}' --compressed -k`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := imported.Request
+	if req.Method != "POST" || req.URL != "https://api.example.com/v1/items?q=1" || req.Body != `{"name":"it's"}` {
+		t.Errorf("got %s %s %q", req.Method, req.URL, req.Body)
+	}
+	if req.ContentType != "application/json" || !req.TLS.Insecure {
+		t.Errorf("got content type %q, insecure %v", req.ContentType, req.TLS.Insecure)
+	}
+	if len(imported.Unsupported) != 0 {
+		t.Errorf("unsupported %v", imported.Unsupported)
+	}
+}
+
+func TestParseCurlBundledFlags(t *testing.T) {
+	tests := []struct {
+		command     string
+		method      string
+		url         string
+		unsupported []string
+	}{
+		{`curl -sXPOST https://x.test/a`, "POST", "https://x.test/a", nil},
+		{`curl -sX PUT https://x.test/a`, "PUT", "https://x.test/a", nil},
+		{`curl -sSLkI https://x.test/a`, "HEAD", "https://x.test/a", nil},
+		{`curl -XPOST https://x.test/a`, "POST", "https://x.test/a", nil},
+		{`curl --retry 3 https://x.test/a`, "GET", "https://x.test/a", []string{"--retry"}},
+		{`curl -sm 5 https://x.test/a`, "GET", "https://x.test/a", []string{"-m"}},
+	}
+	for _, tt := range tests {
+		imported, err := ParseCurl(tt.command)
+		if err != nil {
+			t.Errorf("%s: %v", tt.command, err)
+			continue
+		}
+		if imported.Request.Method != tt.method || imported.Request.URL != tt.url {
+			t.Errorf("%s: got %s %s, want %s %s", tt.command, imported.Request.Method, imported.Request.URL, tt.method, tt.url)
+		}
+		if !reflect.DeepEqual(imported.Unsupported, tt.unsupported) {
+			t.Errorf("%s: unsupported %v, want %v", tt.command, imported.Unsupported, tt.unsupported)
+		}
+	}
+}
+
+func TestParseCurlRedirects(t *testing.T) {
+	tests := []struct {
+		command string
+		want    types.RedirectPolicy
+	}{
+		{`curl https://x.test/`, types.RedirectPolicy{NoFollow: true}},
+		{`curl -L https://x.test/`, types.RedirectPolicy{}},
+		{`curl -sSL https://x.test/`, types.RedirectPolicy{}},
+		{`curl --location --max-redirs 3 --post301 https://x.test/`, types.RedirectPolicy{Max: 3, KeepMethod: true}},
+		{`curl -L --max-redirs 0 https://x.test/`, types.RedirectPolicy{NoFollow: true}},
+	}
+	for _, tt := range tests {
+		imported, err := ParseCurl(tt.command)
+		if err != nil {
+			t.Errorf("%s: %v", tt.command, err)
+			continue
+		}
+		if imported.Request.Redirects != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.command, imported.Request.Redirects, tt.want)
+		}
+	}
+}
+
+func TestParseCurlForm(t *testing.T) {
+	imported, err := ParseCurl(`curl https://x.test/up -F name=bob -F 'file=@a.txt;type=text/plain'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := imported.Request
+	want := []types.FormField{
+		{Key: "name", Value: "bob"},
+		{Key: "file", Value: "a.txt", File: true, ContentType: "text/plain"},
+	}
+	if req.Method != "POST" || req.ContentType != FormMultipart || !reflect.DeepEqual(req.Form, want) {
+		t.Errorf("got %s %s %+v", req.Method, req.ContentType, req.Form)
+	}
+}
+
+func TestParseCurlNeedsURL(t *testing.T) {
+	if _, err := ParseCurl(`curl -H 'X-A: 1'`); err == nil {
+		t.Error("expected an error without a URL")
+	}
+}
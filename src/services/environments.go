@@ -0,0 +1,143 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"postty/src/types"
+)
+
+// variablePattern matches {{name}} references, allowing spaces inside the braces
+var variablePattern = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// LoadEnvironments reads every .json file in dir as an environment. Each file holds a
+// flat object of variable names to values and the environment is named after the file.
+// Files that cannot be decoded are skipped and reported in the returned error.
+func LoadEnvironments(dir string) ([]types.Environment, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []types.Environment{}, nil
+	}
+	if err != nil {
+		return []types.Environment{}, err
+	}
+
+	environments := []types.Environment{}
+	var problems []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			problems = append(problems, path)
+			continue
+		}
+
+		variables := map[string]string{}
+		if err := json.Unmarshal(data, &variables); err != nil {
+			problems = append(problems, path)
+			continue
+		}
+
+		environments = append(environments, types.Environment{
+			Name:      strings.TrimSuffix(entry.Name(), ".json"),
+			Path:      path,
+			Variables: variables,
+		})
+	}
+
+	if len(problems) > 0 {
+		return environments, fmt.Errorf("skipped %s", strings.Join(problems, ", "))
+	}
+	return environments, nil
+}
+
+// Interpolate replaces {{name}} references in text with values from variables and
+// returns the names that could not be resolved, leaving those references untouched
+func Interpolate(text string, variables map[string]string) (string, []string) {
+	var unresolved []string
+	result := variablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := variablePattern.FindStringSubmatch(match)[1]
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		unresolved = append(unresolved, name)
+		return match
+	})
+	return result, unresolved
+}
+
+// FindVariables returns the byte ranges of every {{name}} reference in text along with its name
+func FindVariables(text string) (ranges [][2]int, names []string) {
+	for _, loc := range variablePattern.FindAllStringSubmatchIndex(text, -1) {
+		ranges = append(ranges, [2]int{loc[0], loc[1]})
+		names = append(names, text[loc[2]:loc[3]])
+	}
+	return ranges, names
+}
+
+// InterpolateRequest applies Interpolate to the URL, body and headers of req and
+// returns the resolved request along with every unresolved name, without duplicates
+func InterpolateRequest(req types.Request, variables map[string]string) (types.Request, []string) {
+	var unresolved []string
+	seen := map[string]bool{}
+	req = mapRequestText(req, func(text string) string {
+		text, names := Interpolate(text, variables)
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				unresolved = append(unresolved, name)
+			}
+		}
+		return text
+	})
+	return req, unresolved
+}
+
+// mapRequestText returns req with replace applied to every field that may hold {{name}}
+// references: the URL, body, GraphQL variables and operation, headers, form fields and auth
+func mapRequestText(req types.Request, replace func(string) string) types.Request {
+	req.URL = replace(req.URL)
+	req.Body = replace(req.Body)
+	req.GraphQLVariables = replace(req.GraphQLVariables)
+	req.GraphQLOperation = replace(req.GraphQLOperation)
+
+	headers := make([]types.Header, len(req.Headers))
+	for i, header := range req.Headers {
+		headers[i].Key = replace(header.Key)
+		headers[i].Value = replace(header.Value)
+	}
+	req.Headers = headers
+
+	if req.Form != nil {
+		form := make([]types.FormField, len(req.Form))
+		for i, field := range req.Form {
+			form[i] = field
+			form[i].Key = replace(field.Key)
+			form[i].Value = replace(field.Value)
+		}
+		req.Form = form
+	}
+
+	req.Auth = mapAuthText(req.Auth, replace)
+	req.TLS = mapTLSText(req.TLS, replace)
+	return req
+}
+
+// SaveEnvironment writes the variables of env back to its file
+func SaveEnvironment(env types.Environment) error {
+	data, err := json.MarshalIndent(env.Variables, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(env.Path, append(data, '\n'), 0o644)
+}
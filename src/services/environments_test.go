@@ -0,0 +1,79 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"postty/src/types"
+)
+
+func TestLoadEnvironments(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "dev.json"), []byte(`{"host":"localhost:8080"}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"host":`), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(`{}`), 0o644)
+
+	envs, err := LoadEnvironments(dir)
+	if err == nil || !strings.Contains(err.Error(), "broken.json") {
+		t.Errorf("got error %v, want broken.json reported", err)
+	}
+	if len(envs) != 1 || envs[0].Name != "dev" || envs[0].Variables["host"] != "localhost:8080" {
+		t.Fatalf("got %+v", envs)
+	}
+
+	envs[0].Variables["token"] = "abc"
+	if err := SaveEnvironment(envs[0]); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(filepath.Join(dir, "broken.json"))
+	reloaded, err := LoadEnvironments(dir)
+	if err != nil || len(reloaded) != 1 || !reflect.DeepEqual(reloaded[0].Variables, envs[0].Variables) {
+		t.Errorf("got %+v, %v after saving", reloaded, err)
+	}
+
+	envs, err = LoadEnvironments(filepath.Join(dir, "missing"))
+	if err != nil || len(envs) != 0 {
+		t.Errorf("got %+v, %v for a missing directory", envs, err)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	variables := map[string]string{"host": "a.test", "id": "7"}
+	got, unresolved := Interpolate("https://{{host}}/items/{{ id }}?k={{key}}", variables)
+	if got != "https://a.test/items/7?k={{key}}" {
+		t.Errorf("got %q", got)
+	}
+	if !reflect.DeepEqual(unresolved, []string{"key"}) {
+		t.Errorf("got unresolved %v", unresolved)
+	}
+
+	ranges, names := FindVariables("a {{x}} b {{ y }}")
+	if !reflect.DeepEqual(ranges, [][2]int{{2, 7}, {10, 17}}) || !reflect.DeepEqual(names, []string{"x", "y"}) {
+		t.Errorf("got %v %v", ranges, names)
+	}
+}
+
+func TestInterpolateRequest(t *testing.T) {
+	req := types.Request{
+		URL:     "https://{{host}}/{{path}}",
+		Body:    `{"user":"{{user}}"}`,
+		Headers: []types.Header{{Key: "X-{{name}}", Value: "{{user}}"}},
+		Form:    []types.FormField{{Key: "f", Value: "{{user}}"}},
+	}
+	resolved, unresolved := InterpolateRequest(req, map[string]string{"host": "a.test", "user": "jo", "name": "User"})
+	if resolved.URL != "https://a.test/{{path}}" || resolved.Body != `{"user":"jo"}` {
+		t.Errorf("got %s %s", resolved.URL, resolved.Body)
+	}
+	if resolved.Headers[0] != (types.Header{Key: "X-User", Value: "jo"}) || resolved.Form[0].Value != "jo" {
+		t.Errorf("got headers %v and form %v", resolved.Headers, resolved.Form)
+	}
+	if req.Headers[0].Value != "{{user}}" || req.Form[0].Value != "{{user}}" {
+		t.Error("InterpolateRequest changed the request it was given")
+	}
+	if !reflect.DeepEqual(unresolved, []string{"path"}) {
+		t.Errorf("got unresolved %v", unresolved)
+	}
+}
@@ -0,0 +1,380 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/atotto/clipboard"
+
+	"postty/src/types"
+)
+
+// SnippetFormat describes one of the languages a request can be exported to
+type SnippetFormat struct {
+	Name      string
+	Extension string
+	Generate  func(req types.Request) string
+}
+
+// SnippetFormats lists the available export formats in the order they are offered
+var SnippetFormats = []SnippetFormat{
+	{Name: "cURL", Extension: "sh", Generate: CurlSnippet},
+	{Name: "Go", Extension: "go", Generate: GoSnippet},
+	{Name: "Python", Extension: "py", Generate: PythonSnippet},
+	{Name: "fetch", Extension: "js", Generate: FetchSnippet},
+	{Name: "HTTPie", Extension: "sh", Generate: HTTPieSnippet},
+}
+
+// FileName returns the file a snippet in this format is written to, such as
+// postty-export-curl.sh, so that formats sharing an extension do not collide
+func (f SnippetFormat) FileName() string {
+	return fmt.Sprintf("postty-export-%s.%s", strings.ToLower(f.Name), f.Extension)
+}
+
+// exportHeaders returns the headers the request is sent with, in order
+func exportHeaders(req types.Request) []types.Header {
+	var headers []types.Header
+	if exportBody(req) != "" && req.ContentType != "" {
+		headers = append(headers, types.Header{Key: "Content-Type", Value: sentContentType(req.ContentType)})
+	}
+	for _, h := range req.Headers {
+		if h.Key == "" || h.Value == "" {
+			continue
+		}
+		if strings.EqualFold(h.Key, "Content-Type") && len(headers) > 0 && headers[0].Key == "Content-Type" {
+			// A custom Content-Type replaces the selected one, as it does when sending
+			headers[0].Value = h.Value
+			continue
+		}
+		headers = append(headers, h)
+	}
+	return headers
+}
+
+// exportBody returns the body the request is sent with; only POST, PUT and PATCH carry one.
+// Urlencoded forms are encoded here, multipart forms are rendered by each format from exportForm.
+func exportBody(req types.Request) string {
+	if !methodHasBody(req.Method) || len(exportForm(req)) > 0 {
+		return ""
+	}
+	body, _, err := RequestBody(req)
+	if err != nil {
+		return req.Body
+	}
+	return body
+}
+
+// exportForm returns the multipart fields the request is sent with, if it is a multipart form
+func exportForm(req types.Request) []types.FormField {
+	if !methodHasBody(req.Method) || req.ContentType != FormMultipart {
+		return nil
+	}
+	var fields []types.FormField
+	for _, field := range req.Form {
+		if !field.Disabled && field.Key != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// ShellQuote quotes s for a POSIX shell
+func ShellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// jsonQuote renders s as a double-quoted string literal, valid in JSON, JavaScript and Python
+func jsonQuote(s string) string {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// CurlSnippet renders the request as a curl command
+func CurlSnippet(req types.Request) string {
+	command := "curl"
+	body, form := exportBody(req), exportForm(req)
+	switch {
+	case req.Method == "GET":
+	case req.Method == "HEAD":
+		// -X HEAD makes curl wait for a body that never comes
+		command += " -I"
+	case req.Method == "POST" && !req.Redirects.NoFollow && (body != "" || len(form) > 0):
+		// The data makes it a POST; with -X POST curl would also POST after a 303
+	default:
+		command += " -X " + req.Method
+	}
+	if !req.Redirects.NoFollow {
+		command += " -L"
+		if req.Redirects.Max > 0 {
+			command += " --max-redirs " + strconv.Itoa(req.Redirects.Max)
+		}
+		if req.Redirects.KeepMethod {
+			command += " --post301 --post302"
+		}
+	}
+
+	parts := []string{command + " " + ShellQuote(req.URL)}
+	for _, h := range exportHeaders(req) {
+		parts = append(parts, "-H "+ShellQuote(h.Key+": "+h.Value))
+	}
+	if body != "" {
+		parts = append(parts, "--data-raw "+ShellQuote(body))
+	}
+	for _, field := range form {
+		if !field.File && field.ContentType == "" && (strings.HasPrefix(field.Value, "@") || strings.HasPrefix(field.Value, "<")) {
+			// -F would read a file for these; --form-string sends the text as is
+			parts = append(parts, "--form-string "+ShellQuote(field.Key+"="+field.Value))
+			continue
+		}
+		parts = append(parts, "-F "+ShellQuote(FormFieldSpec(field)))
+	}
+	return strings.Join(parts, " \\\n  ")
+}
+
+// HTTPieSnippet renders the request as an HTTPie command
+func HTTPieSnippet(req types.Request) string {
+	form := exportForm(req)
+	command := "http "
+	if len(form) > 0 {
+		command += "--multipart "
+	}
+
+	parts := []string{command + req.Method + " " + ShellQuote(req.URL)}
+	for _, h := range exportHeaders(req) {
+		parts = append(parts, ShellQuote(h.Key+":"+h.Value))
+	}
+	if body := exportBody(req); body != "" {
+		parts = append(parts, "--raw "+ShellQuote(body))
+	}
+	for _, field := range form {
+		if field.File {
+			item := field.Key + "@" + field.Value
+			if field.ContentType != "" {
+				item += ";type=" + field.ContentType
+			}
+			parts = append(parts, ShellQuote(item))
+		} else {
+			parts = append(parts, ShellQuote(field.Key+"="+field.Value))
+		}
+	}
+	return strings.Join(parts, " \\\n  ")
+}
+
+// GoSnippet renders the request as a Go program using net/http
+func GoSnippet(req types.Request) string {
+	imports := map[string]bool{"fmt": true, "io": true, "net/http": true}
+	var b strings.Builder
+
+	bodyArg := "nil"
+	if body := exportBody(req); body != "" {
+		imports["strings"] = true
+		fmt.Fprintf(&b, "\tbody := strings.NewReader(%s)\n", strconv.Quote(body))
+		bodyArg = "body"
+	}
+
+	form := exportForm(req)
+	if len(form) > 0 {
+		imports["bytes"] = true
+		imports["mime/multipart"] = true
+		b.WriteString("\tvar body bytes.Buffer\n")
+		b.WriteString("\twriter := multipart.NewWriter(&body)\n")
+		for _, field := range form {
+			if !field.File && field.ContentType == "" {
+				fmt.Fprintf(&b, "\twriter.WriteField(%s, %s)\n", strconv.Quote(field.Key), strconv.Quote(field.Value))
+				continue
+			}
+
+			imports["net/textproto"] = true
+			b.WriteString("\t{\n")
+			disposition := fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(field.Key))
+			content := strconv.Quote(field.Value)
+			contentType := field.ContentType
+			if field.File {
+				imports["os"] = true
+				fmt.Fprintf(&b, "\t\tcontent, err := os.ReadFile(%s)\n", strconv.Quote(field.Value))
+				b.WriteString("\t\tif err != nil {\n\t\t\tpanic(err)\n\t\t}\n")
+				disposition += fmt.Sprintf(`; filename="%s"`, escapeQuotes(filepath.Base(field.Value)))
+				content = "content"
+				contentType = fileContentType(field)
+			}
+			b.WriteString("\t\theader := textproto.MIMEHeader{}\n")
+			fmt.Fprintf(&b, "\t\theader.Set(\"Content-Disposition\", %s)\n", strconv.Quote(disposition))
+			fmt.Fprintf(&b, "\t\theader.Set(\"Content-Type\", %s)\n", strconv.Quote(contentType))
+			b.WriteString("\t\tpart, err := writer.CreatePart(header)\n")
+			b.WriteString("\t\tif err != nil {\n\t\t\tpanic(err)\n\t\t}\n")
+			if field.File {
+				b.WriteString("\t\tpart.Write(content)\n")
+			} else {
+				fmt.Fprintf(&b, "\t\tio.WriteString(part, %s)\n", content)
+			}
+			b.WriteString("\t}\n")
+		}
+		b.WriteString("\twriter.Close()\n\n")
+		bodyArg = "&body"
+	}
+
+	fmt.Fprintf(&b, "\treq, err := http.NewRequest(%s, %s, %s)\n", strconv.Quote(req.Method), strconv.Quote(req.URL), bodyArg)
+	b.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	if len(form) > 0 {
+		b.WriteString("\treq.Header.Set(\"Content-Type\", writer.FormDataContentType())\n")
+	}
+	for _, h := range exportHeaders(req) {
+		fmt.Fprintf(&b, "\treq.Header.Set(%s, %s)\n", strconv.Quote(h.Key), strconv.Quote(h.Value))
+	}
+	b.WriteString("\n\tresp, err := http.DefaultClient.Do(req)\n")
+	b.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	b.WriteString("\tdefer resp.Body.Close()\n\n")
+	b.WriteString("\tdata, err := io.ReadAll(resp.Body)\n")
+	b.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	b.WriteString("\tfmt.Println(resp.Status)\n")
+	b.WriteString("\tfmt.Println(string(data))\n")
+	b.WriteString("}\n")
+
+	names := make([]string, 0, len(imports))
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var program strings.Builder
+	program.WriteString("package main\n\nimport (\n")
+	for _, name := range names {
+		fmt.Fprintf(&program, "\t%s\n", strconv.Quote(name))
+	}
+	program.WriteString(")\n\nfunc main() {\n")
+	program.WriteString(b.String())
+	return program.String()
+}
+
+// PythonSnippet renders the request using the requests library
+func PythonSnippet(req types.Request) string {
+	var b strings.Builder
+	b.WriteString("import requests\n\n")
+	fmt.Fprintf(&b, "url = %s\n", jsonQuote(req.URL))
+
+	headers := exportHeaders(req)
+	args := ""
+	if len(headers) > 0 {
+		b.WriteString("headers = {\n")
+		for _, h := range headers {
+			fmt.Fprintf(&b, "    %s: %s,\n", jsonQuote(h.Key), jsonQuote(h.Value))
+		}
+		b.WriteString("}\n")
+		args += ", headers=headers"
+	}
+	if body := exportBody(req); body != "" {
+		fmt.Fprintf(&b, "data = %s\n", jsonQuote(body))
+		args += ", data=data.encode(\"utf-8\")"
+	}
+	if form := exportForm(req); len(form) > 0 {
+		// A (filename, content, type) tuple per part; None as the filename makes a plain field
+		b.WriteString("files = [\n")
+		for _, field := range form {
+			if field.File {
+				fmt.Fprintf(&b, "    (%s, (%s, open(%s, \"rb\"), %s)),\n", jsonQuote(field.Key),
+					jsonQuote(filepath.Base(field.Value)), jsonQuote(field.Value), jsonQuote(fileContentType(field)))
+			} else if field.ContentType != "" {
+				fmt.Fprintf(&b, "    (%s, (None, %s, %s)),\n", jsonQuote(field.Key), jsonQuote(field.Value), jsonQuote(field.ContentType))
+			} else {
+				fmt.Fprintf(&b, "    (%s, (None, %s)),\n", jsonQuote(field.Key), jsonQuote(field.Value))
+			}
+		}
+		b.WriteString("]\n")
+		args += ", files=files"
+	}
+
+	fmt.Fprintf(&b, "\nresponse = requests.request(%s, url%s)\n", jsonQuote(req.Method), args)
+	b.WriteString("print(response.status_code)\n")
+	b.WriteString("print(response.text)\n")
+	return b.String()
+}
+
+// FetchSnippet renders the request using the JavaScript fetch API
+func FetchSnippet(req types.Request) string {
+	var b strings.Builder
+
+	form := exportForm(req)
+	if len(form) > 0 {
+		for _, field := range form {
+			if field.File {
+				b.WriteString("import { openAsBlob } from \"node:fs\";\n\n")
+				break
+			}
+		}
+		b.WriteString("const form = new FormData();\n")
+		for _, field := range form {
+			switch {
+			case field.File:
+				fmt.Fprintf(&b, "form.append(%s, await openAsBlob(%s, { type: %s }), %s);\n", jsonQuote(field.Key),
+					jsonQuote(field.Value), jsonQuote(fileContentType(field)), jsonQuote(filepath.Base(field.Value)))
+			case field.ContentType != "":
+				fmt.Fprintf(&b, "form.append(%s, new Blob([%s], { type: %s }));\n", jsonQuote(field.Key),
+					jsonQuote(field.Value), jsonQuote(field.ContentType))
+			default:
+				fmt.Fprintf(&b, "form.append(%s, %s);\n", jsonQuote(field.Key), jsonQuote(field.Value))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "const response = await fetch(%s, {\n", jsonQuote(req.URL))
+	fmt.Fprintf(&b, "  method: %s,\n", jsonQuote(req.Method))
+
+	if headers := exportHeaders(req); len(headers) > 0 {
+		b.WriteString("  headers: {\n")
+		for _, h := range headers {
+			fmt.Fprintf(&b, "    %s: %s,\n", jsonQuote(h.Key), jsonQuote(h.Value))
+		}
+		b.WriteString("  },\n")
+	}
+	if body := exportBody(req); body != "" {
+		fmt.Fprintf(&b, "  body: %s,\n", jsonQuote(body))
+	}
+	if len(form) > 0 {
+		b.WriteString("  body: form,\n")
+	}
+
+	b.WriteString("});\n\n")
+	b.WriteString("console.log(response.status);\n")
+	b.WriteString("console.log(await response.text());\n")
+	return b.String()
+}
+
+// CopyToClipboard places text on the system clipboard
+func CopyToClipboard(text string) error {
+	return clipboard.WriteAll(text)
+}
+
+// WriteSnippet writes a generated snippet to path, which must not exist yet
+func WriteSnippet(path, text string) error {
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists", path)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"postty/src/types"
+)
+
+func TestCurlSnippetRoundTrip(t *testing.T) {
+	policies := []types.RedirectPolicy{
+		{},
+		{NoFollow: true},
+		{Max: 3},
+		{Max: 5, KeepMethod: true},
+	}
+	for _, policy := range policies {
+		req := types.Request{
+			Method:      "POST",
+			URL:         "https://x.test/items?q=1",
+			Body:        `{"name":"it's"}`,
+			ContentType: "application/json",
+			Headers:     []types.Header{{Key: "X-Trace", Value: "1"}},
+			Redirects:   policy,
+		}
+		snippet := CurlSnippet(req)
+		imported, err := ParseCurl(snippet)
+		if err != nil {
+			t.Fatalf("%s: %v", snippet, err)
+		}
+		got := imported.Request
+		if got.Method != req.Method || got.URL != req.URL || got.Body != req.Body || got.ContentType != req.ContentType {
+			t.Errorf("%s\nimported as %s %s %q %s", snippet, got.Method, got.URL, got.Body, got.ContentType)
+		}
+		if got.Redirects != policy {
+			t.Errorf("%s\nimported redirects %+v, want %+v", snippet, got.Redirects, policy)
+		}
+	}
+}
+
+func TestCurlSnippetMethod(t *testing.T) {
+	tests := []struct {
+		req  types.Request
+		want string
+	}{
+		{types.Request{Method: "GET", URL: "https://x.test/"}, "curl -L https://x.test/"},
+		{types.Request{Method: "HEAD", URL: "https://x.test/", Redirects: types.RedirectPolicy{NoFollow: true}}, "curl -I https://x.test/"},
+		{types.Request{Method: "POST", URL: "https://x.test/", Redirects: types.RedirectPolicy{NoFollow: true}}, "curl -X POST https://x.test/"},
+		{types.Request{Method: "DELETE", URL: "https://x.test/", Redirects: types.RedirectPolicy{Max: 2}}, "curl -X DELETE -L --max-redirs 2 https://x.test/"},
+	}
+	for _, tt := range tests {
+		if got := strings.Split(CurlSnippet(tt.req), " \\\n")[0]; got != tt.want {
+			t.Errorf("got %s, want %s", got, tt.want)
+		}
+	}
+}
+
+// testExportRequests are a JSON POST and a multipart form with a file, a typed field and a
+// disabled field
+var testExportRequests = []types.Request{
+	{
+		Method:      "POST",
+		URL:         "https://x.test/items?q=1",
+		Body:        `{"name":"it's"}`,
+		ContentType: "application/json",
+		Headers:     []types.Header{{Key: "X-Trace", Value: "a b"}},
+	},
+	{
+		Method:      "POST",
+		URL:         "https://x.test/up",
+		ContentType: FormMultipart,
+		Form: []types.FormField{
+			{Key: "name", Value: "bob"},
+			{Key: "doc", Value: "/tmp/a.txt", File: true},
+			{Key: "meta", Value: "{}", ContentType: "application/json"},
+			{Key: "off", Value: "x", Disabled: true},
+		},
+	},
+}
+
+func TestGoSnippet(t *testing.T) {
+	for _, req := range testExportRequests {
+		snippet := GoSnippet(req)
+		formatted, err := format.Source([]byte(snippet))
+		if err != nil {
+			t.Fatalf("%v\n%s", err, snippet)
+		}
+		if string(formatted) != snippet {
+			t.Errorf("the program is not gofmt'd:\n%s", snippet)
+		}
+		if strings.Contains(snippet, `"off"`) {
+			t.Errorf("the disabled field was exported:\n%s", snippet)
+		}
+	}
+
+	tests := []struct {
+		req  types.Request
+		want []string
+	}{
+		{testExportRequests[0], []string{
+			`body := strings.NewReader("{\"name\":\"it's\"}")`,
+			`req, err := http.NewRequest("POST", "https://x.test/items?q=1", body)`,
+			`req.Header.Set("Content-Type", "application/json")`,
+			`req.Header.Set("X-Trace", "a b")`,
+		}},
+		{testExportRequests[1], []string{
+			`writer.WriteField("name", "bob")`,
+			`content, err := os.ReadFile("/tmp/a.txt")`,
+			`header.Set("Content-Disposition", "form-data; name=\"doc\"; filename=\"a.txt\"")`,
+			`header.Set("Content-Type", "application/json")`,
+			`req.Header.Set("Content-Type", writer.FormDataContentType())`,
+		}},
+		{types.Request{Method: "GET", URL: "https://x.test/", Body: "ignored"}, []string{
+			`req, err := http.NewRequest("GET", "https://x.test/", nil)`,
+		}},
+	}
+	for _, tt := range tests {
+		snippet := GoSnippet(tt.req)
+		for _, line := range tt.want {
+			if !strings.Contains(snippet, line) {
+				t.Errorf("missing %s in\n%s", line, snippet)
+			}
+		}
+	}
+}
+
+func TestPythonSnippet(t *testing.T) {
+	want := `import requests
+
+url = "https://x.test/items?q=1"
+headers = {
+    "Content-Type": "application/json",
+    "X-Trace": "a b",
+}
+data = "{\"name\":\"it's\"}"
+
+response = requests.request("POST", url, headers=headers, data=data.encode("utf-8"))
+print(response.status_code)
+print(response.text)
+`
+	if got := PythonSnippet(testExportRequests[0]); got != want {
+		t.Errorf("got\n%s", got)
+	}
+
+	wantFiles := `files = [
+    ("name", (None, "bob")),
+    ("doc", ("a.txt", open("/tmp/a.txt", "rb"), "text/plain; charset=utf-8")),
+    ("meta", (None, "{}", "application/json")),
+]
+
+response = requests.request("POST", url, files=files)
+`
+	if got := PythonSnippet(testExportRequests[1]); !strings.Contains(got, wantFiles) {
+		t.Errorf("got\n%s", got)
+	}
+}
+
+func TestFetchSnippet(t *testing.T) {
+	want := `const response = await fetch("https://x.test/items?q=1", {
+  method: "POST",
+  headers: {
+    "Content-Type": "application/json",
+    "X-Trace": "a b",
+  },
+  body: "{\"name\":\"it's\"}",
+});
+
+console.log(response.status);
+console.log(await response.text());
+`
+	if got := FetchSnippet(testExportRequests[0]); got != want {
+		t.Errorf("got\n%s", got)
+	}
+
+	wantForm := `import { openAsBlob } from "node:fs";
+
+const form = new FormData();
+form.append("name", "bob");
+form.append("doc", await openAsBlob("/tmp/a.txt", { type: "text/plain; charset=utf-8" }), "a.txt");
+form.append("meta", new Blob(["{}"], { type: "application/json" }));
+
+const response = await fetch("https://x.test/up", {
+  method: "POST",
+  body: form,
+});
+`
+	if got := FetchSnippet(testExportRequests[1]); !strings.HasPrefix(got, wantForm) {
+		t.Errorf("got\n%s", got)
+	}
+}
+
+func TestHTTPieSnippet(t *testing.T) {
+	tests := []struct {
+		req  types.Request
+		want string
+	}{
+		{testExportRequests[0], "http POST 'https://x.test/items?q=1' \\\n" +
+			"  Content-Type:application/json \\\n" +
+			"  'X-Trace:a b' \\\n" +
+			`  --raw '{"name":"it'\''s"}'`},
+		{testExportRequests[1], "http --multipart POST https://x.test/up \\\n" +
+			"  name=bob \\\n" +
+			"  doc@/tmp/a.txt \\\n" +
+			"  'meta={}'"},
+		{types.Request{Method: "DELETE", URL: "https://x.test/1"}, "http DELETE https://x.test/1"},
+	}
+	for _, tt := range tests {
+		if got := HTTPieSnippet(tt.req); got != tt.want {
+			t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"":                     "''",
+		"https://x.test/a?q=1": "'https://x.test/a?q=1'",
+		"https://x.test/a,b@c": "https://x.test/a,b@c",
+		"X-Trace:1":            "X-Trace:1",
+		"a b":                  "'a b'",
+		"it's":                 `'it'\''s'`,
+		"$HOME":                "'$HOME'",
+		"`id`":                 "'`id`'",
+		"semi;colon":           "'semi;colon'",
+		"new\nline":            "'new\nline'",
+		"ünïcode":              "'ünïcode'",
+	}
+	for s, want := range tests {
+		if got := ShellQuote(s); got != want {
+			t.Errorf("%q: got %s, want %s", s, got, want)
+		}
+	}
+}
+
+func TestWriteSnippet(t *testing.T) {
+	names := map[string]bool{}
+	for _, format := range SnippetFormats {
+		if names[format.FileName()] {
+			t.Errorf("%s shares the file %s", format.Name, format.FileName())
+		}
+		names[format.FileName()] = true
+	}
+	if got := SnippetFormats[0].FileName(); got != "postty-export-curl.sh" {
+		t.Errorf("got %s", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "snippet.sh")
+	if err := WriteSnippet(path, "echo 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteSnippet(path, "echo 2"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("got %v, want the existing file refused", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "echo 1\n" {
+		t.Errorf("the file holds %q", data)
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"postty/src/types"
+)
+
+// Content types whose body is built from form fields
+const (
+	FormURLEncoded = "application/x-www-form-urlencoded"
+	FormMultipart  = "multipart/form-data"
+)
+
+// IsFormContentType reports whether contentType is edited as form fields
+func IsFormContentType(contentType string) bool {
+	return contentType == FormURLEncoded || contentType == FormMultipart
+}
+
+// ParseFormField parses a field written the way curl's -F takes it:
+// "name=value", "name=@path" for a file part, with an optional ";type=content/type"
+func ParseFormField(spec string) (types.FormField, error) {
+	name, value, ok := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return types.FormField{}, fmt.Errorf("form field %q must look like name=value or name=@file", spec)
+	}
+
+	field := types.FormField{Key: name, Value: value}
+	if strings.HasPrefix(value, "@") {
+		field.File = true
+		field.Value = value[1:]
+	}
+	if i := strings.LastIndex(field.Value, ";type="); i >= 0 {
+		field.ContentType = strings.TrimSpace(field.Value[i+len(";type="):])
+		field.Value = field.Value[:i]
+	}
+	return field, nil
+}
+
+// FormFieldSpec renders a field in the syntax read by ParseFormField
+func FormFieldSpec(field types.FormField) string {
+	spec := field.Key + "="
+	if field.File {
+		spec += "@"
+	}
+	spec += field.Value
+	if field.ContentType != "" {
+		spec += ";type=" + field.ContentType
+	}
+	return spec
+}
+
+// ParseFormBody turns a raw body into form fields, for requests stored before the form
+// editor existed. It returns nil when the body is not a clean list of fields.
+func ParseFormBody(body, contentType string) []types.FormField {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil
+	}
+
+	var fields []types.FormField
+	switch contentType {
+	case FormURLEncoded:
+		for _, pair := range strings.Split(body, "&") {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || key == "" || strings.ContainsAny(pair, " \t\r\n{}[]\"") {
+				return nil
+			}
+			fields = append(fields, types.FormField{Key: unescapeQueryComponent(key), Value: unescapeQueryComponent(value)})
+		}
+
+	case FormMultipart:
+		// One name=value per line, as cURL imports used to store -F fields
+		for _, line := range strings.Split(body, "\n") {
+			field, err := ParseFormField(strings.TrimSpace(line))
+			if err != nil {
+				return nil
+			}
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// EncodeForm builds the body and Content-Type for form fields. Multipart bodies get a
+// generated boundary and read file parts from disk.
+func EncodeForm(fields []types.FormField, contentType string) (string, string, error) {
+	if contentType == FormMultipart {
+		return encodeMultipart(fields)
+	}
+
+	var pairs []string
+	for _, field := range fields {
+		if field.Disabled || field.Key == "" {
+			continue
+		}
+		if field.File {
+			return "", "", fmt.Errorf("form field %s: file parts need %s", field.Key, FormMultipart)
+		}
+		pairs = append(pairs, escapeQueryComponent(field.Key)+"="+escapeQueryComponent(field.Value))
+	}
+	return strings.Join(pairs, "&"), contentType, nil
+}
+
+// encodeMultipart writes the fields as a multipart/form-data body
+func encodeMultipart(fields []types.FormField) (string, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for _, field := range fields {
+		if field.Disabled || field.Key == "" {
+			continue
+		}
+
+		header := textproto.MIMEHeader{}
+		var content []byte
+		if field.File {
+			data, err := os.ReadFile(field.Value)
+			if err != nil {
+				return "", "", fmt.Errorf("form field %s: %w", field.Key, err)
+			}
+			content = data
+			header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+				escapeQuotes(field.Key), escapeQuotes(filepath.Base(field.Value))))
+			header.Set("Content-Type", fileContentType(field))
+		} else {
+			content = []byte(field.Value)
+			header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(field.Key)))
+			if field.ContentType != "" {
+				header.Set("Content-Type", field.ContentType)
+			}
+		}
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return "", "", err
+		}
+		if _, err := part.Write(content); err != nil {
+			return "", "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", "", err
+	}
+	return body.String(), writer.FormDataContentType(), nil
+}
+
+// fileContentType returns the part content type of a file field, guessing from its extension
+func fileContentType(field types.FormField) string {
+	if field.ContentType != "" {
+		return field.ContentType
+	}
+	if guessed := mime.TypeByExtension(filepath.Ext(field.Value)); guessed != "" {
+		return guessed
+	}
+	return "application/octet-stream"
+}
+
+// escapeQuotes escapes a Content-Disposition parameter value
+func escapeQuotes(s string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(s)
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"postty/src/types"
+)
+
+func TestParseFormField(t *testing.T) {
+	tests := []struct {
+		spec string
+		want types.FormField
+	}{
+		{"name=bob smith", types.FormField{Key: "name", Value: "bob smith"}},
+		{"up=@a.txt;type=text/x-custom", types.FormField{Key: "up", Value: "a.txt", File: true, ContentType: "text/x-custom"}},
+		{"meta={};type=application/json", types.FormField{Key: "meta", Value: "{}", ContentType: "application/json"}},
+		{"empty=", types.FormField{Key: "empty"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormField(tt.spec)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %+v, %v", tt.spec, got, err)
+		}
+		if spec := FormFieldSpec(got); spec != tt.spec {
+			t.Errorf("%s: FormFieldSpec gave %s", tt.spec, spec)
+		}
+	}
+	for _, spec := range []string{"bad", "=value"} {
+		if _, err := ParseFormField(spec); err == nil {
+			t.Errorf("%s: expected an error", spec)
+		}
+	}
+}
+
+func TestParseFormBody(t *testing.T) {
+	got := ParseFormBody("a=1&b=two+words&c=%2F", FormURLEncoded)
+	want := []types.FormField{{Key: "a", Value: "1"}, {Key: "b", Value: "two words"}, {Key: "c", Value: "/"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v", got)
+	}
+	if got := ParseFormBody(`{"a":1}`, FormURLEncoded); got != nil {
+		t.Errorf("got %+v for a JSON body", got)
+	}
+
+	got = ParseFormBody("name=bob\nup=@a.txt", FormMultipart)
+	want = []types.FormField{{Key: "name", Value: "bob"}, {Key: "up", Value: "a.txt", File: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestEncodeForm(t *testing.T) {
+	fields := []types.FormField{
+		{Key: "name", Value: "jo & co"},
+		{Key: "off", Value: "1", Disabled: true},
+		{Key: "tag", Value: "{{tag}}"},
+	}
+	body, contentType, err := EncodeForm(fields, FormURLEncoded)
+	if err != nil || body != "name=jo+%26+co&tag={{tag}}" || contentType != FormURLEncoded {
+		t.Errorf("got %q %q %v", body, contentType, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(file, []byte("FILEDATA"), 0o644)
+	if _, _, err := EncodeForm([]types.FormField{{Key: "up", Value: file, File: true}}, FormURLEncoded); err == nil {
+		t.Error("expected an error for a file part in an urlencoded form")
+	}
+
+	fields = append(fields,
+		types.FormField{Key: "meta", Value: "{}", ContentType: "application/json"},
+		types.FormField{Key: "up", Value: file, File: true},
+	)
+	body, contentType, err = EncodeForm(fields, FormMultipart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType != FormMultipart {
+		t.Fatalf("got Content-Type %s", contentType)
+	}
+
+	type part struct{ name, file, contentType, content string }
+	var parts []part
+	reader := multipart.NewReader(strings.NewReader(body), params["boundary"])
+	for {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(p)
+		parts = append(parts, part{p.FormName(), p.FileName(), p.Header.Get("Content-Type"), string(content)})
+	}
+	want := []part{
+		{"name", "", "", "jo & co"},
+		{"tag", "", "", "{{tag}}"},
+		{"meta", "", "application/json", "{}"},
+		{"up", "a.txt", "text/plain; charset=utf-8", "FILEDATA"},
+	}
+	if !reflect.DeepEqual(parts, want) {
+		t.Errorf("got parts %+v", parts)
+	}
+
+	missing := []types.FormField{{Key: "up", Value: filepath.Join(t.TempDir(), "missing"), File: true}}
+	if _, _, err := EncodeForm(missing, FormMultipart); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/types"
+)
+
+// GraphQLContentType is the Content-Type pane entry for GraphQL requests. The body is the
+// query, and the request is sent as the JSON payload of the GraphQL over HTTP convention.
+const GraphQLContentType = "application/graphql"
+
+// graphQLIntrospectionQuery asks for the fields of every type, which is all completion needs
+const graphQLIntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      name
+      kind
+      fields(includeDeprecated: true) {
+        name
+        type { ...TypeRef }
+      }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+}`
+
+// graphQLKeywords are offered outside of any selection set
+var graphQLKeywords = []string{"query", "mutation", "subscription", "fragment"}
+
+// graphQLTypeRef is a type reference as returned by introspection
+type graphQLTypeRef struct {
+	Kind   string          `json:"kind"`
+	Name   string          `json:"name"`
+	OfType *graphQLTypeRef `json:"ofType"`
+}
+
+// GraphQLPayload builds the JSON document a GraphQL request is sent as. Variables must be
+// empty or a JSON object.
+func GraphQLPayload(req types.Request) (string, error) {
+	payload := struct {
+		Query         string          `json:"query"`
+		Variables     json.RawMessage `json:"variables,omitempty"`
+		OperationName string          `json:"operationName,omitempty"`
+	}{
+		Query:         req.Body,
+		OperationName: strings.TrimSpace(req.GraphQLOperation),
+	}
+
+	if variables := strings.TrimSpace(req.GraphQLVariables); variables != "" {
+		var object map[string]any
+		if err := json.Unmarshal([]byte(variables), &object); err != nil {
+			return "", fmt.Errorf("GraphQL variables must be a JSON object: %w", err)
+		}
+		payload.Variables = json.RawMessage(variables)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// sentContentType returns the Content-Type header sent for a content type selected in the form
+func sentContentType(contentType string) string {
+	if contentType == GraphQLContentType {
+		return "application/json"
+	}
+	return contentType
+}
+
+// IntrospectGraphQL creates a command that fetches the schema of the request's endpoint,
+// sending its headers so authenticated endpoints can be introspected too
+func IntrospectGraphQL(ctx context.Context, req types.Request) tea.Cmd {
+	return func() tea.Msg {
+		req.Method = "POST"
+		req.ContentType = GraphQLContentType
+		req.Body = graphQLIntrospectionQuery
+		req.GraphQLVariables = ""
+		req.GraphQLOperation = "IntrospectionQuery"
+
+		msg, ok := ExecuteRequest(ctx, req)().(types.ResponseMsg)
+		if !ok {
+			return types.GraphQLSchemaMsg{URL: req.URL, Err: fmt.Errorf("endpoint answered with an event stream")}
+		}
+		if msg.Err != nil {
+			return types.GraphQLSchemaMsg{URL: req.URL, Err: msg.Err}
+		}
+		if msg.StatusCode >= 400 {
+			return types.GraphQLSchemaMsg{URL: req.URL, Err: fmt.Errorf("introspection failed: %s", msg.Meta.Status)}
+		}
+
+		schema, err := ParseGraphQLSchema([]byte(msg.Body))
+		return types.GraphQLSchemaMsg{URL: req.URL, Schema: schema, Err: err}
+	}
+}
+
+// ParseGraphQLSchema reads the fields of each type from an introspection response
+func ParseGraphQLSchema(data []byte) (*types.GraphQLSchema, error) {
+	var response struct {
+		Data struct {
+			Schema *struct {
+				QueryType        *struct{ Name string } `json:"queryType"`
+				MutationType     *struct{ Name string } `json:"mutationType"`
+				SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+				Types            []struct {
+					Name   string `json:"name"`
+					Fields []struct {
+						Name string         `json:"name"`
+						Type graphQLTypeRef `json:"type"`
+					} `json:"fields"`
+				} `json:"types"`
+			} `json:"__schema"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("introspection response is not JSON: %w", err)
+	}
+	if response.Data.Schema == nil {
+		if len(response.Errors) > 0 {
+			return nil, fmt.Errorf("introspection failed: %s", response.Errors[0].Message)
+		}
+		return nil, fmt.Errorf("introspection response has no schema")
+	}
+
+	raw := response.Data.Schema
+	schema := &types.GraphQLSchema{Types: map[string][]types.GraphQLField{}}
+	if raw.QueryType != nil {
+		schema.QueryType = raw.QueryType.Name
+	}
+	if raw.MutationType != nil {
+		schema.MutationType = raw.MutationType.Name
+	}
+	if raw.SubscriptionType != nil {
+		schema.SubscriptionType = raw.SubscriptionType.Name
+	}
+	for _, t := range raw.Types {
+		if t.Fields == nil {
+			continue
+		}
+		fields := make([]types.GraphQLField, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			fields = append(fields, types.GraphQLField{Name: f.Name, Type: typeRefString(&f.Type), TypeName: typeRefName(&f.Type)})
+		}
+		schema.Types[t.Name] = fields
+	}
+	return schema, nil
+}
+
+// typeRefString renders a type reference the way it is written in SDL, e.g. "[User!]!"
+func typeRefString(ref *graphQLTypeRef) string {
+	if ref == nil {
+		return ""
+	}
+	switch ref.Kind {
+	case "NON_NULL":
+		return typeRefString(ref.OfType) + "!"
+	case "LIST":
+		return "[" + typeRefString(ref.OfType) + "]"
+	}
+	return ref.Name
+}
+
+// typeRefName returns the named type at the bottom of a type reference
+func typeRefName(ref *graphQLTypeRef) string {
+	for ref != nil && ref.Name == "" {
+		ref = ref.OfType
+	}
+	if ref == nil {
+		return ""
+	}
+	return ref.Name
+}
+
+// GraphQLCompletions returns the word being typed at the end of before, the query text up
+// to the cursor, and what it can be completed to: the fields of the enclosing selection set,
+// type names after "on", or operation keywords outside of any selection set.
+func GraphQLCompletions(schema *types.GraphQLSchema, before string) (string, []string) {
+	tokens := graphQLTokens(before)
+
+	prefix := ""
+	if n := len(tokens); n > 0 && isGraphQLName(tokens[n-1]) && strings.HasSuffix(before, tokens[n-1]) {
+		prefix = tokens[n-1]
+		tokens = tokens[:n-1]
+	}
+
+	var stack []string // Type of each enclosing selection set, "" when unknown
+	pending := ""      // Type the next selection set belongs to
+	parens := 0
+	previous := ""
+	for _, token := range tokens {
+		switch {
+		case token == "(":
+			parens++
+		case token == ")":
+			if parens > 0 {
+				parens--
+			}
+		case parens > 0:
+			// Arguments and variable definitions hold no selections
+		case token == "{":
+			if len(stack) == 0 && pending == "" {
+				pending = schema.QueryType // Shorthand query
+			}
+			stack = append(stack, pending)
+			pending = ""
+		case token == "}":
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			pending = ""
+		case !isGraphQLName(token) || previous == "@" || previous == "$":
+			// Punctuation, directives and variables
+		case previous == "on":
+			pending = token
+		case len(stack) == 0:
+			switch token {
+			case "query":
+				pending = schema.QueryType
+			case "mutation":
+				pending = schema.MutationType
+			case "subscription":
+				pending = schema.SubscriptionType
+			}
+		case token != "on" && previous != "...":
+			pending = graphQLFieldType(schema, stack[len(stack)-1], token)
+		}
+		previous = token
+	}
+
+	var candidates []string
+	switch {
+	case parens > 0:
+		return prefix, nil
+	case previous == "on":
+		for name := range schema.Types {
+			if !strings.HasPrefix(name, "__") {
+				candidates = append(candidates, name)
+			}
+		}
+		sort.Strings(candidates)
+	case len(stack) == 0:
+		candidates = append(candidates, graphQLKeywords...)
+	default:
+		for _, field := range schema.Types[stack[len(stack)-1]] {
+			candidates = append(candidates, field.Name)
+		}
+		candidates = append(candidates, "__typename")
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) && candidate != prefix {
+			matches = append(matches, candidate)
+		}
+	}
+	return prefix, matches
+}
+
+// graphQLFieldType returns the named type of a field, or "" when the schema does not know it
+func graphQLFieldType(schema *types.GraphQLSchema, typeName, field string) string {
+	for _, f := range schema.Types[typeName] {
+		if f.Name == field {
+			return f.TypeName
+		}
+	}
+	return ""
+}
+
+// graphQLTokens splits a query into names, punctuators and values, dropping comments,
+// strings and whitespace
+func graphQLTokens(text string) []string {
+	var tokens []string
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '"' && strings.HasPrefix(string(runes[i:min(i+3, len(runes))]), `"""`):
+			i += 3
+			for i < len(runes) && string(runes[i:min(i+3, len(runes))]) != `"""` {
+				i++
+			}
+			i += 3
+		case r == '"':
+			i++
+			for i < len(runes) && runes[i] != '"' && runes[i] != '\n' {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+		case r == '.' && string(runes[i:min(i+3, len(runes))]) == "...":
+			tokens = append(tokens, "...")
+			i += 3
+		case isGraphQLNameRune(r, true):
+			start := i
+			for i < len(runes) && isGraphQLNameRune(runes[i], false) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		case r >= '0' && r <= '9' || r == '-':
+			start := i
+			for i++; i < len(runes) && strings.ContainsRune("0123456789.eE+-", runes[i]); i++ {
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		case strings.ContainsRune("{}()[]:=@$!|&", r):
+			tokens = append(tokens, string(r))
+			i++
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// isGraphQLName reports whether token is a name rather than a punctuator or number
+func isGraphQLName(token string) bool {
+	return token != "" && isGraphQLNameRune([]rune(token)[0], true)
+}
+
+// isGraphQLNameRune reports whether r can appear in a name, first telling whether it starts it
+func isGraphQLNameRune(r rune, first bool) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || !first && r >= '0' && r <= '9'
+}
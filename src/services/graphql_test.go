@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"postty/src/types"
+)
+
+const testGraphQLSchema = `{"data":{"__schema":{"queryType":{"name":"Query"},"mutationType":{"name":"Mutation"},"subscriptionType":null,"types":[
+{"name":"Query","kind":"OBJECT","fields":[{"name":"user","type":{"kind":"OBJECT","name":"User","ofType":null}},{"name":"users","type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"LIST","name":null,"ofType":{"kind":"NON_NULL","name":null,"ofType":{"kind":"OBJECT","name":"User"}}}}}]},
+{"name":"Mutation","kind":"OBJECT","fields":[{"name":"createUser","type":{"kind":"OBJECT","name":"User","ofType":null}}]},
+{"name":"User","kind":"OBJECT","fields":[{"name":"id","type":{"kind":"SCALAR","name":"ID"}},{"name":"name","type":{"kind":"SCALAR","name":"String"}},{"name":"friends","type":{"kind":"LIST","name":null,"ofType":{"kind":"OBJECT","name":"User"}}}]},
+{"name":"String","kind":"SCALAR","fields":null}]}}}`
+
+func TestGraphQLPayload(t *testing.T) {
+	payload, err := GraphQLPayload(types.Request{Body: "query Q { user { id } }", GraphQLVariables: ` {"id": "1"} `, GraphQLOperation: " Q "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"query":"query Q { user { id } }","variables":{"id":"1"},"operationName":"Q"}`; payload != want {
+		t.Errorf("got %s", payload)
+	}
+
+	payload, err = GraphQLPayload(types.Request{Body: "{ users { id } }"})
+	if err != nil || payload != `{"query":"{ users { id } }"}` {
+		t.Errorf("got %s, %v", payload, err)
+	}
+
+	if _, err := GraphQLPayload(types.Request{Body: "{ a }", GraphQLVariables: "[1]"}); err == nil {
+		t.Error("expected an error for variables that are not an object")
+	}
+}
+
+func TestParseGraphQLSchema(t *testing.T) {
+	schema, err := ParseGraphQLSchema([]byte(testGraphQLSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.QueryType != "Query" || schema.MutationType != "Mutation" || schema.SubscriptionType != "" {
+		t.Errorf("got root types %+v", schema)
+	}
+	want := []types.GraphQLField{{Name: "user", Type: "User", TypeName: "User"}, {Name: "users", Type: "[User!]!", TypeName: "User"}}
+	if !reflect.DeepEqual(schema.Types["Query"], want) {
+		t.Errorf("got Query fields %+v", schema.Types["Query"])
+	}
+	if _, ok := schema.Types["String"]; ok {
+		t.Error("scalar types have no fields to complete")
+	}
+
+	if _, err := ParseGraphQLSchema([]byte(`{"errors":[{"message":"introspection disabled"}]}`)); err == nil {
+		t.Error("expected an error without a schema")
+	}
+}
+
+func TestGraphQLCompletions(t *testing.T) {
+	schema, err := ParseGraphQLSchema([]byte(testGraphQLSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		before string
+		prefix string
+		want   []string
+	}{
+		{"", "", []string{"query", "mutation", "subscription", "fragment"}},
+		{"qu", "qu", []string{"query"}},
+		{"{ us", "us", []string{"user", "users"}},
+		{`query Q($id: ID = "{") { user(id: $id) { `, "", []string{"id", "name", "friends", "__typename"}},
+		{"{ user { friends { na", "na", []string{"name"}},
+		{"{ users { ... on ", "", []string{"Mutation", "Query", "User"}},
+		{"mutation { c", "c", []string{"createUser"}},
+		{"{ user { id } }", "", []string{"query", "mutation", "subscription", "fragment"}},
+		{"fragment F on User { fr", "fr", []string{"friends"}},
+		{"{ a: user { n", "n", []string{"name"}},
+	}
+	for _, tt := range tests {
+		prefix, got := GraphQLCompletions(schema, tt.before)
+		if prefix != tt.prefix || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %q %v, want %q %v", tt.before, prefix, got, tt.prefix, tt.want)
+		}
+	}
+
+	if _, got := GraphQLCompletions(schema, "{ user(filter: {a"); len(got) != 0 {
+		t.Errorf("got %v inside arguments", got)
+	}
+}
+
+func TestIntrospectGraphQL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload struct{ OperationName string }
+		json.Unmarshal(body, &payload)
+		if r.Header.Get("Content-Type") != "application/json" || payload.OperationName != "IntrospectionQuery" || r.Header.Get("Authorization") != "Bearer t" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, testGraphQLSchema)
+	}))
+	defer server.Close()
+
+	req := types.Request{Method: "GET", URL: server.URL, Body: "{ user }", Headers: []types.Header{{Key: "Authorization", Value: "Bearer t"}}}
+	msg, ok := IntrospectGraphQL(context.Background(), req)().(types.GraphQLSchemaMsg)
+	if !ok || msg.Err != nil || msg.Schema == nil || len(msg.Schema.Types["User"]) != 3 {
+		t.Fatalf("got %+v", msg)
+	}
+
+	// Auth is applied as it is when sending
+	req.Headers = nil
+	req.Auth = types.Auth{Type: types.AuthBearer, Token: "t"}
+	if msg := IntrospectGraphQL(context.Background(), req)().(types.GraphQLSchemaMsg); msg.Err != nil {
+		t.Errorf("got %v with bearer auth", msg.Err)
+	}
+
+	req.URL = server.URL + "/?fail"
+	req.Auth = types.Auth{}
+	msg = IntrospectGraphQL(context.Background(), req)().(types.GraphQLSchemaMsg)
+	if msg.Err == nil || !strings.Contains(msg.Err.Error(), "400") {
+		t.Errorf("got %v, want the status reported", msg.Err)
+	}
+}
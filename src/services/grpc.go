@@ -0,0 +1,511 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"postty/src/types"
+)
+
+// reflectionMethods are the server reflection endpoints tried in turn; v1alpha has the
+// same messages as v1, so both are spoken with the v1 types
+var reflectionMethods = []string{
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+}
+
+// grpcCall implements types.GRPCCall over a client stream
+type grpcCall struct {
+	replies chan types.GRPCReply
+	result  types.GRPCResult
+}
+
+// GRPCTarget splits a gRPC URL such as "grpc://localhost:50051/helloworld.Greeter/SayHello"
+// into the address to dial, whether to use TLS and the full method name. grpcs:// and
+// https:// use TLS; grpc://, http:// and bare addresses do not.
+func GRPCTarget(rawURL string) (address string, useTLS bool, method string, err error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "grpc://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false, "", err
+	}
+
+	switch u.Scheme {
+	case "grpcs", "https":
+		useTLS = true
+	case "grpc", "http":
+	default:
+		return "", false, "", fmt.Errorf("gRPC URLs start with grpc:// or grpcs://, not %s://", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", false, "", fmt.Errorf("gRPC URL %q has no host", rawURL)
+	}
+
+	address = u.Host
+	if u.Port() == "" {
+		address += ":443"
+		if !useTLS {
+			address = u.Host + ":80"
+		}
+	}
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		method = "/" + path
+	}
+	return address, useTLS, method, nil
+}
+
+// dialGRPC creates a client connection for a gRPC URL, using settings when it is over TLS
+func dialGRPC(rawURL string, settings types.TLSSettings) (*grpc.ClientConn, string, error) {
+	address, useTLS, method, err := GRPCTarget(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	creds := insecure.NewCredentials()
+	if useTLS {
+		config, err := TLSConfig(settings)
+		if err != nil {
+			return nil, "", err
+		}
+		creds = credentials.NewTLS(config)
+	}
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, "", err
+	}
+	return conn, method, nil
+}
+
+// ListGRPCMethods creates a command that lists the methods of the server in the request
+// URL, described by protoFiles when any are configured and by server reflection otherwise
+func ListGRPCMethods(ctx context.Context, req types.Request, protoFiles, importPaths []string) tea.Cmd {
+	return func() tea.Msg {
+		if req.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(req.Timeout)*time.Second)
+			defer cancel()
+		}
+
+		conn, _, err := dialGRPC(req.URL, req.TLS)
+		if err != nil {
+			return types.GRPCMethodsMsg{Err: err}
+		}
+		defer conn.Close()
+
+		methods, source, err := grpcMethods(grpcContext(ctx, req), conn, protoFiles, importPaths)
+		if err != nil {
+			return types.GRPCMethodsMsg{Err: err}
+		}
+
+		var list []types.GRPCMethodInfo
+		for name, method := range methods {
+			template, _ := grpcJSON(protojson.MarshalOptions{EmitUnpopulated: true}, dynamicpb.NewMessage(method.Input()))
+			list = append(list, types.GRPCMethodInfo{
+				FullName:        name,
+				ClientStreaming: method.IsStreamingClient(),
+				ServerStreaming: method.IsStreamingServer(),
+				Template:        template,
+			})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].FullName < list[j].FullName })
+		return types.GRPCMethodsMsg{Methods: list, Source: source}
+	}
+}
+
+// CallGRPC creates a command that calls the method in the request URL. The body holds the
+// request message as JSON, or several messages one after another for client streaming,
+// and custom headers are sent as metadata. The call is reported with a GRPCStartedMsg
+// once the response headers arrive; its messages and status follow on the returned call.
+// request.Timeout, when set, is the deadline of the whole call.
+func CallGRPC(ctx context.Context, request types.Request, protoFiles, importPaths []string) tea.Cmd {
+	return func() tea.Msg {
+		cancel := context.CancelFunc(func() {})
+		if request.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(request.Timeout)*time.Second)
+		}
+		ctx = grpcContext(ctx, request)
+
+		conn, name, err := dialGRPC(request.URL, request.TLS)
+		if err != nil {
+			cancel()
+			return types.ResponseMsg{Err: err, Outcome: types.OutcomeError}
+		}
+		fail := func(err error) tea.Msg {
+			defer cancel()
+			conn.Close()
+			if s, ok := status.FromError(err); ok && s.Code() == codes.DeadlineExceeded {
+				return types.ResponseMsg{Err: err, Outcome: types.OutcomeTimedOut}
+			}
+			return types.ResponseMsg{Err: err, Outcome: errorOutcome(ctx, err)}
+		}
+		if name == "" {
+			return fail(fmt.Errorf("add the method to the URL, e.g. grpc://host:port/package.Service/Method"))
+		}
+
+		methods, source, err := grpcMethods(ctx, conn, protoFiles, importPaths)
+		if err != nil {
+			return fail(err)
+		}
+		method, ok := methods[name]
+		if !ok {
+			return fail(fmt.Errorf("method %s not found through %s", name, source))
+		}
+
+		messages, err := grpcRequestMessages(request.Body, method)
+		if err != nil {
+			return fail(err)
+		}
+
+		start := time.Now()
+		desc := &grpc.StreamDesc{ClientStreams: method.IsStreamingClient(), ServerStreams: method.IsStreamingServer()}
+		stream, err := conn.NewStream(ctx, desc, name)
+		if err != nil {
+			return fail(err)
+		}
+		for _, message := range messages {
+			if err := stream.SendMsg(message); err != nil {
+				if errors.Is(err, io.EOF) {
+					// The server ended the call; its status comes with the first receive
+					break
+				}
+				return fail(err)
+			}
+		}
+		if err := stream.CloseSend(); err != nil {
+			return fail(err)
+		}
+
+		header, err := stream.Header()
+		if err != nil {
+			return fail(err)
+		}
+
+		call := &grpcCall{replies: make(chan types.GRPCReply, 64)}
+		go func() {
+			defer close(call.replies)
+			defer cancel()
+			defer conn.Close()
+			call.receive(stream, method.Output())
+		}()
+
+		meta := types.ResponseMeta{
+			Status:        "gRPC " + name,
+			Proto:         "HTTP/2.0",
+			Duration:      time.Since(start),
+			ContentLength: -1,
+		}
+		if p, ok := peer.FromContext(stream.Context()); ok {
+			if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+				meta.TLS = tlsInfo(&info.State)
+			}
+		}
+		return types.GRPCStartedMsg{
+			Call:    call,
+			Headers: metadataHeaders(header),
+			Meta:    meta,
+		}
+	}
+}
+
+// WaitGRPC creates a command that delivers the next response message of call, or its end
+func WaitGRPC(call types.GRPCCall) tea.Cmd {
+	return func() tea.Msg {
+		reply, ok := <-call.Replies()
+		if !ok {
+			return types.GRPCEndedMsg{Call: call}
+		}
+		return types.GRPCReplyMsg{Call: call, Reply: reply}
+	}
+}
+
+// Replies implements types.GRPCCall
+func (c *grpcCall) Replies() <-chan types.GRPCReply {
+	return c.replies
+}
+
+// Result implements types.GRPCCall
+func (c *grpcCall) Result() types.GRPCResult {
+	return c.result
+}
+
+// receive forwards response messages until the call ends, then records its status
+func (c *grpcCall) receive(stream grpc.ClientStream, output protoreflect.MessageDescriptor) {
+	var err error
+	for {
+		message := dynamicpb.NewMessage(output)
+		if err = stream.RecvMsg(message); err != nil {
+			break
+		}
+		text, marshalErr := grpcJSON(protojson.MarshalOptions{}, message)
+		if marshalErr != nil {
+			text = fmt.Sprintf("(undecodable message: %v)", marshalErr)
+		}
+		c.replies <- types.GRPCReply{Time: time.Now(), Message: text}
+	}
+
+	s := status.New(codes.OK, "")
+	if !errors.Is(err, io.EOF) {
+		s = status.Convert(err)
+	}
+	c.result = types.GRPCResult{
+		Code:     code.Code(s.Code()).String(),
+		Number:   int(s.Code()),
+		Message:  s.Message(),
+		Trailers: metadataHeaders(stream.Trailer()),
+	}
+}
+
+// grpcJSON renders a message as indented JSON. protojson varies its whitespace on purpose,
+// so its compact output is indented with encoding/json for a stable layout.
+func grpcJSON(options protojson.MarshalOptions, message proto.Message) (string, error) {
+	data, err := options.Marshal(message)
+	if err != nil {
+		return "", err
+	}
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, data, "", "  "); err != nil {
+		return "", err
+	}
+	return pretty.String(), nil
+}
+
+// grpcContext attaches the custom headers of req as outgoing metadata
+func grpcContext(ctx context.Context, req types.Request) context.Context {
+	md := metadata.MD{}
+	for _, h := range req.Headers {
+		if h.Key != "" && h.Value != "" {
+			md.Append(strings.ToLower(h.Key), h.Value)
+		}
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// grpcRequestMessages parses the body into the request messages of method. An empty
+// body is one empty message, or none for client streaming.
+func grpcRequestMessages(body string, method protoreflect.MethodDescriptor) ([]proto.Message, error) {
+	var messages []proto.Message
+	decoder := json.NewDecoder(strings.NewReader(body))
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("request message %d is not valid JSON: %w", len(messages)+1, err)
+		}
+
+		message := dynamicpb.NewMessage(method.Input())
+		if err := protojson.Unmarshal(raw, message); err != nil {
+			return nil, fmt.Errorf("request message %d does not match %s: %w", len(messages)+1, method.Input().FullName(), err)
+		}
+		messages = append(messages, message)
+	}
+
+	if !method.IsStreamingClient() {
+		switch len(messages) {
+		case 0:
+			messages = append(messages, dynamicpb.NewMessage(method.Input()))
+		case 1:
+		default:
+			return nil, fmt.Errorf("%s takes a single request message, the body has %d", method.FullName(), len(messages))
+		}
+	}
+	return messages, nil
+}
+
+// grpcMethods returns the methods offered by the server, keyed by their full name, and
+// where their descriptions came from
+func grpcMethods(ctx context.Context, conn *grpc.ClientConn, protoFiles, importPaths []string) (map[string]protoreflect.MethodDescriptor, string, error) {
+	methods := map[string]protoreflect.MethodDescriptor{}
+	addService := func(service protoreflect.ServiceDescriptor) {
+		for i := 0; i < service.Methods().Len(); i++ {
+			method := service.Methods().Get(i)
+			methods[fmt.Sprintf("/%s/%s", service.FullName(), method.Name())] = method
+		}
+	}
+
+	if len(protoFiles) > 0 {
+		compiler := protocompile.Compiler{
+			Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: importPaths}),
+		}
+		files, err := compiler.Compile(ctx, protoFiles...)
+		if err != nil {
+			return nil, "", fmt.Errorf("compiling .proto files: %w", err)
+		}
+		for _, file := range files {
+			for i := 0; i < file.Services().Len(); i++ {
+				addService(file.Services().Get(i))
+			}
+		}
+		return methods, ".proto files", nil
+	}
+
+	services, files, err := reflectServices(ctx, conn)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, name := range services {
+		descriptor, err := files.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			continue
+		}
+		if service, ok := descriptor.(protoreflect.ServiceDescriptor); ok {
+			addService(service)
+		}
+	}
+	return methods, "server reflection", nil
+}
+
+// reflectServices asks the server for its services and the files describing them
+func reflectServices(ctx context.Context, conn *grpc.ClientConn) ([]string, *protoregistry.Files, error) {
+	var stream grpc.ClientStream
+	var services []string
+	var err error
+	for _, method := range reflectionMethods {
+		stream, err = conn.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}, method)
+		if err != nil {
+			return nil, nil, err
+		}
+		var resp *reflectionpb.ServerReflectionResponse
+		resp, err = reflectionRequest(stream, &reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{ListServices: "*"},
+		})
+		if status.Code(err) == codes.Unimplemented {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, service := range resp.GetListServicesResponse().GetService() {
+			if !strings.HasPrefix(service.GetName(), "grpc.reflection.") {
+				services = append(services, service.GetName())
+			}
+		}
+		break
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("server reflection is not available (%w); configure proto_files instead", err)
+	}
+	defer stream.CloseSend()
+
+	// Fetch the files defining each service, then any of their imports not sent along
+	files := map[string]*descriptorpb.FileDescriptorProto{}
+	add := func(resp *reflectionpb.ServerReflectionResponse) error {
+		for _, data := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			file := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(data, file); err != nil {
+				return err
+			}
+			files[file.GetName()] = file
+		}
+		return nil
+	}
+	for _, service := range services {
+		resp, err := reflectionRequest(stream, &reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := add(resp); err != nil {
+			return nil, nil, err
+		}
+	}
+	for missing := missingImports(files); len(missing) > 0; missing = missingImports(files) {
+		for _, name := range missing {
+			resp, err := reflectionRequest(stream, &reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := add(resp); err != nil {
+				return nil, nil, err
+			}
+			if _, ok := files[name]; !ok {
+				return nil, nil, fmt.Errorf("server reflection did not return %s", name)
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range files {
+		set.File = append(set.File, file)
+	}
+	registry, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, nil, fmt.Errorf("server reflection returned invalid descriptors: %w", err)
+	}
+	return services, registry, nil
+}
+
+// reflectionRequest sends one reflection request and returns its answer
+func reflectionRequest(stream grpc.ClientStream, req *reflectionpb.ServerReflectionRequest) (*reflectionpb.ServerReflectionResponse, error) {
+	if err := stream.SendMsg(req); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	resp := &reflectionpb.ServerReflectionResponse{}
+	if err := stream.RecvMsg(resp); err != nil {
+		return nil, err
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+// missingImports lists the files imported by files that are not among them
+func missingImports(files map[string]*descriptorpb.FileDescriptorProto) []string {
+	seen := map[string]bool{}
+	var missing []string
+	for _, file := range files {
+		for _, dependency := range file.GetDependency() {
+			if _, ok := files[dependency]; !ok && !seen[dependency] {
+				seen[dependency] = true
+				missing = append(missing, dependency)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// metadataHeaders flattens gRPC metadata into a sorted header list
+func metadataHeaders(md metadata.MD) []types.Header {
+	var headers []types.Header
+	for key, values := range md {
+		for _, value := range values {
+			headers = append(headers, types.Header{Key: key, Value: value})
+		}
+	}
+	sort.SliceStable(headers, func(i, j int) bool { return headers[i].Key < headers[j].Key })
+	return headers
+}
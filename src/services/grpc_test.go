@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+
+	"postty/src/types"
+)
+
+// startGRPCServer serves the health service with reflection, echoing the x-token header
+// back and sending a trailer with every unary call
+func startGRPCServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		grpc.SetHeader(ctx, metadata.Pairs("x-echo", strings.Join(md["x-token"], ",")))
+		grpc.SetTrailer(ctx, metadata.Pairs("x-trail", "bye"))
+		return handler(ctx, req)
+	}))
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("svc", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+// finishGRPCCall collects the replies of a started call and how it ended
+func finishGRPCCall(t *testing.T, msg any) ([]string, types.GRPCResult, []types.Header) {
+	t.Helper()
+	started, ok := msg.(types.GRPCStartedMsg)
+	if !ok {
+		t.Fatalf("got %+v, want a started call", msg)
+	}
+	var replies []string
+	for reply := range started.Call.Replies() {
+		replies = append(replies, strings.Join(strings.Fields(reply.Message), " "))
+	}
+	return replies, started.Call.Result(), started.Headers
+}
+
+func TestGRPCTarget(t *testing.T) {
+	tests := []struct {
+		url, address string
+		useTLS       bool
+		method       string
+	}{
+		{"grpc://localhost:50051/helloworld.Greeter/SayHello", "localhost:50051", false, "/helloworld.Greeter/SayHello"},
+		{"grpcs://api.test", "api.test:443", true, ""},
+		{"https://api.test/pkg.Svc/M", "api.test:443", true, "/pkg.Svc/M"},
+		{"http://api.test", "api.test:80", false, ""},
+		{"localhost:9000", "localhost:9000", false, ""},
+	}
+	for _, tt := range tests {
+		address, useTLS, method, err := GRPCTarget(tt.url)
+		if err != nil || address != tt.address || useTLS != tt.useTLS || method != tt.method {
+			t.Errorf("%s: got %s %v %s %v", tt.url, address, useTLS, method, err)
+		}
+	}
+	for _, bad := range []string{"ws://api.test", "grpc://"} {
+		if _, _, _, err := GRPCTarget(bad); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
+
+func TestListGRPCMethods(t *testing.T) {
+	address := startGRPCServer(t)
+	msg := ListGRPCMethods(context.Background(), types.Request{URL: "grpc://" + address, Timeout: 5}, nil, nil)().(types.GRPCMethodsMsg)
+	if msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	if msg.Source != "server reflection" {
+		t.Errorf("got source %q", msg.Source)
+	}
+	methods := map[string]types.GRPCMethodInfo{}
+	for _, method := range msg.Methods {
+		methods[method.FullName] = method
+	}
+	check, watch := methods["/grpc.health.v1.Health/Check"], methods["/grpc.health.v1.Health/Watch"]
+	if check.ServerStreaming || check.Template != "{\n  \"service\": \"\"\n}" {
+		t.Errorf("got %+v", check)
+	}
+	if !watch.ServerStreaming || watch.ClientStreaming {
+		t.Errorf("got %+v", watch)
+	}
+}
+
+func TestCallGRPC(t *testing.T) {
+	address := startGRPCServer(t)
+	req := types.Request{
+		Method:  types.GRPCMethod,
+		URL:     "grpc://" + address + "/grpc.health.v1.Health/Check",
+		Body:    `{"service":"svc"}`,
+		Headers: []types.Header{{Key: "x-token", Value: "abc"}},
+		Timeout: 5,
+	}
+	replies, result, headers := finishGRPCCall(t, CallGRPC(context.Background(), req, nil, nil)())
+	if !reflect.DeepEqual(replies, []string{`{ "status": "SERVING" }`}) {
+		t.Errorf("got replies %v", replies)
+	}
+	if result.Code != "OK" || !reflect.DeepEqual(result.Trailers, []types.Header{{Key: "x-trail", Value: "bye"}}) {
+		t.Errorf("got result %+v", result)
+	}
+	if !containsHeader(headers, types.Header{Key: "x-echo", Value: "abc"}) {
+		t.Errorf("got headers %v, want the metadata sent back", headers)
+	}
+
+	req.Body = `{"service":"nope"}`
+	if _, result, _ := finishGRPCCall(t, CallGRPC(context.Background(), req, nil, nil)()); result.Code != "NOT_FOUND" || result.Number != 5 {
+		t.Errorf("got result %+v", result)
+	}
+
+	// A server stream runs until the call is cancelled
+	req.URL = "grpc://" + address + "/grpc.health.v1.Health/Watch"
+	req.Body = `{"service":"svc"}`
+	ctx, cancel := context.WithCancel(context.Background())
+	started := CallGRPC(ctx, req, nil, nil)().(types.GRPCStartedMsg)
+	<-started.Call.Replies()
+	cancel()
+	if _, result, _ := finishGRPCCall(t, started); result.Code != "CANCELLED" {
+		t.Errorf("got result %+v after cancelling", result)
+	}
+
+	for body, want := range map[string]string{`{"bogus":1}`: `unknown field "bogus"`, `{`: "not valid JSON"} {
+		req.URL = "grpc://" + address + "/grpc.health.v1.Health/Check"
+		req.Body = body
+		msg, ok := CallGRPC(context.Background(), req, nil, nil)().(types.ResponseMsg)
+		if !ok || msg.Err == nil || !strings.Contains(msg.Err.Error(), want) {
+			t.Errorf("%s: got %+v", body, msg)
+		}
+	}
+
+	req.URL = "grpc://" + address + "/grpc.health.v1.Health/Nope"
+	if msg, ok := CallGRPC(context.Background(), req, nil, nil)().(types.ResponseMsg); !ok || msg.Err == nil || !strings.Contains(msg.Err.Error(), "not found") {
+		t.Errorf("got %+v for an unknown method", msg)
+	}
+}
+
+func TestCallGRPCWithProtoFiles(t *testing.T) {
+	address := startGRPCServer(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "health.proto"), []byte(`syntax = "proto3";
+package grpc.health.v1;
+message HealthCheckRequest { string service = 1; }
+message HealthCheckResponse {
+  enum ServingStatus { UNKNOWN = 0; SERVING = 1; NOT_SERVING = 2; SERVICE_UNKNOWN = 3; }
+  ServingStatus status = 1;
+}
+service Health { rpc Check(HealthCheckRequest) returns (HealthCheckResponse); }
+`), 0o644)
+
+	req := types.Request{Method: types.GRPCMethod, URL: "grpc://" + address, Timeout: 5}
+	msg := ListGRPCMethods(context.Background(), req, []string{"health.proto"}, []string{dir})().(types.GRPCMethodsMsg)
+	if msg.Err != nil || len(msg.Methods) != 1 || msg.Methods[0].FullName != "/grpc.health.v1.Health/Check" {
+		t.Fatalf("got %+v", msg)
+	}
+
+	req.URL += "/grpc.health.v1.Health/Check"
+	req.Body = `{"service":"svc"}`
+	replies, result, _ := finishGRPCCall(t, CallGRPC(context.Background(), req, []string{"health.proto"}, []string{dir})())
+	if result.Code != "OK" || !reflect.DeepEqual(replies, []string{`{ "status": "SERVING" }`}) {
+		t.Errorf("got %v, %+v", replies, result)
+	}
+}
+
+// containsHeader reports whether headers holds want
+func containsHeader(headers []types.Header, want types.Header) bool {
+	for _, h := range headers {
+		if h == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"postty/src/types"
+)
+
+// HistoryPath returns the location of the persisted history log
+func HistoryPath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// LoadHistory reads the history log and returns at most limit items, most recent first.
+// Lines that cannot be decoded (for example a write cut short by a crash) are skipped
+// and counted in the returned skipped value. When the log held corrupt or surplus
+// entries it is compacted so that later appends start from a clean file.
+func LoadHistory(path string, limit int) (items []types.HistoryItem, skipped int, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []types.HistoryItem{}, 0, nil
+	}
+	if err != nil {
+		return []types.HistoryItem{}, 0, err
+	}
+
+	// The log is stored oldest first
+	var entries []types.HistoryItem
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var item types.HistoryItem
+		if err := json.Unmarshal(line, &item); err != nil {
+			skipped++
+			continue
+		}
+		entries = append(entries, item)
+	}
+
+	needsCompaction := skipped > 0 || (len(data) > 0 && data[len(data)-1] != '\n')
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+		needsCompaction = true
+	}
+
+	items = make([]types.HistoryItem, len(entries))
+	for i, item := range entries {
+		items[len(entries)-1-i] = item
+	}
+
+	if needsCompaction {
+		if err := SaveHistory(path, items); err != nil {
+			return items, skipped, err
+		}
+	}
+
+	return items, skipped, nil
+}
+
+// AppendHistory appends a single item to the history log
+func AppendHistory(path string, item types.HistoryItem) error {
+	line, err := json.Marshal(storedHistoryItem(item))
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SaveHistory atomically rewrites the history log from items, most recent first
+func SaveHistory(path string, items []types.HistoryItem) error {
+	var buf bytes.Buffer
+	for i := len(items) - 1; i >= 0; i-- {
+		line, err := json.Marshal(storedHistoryItem(items[i]))
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return writeFileAtomic(path, buf.Bytes(), 0o600)
+}
+
+// historyTruncatedNote ends a response body cut short in the history log
+const historyTruncatedNote = "\n[response truncated in history]"
+
+// storedHistoryItem returns item as it is written to the log, without its secrets and with
+// its response body cut to types.MaxHistoryBodySize
+func storedHistoryItem(item types.HistoryItem) types.HistoryItem {
+	item.Request, _ = RedactSecrets(item.Request)
+	if len(item.ResponseBody) <= types.MaxHistoryBodySize {
+		return item
+	}
+	cut := types.MaxHistoryBodySize
+	for cut > 0 && !utf8.RuneStart(item.ResponseBody[cut]) {
+		cut--
+	}
+	item.ResponseBody = item.ResponseBody[:cut] + historyTruncatedNote
+	return item
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"postty/src/types"
+)
+
+func TestHistoryAppendAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	for _, url := range []string{"http://a.test/1", "http://a.test/2", "http://a.test/3"} {
+		if err := AppendHistory(path, types.HistoryItem{Request: types.Request{Method: "GET", URL: url}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	items, skipped, err := LoadHistory(path, 2)
+	if err != nil || skipped != 0 {
+		t.Fatalf("LoadHistory: skipped %d, err %v", skipped, err)
+	}
+	if len(items) != 2 || items[0].URL != "http://a.test/3" || items[1].URL != "http://a.test/2" {
+		t.Fatalf("got %+v, want the two newest items, most recent first", items)
+	}
+
+	// Loading past the limit compacts the log
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("log has %d lines after compaction, want 2", lines)
+	}
+}
+
+func TestHistorySkipsCorruptLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	os.WriteFile(path, []byte(`{"method":"GET","url":"http://a.test/"}`+"\n"+`{"method":"GE`), 0o600)
+
+	items, skipped, err := LoadHistory(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || skipped != 1 {
+		t.Fatalf("got %d items and %d skipped, want 1 and 1", len(items), skipped)
+	}
+}
+
+func TestHistoryCapsResponseBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	body := strings.Repeat("é", types.MaxHistoryBodySize)
+	if err := AppendHistory(path, types.HistoryItem{Request: types.Request{URL: "http://a.test/"}, ResponseBody: body}); err != nil {
+		t.Fatal(err)
+	}
+
+	items, _, err := LoadHistory(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := items[0].ResponseBody
+	if !strings.HasSuffix(stored, historyTruncatedNote) {
+		t.Fatalf("stored body does not end with the truncation note")
+	}
+	if kept := strings.TrimSuffix(stored, historyTruncatedNote); len(kept) > types.MaxHistoryBodySize || strings.ContainsRune(kept, '�') {
+		t.Errorf("stored body is %d bytes or splits a character", len(kept))
+	}
+}
+
+func TestHistoryLeavesOutSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	req := types.Request{URL: "http://a.test/", Auth: types.Auth{Type: types.AuthBearer, Token: "s3cr3t"}}
+	if err := AppendHistory(path, types.HistoryItem{Request: req}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "s3cr3t") {
+		t.Errorf("history log holds the token: %s", data)
+	}
+}
@@ -2,50 +2,111 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"postty/src/types"
 )
 
-// ExecuteRequest creates a command to execute an HTTP request
-func ExecuteRequest(method, url, body, contentType string, customHeaders []types.Header) tea.Cmd {
+// ExecuteRequest creates a command to execute an HTTP request. Cancelling ctx aborts the
+// request and request.Timeout, when set, limits it. A text/event-stream response is not
+// read here: it is returned as a StreamStartedMsg, and its timeout only covers the headers.
+func ExecuteRequest(ctx context.Context, request types.Request) tea.Cmd {
 	return func() tea.Msg {
-		var req *http.Request
-		var err error
-
-		if body != "" && (method == "POST" || method == "PUT" || method == "PATCH") {
-			req, err = http.NewRequest(method, url, strings.NewReader(body))
-		} else {
-			req, err = http.NewRequest(method, url, nil)
+		ctx, cancel := context.WithCancelCause(ctx)
+		streaming := false
+		defer func() {
+			if !streaming {
+				cancel(nil)
+			}
+		}()
+		if request.Timeout > 0 {
+			timer := time.AfterFunc(time.Duration(request.Timeout)*time.Second, func() { cancel(context.DeadlineExceeded) })
+			defer timer.Stop()
 		}
 
+		body, contentType, err := RequestBody(request)
 		if err != nil {
-			return types.ResponseMsg{Err: err}
+			return types.ResponseMsg{Err: err, Outcome: types.OutcomeError}
 		}
 
-		req.Header.Set("Content-Type", contentType)
-
-		for _, header := range customHeaders {
-			if header.Key != "" && header.Value != "" {
-				req.Header.Set(header.Key, header.Value)
-			}
+		if !methodHasBody(request.Method) {
+			body = ""
+		}
+		req, err := newHTTPRequest(ctx, request, body, contentType)
+		if err != nil {
+			return types.ResponseMsg{Err: err, Outcome: types.OutcomeError}
 		}
 
-		client := &http.Client{}
+		client, err := newHTTPClient(request)
+		if err != nil {
+			return types.ResponseMsg{Err: err, Outcome: types.OutcomeError}
+		}
+		start := time.Now()
+		redirects := newRedirectChain(request.Redirects, start)
+		client.CheckRedirect = redirects.check
 		resp, err := client.Do(req)
+		if err == nil && request.Auth.Type == types.AuthDigest {
+			resp, err = answerDigestChallenge(ctx, client, resp, request, body, contentType)
+		}
 		if err != nil {
-			return types.ResponseMsg{Err: err}
+			return types.ResponseMsg{Err: err, Outcome: errorOutcome(ctx, err)}
+		}
+
+		if isEventStream(resp.Header.Get("Content-Type")) && resp.StatusCode < 300 {
+			// The stream lives on after this command; cancelling the caller's ctx stops it
+			streaming = true
+			return types.StreamStartedMsg{
+				Stream:     newEventStream(resp.Body),
+				StatusCode: resp.StatusCode,
+				Headers:    headerList(resp.Header),
+				Meta: types.ResponseMeta{
+					Status:        resp.Status,
+					Proto:         resp.Proto,
+					Duration:      time.Since(start),
+					ContentLength: resp.ContentLength,
+					TLS:           tlsInfo(resp.TLS),
+					Proxy:         usedProxy(request.Proxy, resp.Request.URL),
+					URL:           redirects.finalURL(resp),
+					Redirects:     redirects.hops,
+				},
+			}
 		}
-		defer resp.Body.Close()
 
 		bodyBytes, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
 		if err != nil {
-			return types.ResponseMsg{Err: err}
+			return types.ResponseMsg{Err: err, Outcome: errorOutcome(ctx, err)}
+		}
+
+		meta := types.ResponseMeta{
+			Status:          resp.Status,
+			Proto:           resp.Proto,
+			Duration:        time.Since(start),
+			BodySize:        int64(len(bodyBytes)),
+			ContentLength:   resp.ContentLength,
+			ContentEncoding: resp.Header.Get("Content-Encoding"),
+			Decompressed:    resp.Uncompressed,
+			TLS:             tlsInfo(resp.TLS),
+			Proxy:           usedProxy(request.Proxy, resp.Request.URL),
+			URL:             redirects.finalURL(resp),
+			Redirects:       redirects.hops,
+		}
+		if resp.Uncompressed {
+			// The transport strips Content-Encoding after decoding gzip for us
+			meta.ContentEncoding = "gzip"
 		}
 
 		var prettyJSON bytes.Buffer
@@ -58,6 +119,164 @@ func ExecuteRequest(method, url, body, contentType string, customHeaders []types
 		return types.ResponseMsg{
 			Body:       string(bodyBytes),
 			StatusCode: resp.StatusCode,
+			Headers:    headerList(resp.Header),
+			Meta:       meta,
+		}
+	}
+}
+
+// newHTTPClient returns the client a request is sent with, set up with its TLS and proxy
+// settings and the session's cookie jar
+func newHTTPClient(request types.Request) (*http.Client, error) {
+	transport, err := httpTransport(request)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport}
+	if !request.NoCookies {
+		client.Jar = sessionCookies
+	}
+	return client, nil
+}
+
+// cachedTransport is a transport kept for the requests sent with the same TLS and proxy
+// settings, along with the modification times of the files its TLS settings name
+type cachedTransport struct {
+	transport *http.Transport
+	files     string
+}
+
+// httpTransports holds a transport per TLS and proxy settings, so that requests reuse their
+// connections
+var httpTransports = struct {
+	sync.Mutex
+	transports map[string]cachedTransport
+}{transports: map[string]cachedTransport{}}
+
+// httpTransport returns the transport for the TLS and proxy settings of request. It is set
+// up the first time the settings are used, and again once a file they name has changed.
+func httpTransport(request types.Request) (*http.Transport, error) {
+	key, err := json.Marshal(struct {
+		TLS   types.TLSSettings
+		Proxy types.ProxySettings
+	}{request.TLS, request.Proxy})
+	if err != nil {
+		return nil, err
+	}
+	files := tlsFilesModified(request.TLS)
+
+	httpTransports.Lock()
+	defer httpTransports.Unlock()
+	cached, ok := httpTransports.transports[string(key)]
+	if ok && cached.files == files {
+		return cached.transport, nil
+	}
+
+	config, err := TLSConfig(request.TLS)
+	if err != nil {
+		return nil, err
+	}
+	choose, err := proxyFunc(request.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	transport.Proxy = func(req *http.Request) (*url.URL, error) { return choose(req.URL) }
+	if ok {
+		cached.transport.CloseIdleConnections()
+	}
+	httpTransports.transports[string(key)] = cachedTransport{transport: transport, files: files}
+	return transport, nil
+}
+
+// tlsFilesModified lists when the certificate, key and CA files of settings were last
+// changed; a file that cannot be read is left for TLSConfig to report
+func tlsFilesModified(settings types.TLSSettings) string {
+	var times []string
+	for _, path := range append([]string{settings.CertFile, settings.KeyFile}, settings.CAFiles...) {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			times = append(times, info.ModTime().String())
+		}
+	}
+	return strings.Join(times, "\n")
+}
+
+// newHTTPRequest builds the request to send, with its headers and credentials
+func newHTTPRequest(ctx context.Context, request types.Request, body, contentType string) (*http.Request, error) {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, request.Method, request.URL, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	for _, header := range request.Headers {
+		if header.Key != "" && header.Value != "" {
+			req.Header.Set(header.Key, header.Value)
+		}
+	}
+
+	if err := applyAuth(req, request, body); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// RequestBody returns the body a request is sent with and its Content-Type. Form content
+// types with fields are encoded from the fields, GraphQL requests are composed into their
+// JSON payload and everything else sends Body as typed.
+func RequestBody(req types.Request) (string, string, error) {
+	if IsFormContentType(req.ContentType) && len(req.Form) > 0 {
+		return EncodeForm(req.Form, req.ContentType)
+	}
+	if req.ContentType == GraphQLContentType {
+		body, err := GraphQLPayload(req)
+		return body, sentContentType(req.ContentType), err
+	}
+	return req.Body, req.ContentType, nil
+}
+
+// methodHasBody reports whether requests with method carry a body
+func methodHasBody(method string) bool {
+	return method == "POST" || method == "PUT" || method == "PATCH"
+}
+
+// errorOutcome tells a cancelled or timed out request apart from other failures
+func errorOutcome(ctx context.Context, err error) types.RequestOutcome {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(context.Cause(ctx), context.DeadlineExceeded):
+		return types.OutcomeTimedOut
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+		return types.OutcomeCancelled
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return types.OutcomeTimedOut
+	default:
+		return types.OutcomeError
+	}
+}
+
+// headerList flattens an http.Header into key/value pairs sorted by key
+func headerList(h http.Header) []types.Header {
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	headers := []types.Header{}
+	for _, key := range keys {
+		for _, value := range h[key] {
+			headers = append(headers, types.Header{Key: key, Value: value})
 		}
 	}
+	return headers
 }
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"postty/src/types"
+)
+
+func TestExecuteRequestReusesConnections(t *testing.T) {
+	var conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	for range 3 {
+		msg := ExecuteRequest(context.Background(), types.Request{Method: "GET", URL: server.URL, NoCookies: true})().(types.ResponseMsg)
+		if msg.Err != nil || msg.Body != "ok" {
+			t.Fatalf("got %q, %v", msg.Body, msg.Err)
+		}
+	}
+	if conns != 1 {
+		t.Errorf("3 requests opened %d connections, want 1", conns)
+	}
+}
+
+func TestHTTPTransportPerSettings(t *testing.T) {
+	plain, err := httpTransport(types.Request{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := httpTransport(types.Request{}); again != plain {
+		t.Error("the same settings got a new transport")
+	}
+	if insecure, _ := httpTransport(types.Request{TLS: types.TLSSettings{Insecure: true}}); insecure == plain {
+		t.Error("other TLS settings got the same transport")
+	}
+
+	// Changing a CA file sets up the transport again
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600)
+	request := types.Request{TLS: types.TLSSettings{CAFiles: []string{ca}}}
+	first, err := httpTransport(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(ca, time.Now(), time.Now().Add(time.Minute))
+	if second, _ := httpTransport(request); second == first {
+		t.Error("the transport was kept after its CA file changed")
+	}
+}
+
+func TestExecuteRequestMeta(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(50 * time.Millisecond)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Length", "7")
+			w.Write([]byte(`{"a":1}`))
+		case "/gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(w)
+			zw.Write([]byte("hello hello hello"))
+			zw.Close()
+		case "/chunked":
+			w.Write([]byte("one,"))
+			w.(http.Flusher).Flush()
+			w.Write([]byte("two"))
+		case "/hop":
+			time.Sleep(20 * time.Millisecond)
+			http.Redirect(w, r, "/slow", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+	send := func(path string) types.ResponseMsg {
+		t.Helper()
+		msg := ExecuteRequest(context.Background(), types.Request{Method: "GET", URL: server.URL + path, NoCookies: true})().(types.ResponseMsg)
+		if msg.Err != nil {
+			t.Fatal(msg.Err)
+		}
+		return msg
+	}
+
+	// The size is that of the body as received, before it is indented. The final URL is
+	// only set when redirects were followed.
+	meta := send("/slow").Meta
+	if meta.Status != "200 OK" || meta.Proto != "HTTP/1.1" || meta.BodySize != 7 || meta.ContentLength != 7 || meta.ContentEncoding != "" || meta.Decompressed {
+		t.Errorf("got %+v", meta)
+	}
+	if meta.Duration < 50*time.Millisecond || meta.URL != "" || len(meta.Redirects) != 0 {
+		t.Errorf("got time %v, URL %s, redirects %+v", meta.Duration, meta.URL, meta.Redirects)
+	}
+
+	if meta := send("/gzip").Meta; meta.ContentEncoding != "gzip" || !meta.Decompressed || meta.BodySize != 17 || meta.ContentLength != -1 {
+		t.Errorf("got %+v for a gzipped body", meta)
+	}
+	if meta := send("/chunked").Meta; meta.BodySize != 7 || meta.ContentLength != -1 {
+		t.Errorf("got %+v for a chunked body", meta)
+	}
+
+	// Each redirect is timed on its own and the total covers the whole chain
+	meta = send("/hop").Meta
+	if meta.URL != server.URL+"/slow" || len(meta.Redirects) != 1 {
+		t.Fatalf("got URL %s, redirects %+v", meta.URL, meta.Redirects)
+	}
+	hop := meta.Redirects[0]
+	if hop.Method != "GET" || hop.URL != server.URL+"/hop" || hop.StatusCode != 302 || hop.Status != "302 Found" || hop.Location != server.URL+"/slow" {
+		t.Errorf("got redirect %+v", hop)
+	}
+	if hop.Duration < 20*time.Millisecond || meta.Duration < hop.Duration+50*time.Millisecond {
+		t.Errorf("got redirect time %v and total %v", hop.Duration, meta.Duration)
+	}
+}
+
+// slowServer sends the headers of each response at once and its body when released
+func slowServer(release <-chan struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+			w.Write([]byte("late"))
+		case <-r.Context().Done():
+		}
+	}))
+}
+
+func TestExecuteRequestTimeoutAndCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := slowServer(release)
+	defer server.Close()
+	defer close(release)
+	req := types.Request{Method: "GET", URL: server.URL, NoCookies: true}
+
+	// The body that would arrive after the timeout is not reported
+	timed := req
+	timed.Timeout = 1
+	start := time.Now()
+	msg := ExecuteRequest(context.Background(), timed)().(types.ResponseMsg)
+	if msg.Outcome != types.OutcomeTimedOut || msg.Err == nil || msg.Body != "" || msg.StatusCode != 0 {
+		t.Errorf("got %+v, want a timeout", msg)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 3*time.Second {
+		t.Errorf("timed out after %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	msg = ExecuteRequest(ctx, req)().(types.ResponseMsg)
+	if msg.Outcome != types.OutcomeCancelled || msg.Err == nil || msg.Body != "" || msg.StatusCode != 0 {
+		t.Errorf("got %+v, want a cancelled request", msg)
+	}
+
+	// A request cancelled before it is sent never reaches the server
+	cancel()
+	if msg := ExecuteRequest(ctx, req)().(types.ResponseMsg); msg.Outcome != types.OutcomeCancelled {
+		t.Errorf("got %+v for a request cancelled up front", msg)
+	}
+
+	// Other failures are plain errors
+	if msg := ExecuteRequest(context.Background(), types.Request{Method: "GET", URL: "http://127.0.0.1:1/", NoCookies: true})().(types.ResponseMsg); msg.Outcome != types.OutcomeError {
+		t.Errorf("got %+v for a refused connection", msg)
+	}
+}
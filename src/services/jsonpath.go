@@ -0,0 +1,174 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep is one step of a JSONPath: a field name, an array index or a wildcard
+type jsonPathStep struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// EvaluateJSONPath returns the value at path in a decoded JSON document. Paths start with $
+// and use .name, ['name'], [index] (negative indexes count from the end) and [*] or .* for
+// every element; a path with a wildcard returns the list of values it reaches.
+func EvaluateJSONPath(document any, path string) (any, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []any{document}
+	wildcard := false
+	for _, step := range steps {
+		var next []any
+		for _, node := range nodes {
+			switch {
+			case step.wildcard:
+				switch value := node.(type) {
+				case []any:
+					next = append(next, value...)
+				case map[string]any:
+					for _, key := range sortedKeys(value) {
+						next = append(next, value[key])
+					}
+				}
+			case step.isIndex:
+				list, ok := node.([]any)
+				if !ok {
+					if wildcard {
+						continue
+					}
+					return nil, fmt.Errorf("%s: [%d] applied to %s", path, step.index, jsonKind(node))
+				}
+				i := step.index
+				if i < 0 {
+					i += len(list)
+				}
+				if i < 0 || i >= len(list) {
+					if wildcard {
+						continue
+					}
+					return nil, fmt.Errorf("%s: index %d out of range, array has %d elements", path, step.index, len(list))
+				}
+				next = append(next, list[i])
+			default:
+				object, ok := node.(map[string]any)
+				if !ok {
+					if wildcard {
+						continue
+					}
+					return nil, fmt.Errorf("%s: .%s applied to %s", path, step.name, jsonKind(node))
+				}
+				value, ok := object[step.name]
+				if !ok {
+					if wildcard {
+						continue
+					}
+					return nil, fmt.Errorf("%s: no field %q", path, step.name)
+				}
+				next = append(next, value)
+			}
+		}
+		nodes = next
+		wildcard = wildcard || step.wildcard
+	}
+
+	if wildcard {
+		if nodes == nil {
+			nodes = []any{}
+		}
+		return nodes, nil
+	}
+	return nodes[0], nil
+}
+
+// parseJSONPath splits a JSONPath into its steps
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(path), "$")
+	if !ok {
+		return nil, fmt.Errorf("JSONPath %q must start with $", path)
+	}
+
+	var steps []jsonPathStep
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".*"):
+			steps = append(steps, jsonPathStep{wildcard: true})
+			rest = rest[2:]
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" {
+				return nil, fmt.Errorf("JSONPath %q has an empty field name", path)
+			}
+			steps = append(steps, jsonPathStep{name: name})
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if quote := rest[1:min(2, len(rest))]; quote == "'" || quote == `"` {
+				end = strings.Index(rest[2:], quote+"]")
+				if end >= 0 {
+					end += 3
+				}
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath %q has an unclosed [", path)
+			}
+			inner := rest[1:end]
+			switch {
+			case inner == "*":
+				steps = append(steps, jsonPathStep{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"'):
+				steps = append(steps, jsonPathStep{name: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(strings.TrimSpace(inner))
+				if err != nil {
+					return nil, fmt.Errorf("JSONPath %q: [%s] is not an index, quote field names as ['name']", path, inner)
+				}
+				steps = append(steps, jsonPathStep{index: index, isIndex: true})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("JSONPath %q: expected . or [ at %q", path, rest)
+		}
+	}
+	return steps, nil
+}
+
+// jsonKind names the JSON type of a decoded value for error messages
+func jsonKind(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return "null"
+	}
+}
+
+// sortedKeys returns the keys of an object in a stable order
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEvaluateJSONPath(t *testing.T) {
+	var document any
+	json.Unmarshal([]byte(`{"a":{"b":[1,{"c":"x"},3]},"we ird":true,"list":[{"id":1},{"id":2},{"name":"n"}]}`), &document)
+
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"$.a.b[1].c", "x"},
+		{"$.a.b[-1]", 3.0},
+		{"$['we ird']", true},
+		{`$["a"].b[0]`, 1.0},
+		{"$.list[*].id", []any{1.0, 2.0}},
+		{"$.a.*", []any{[]any{1.0, map[string]any{"c": "x"}, 3.0}}},
+		{"$.a.b[*].missing", []any{}},
+	}
+	for _, tt := range tests {
+		got, err := EvaluateJSONPath(document, tt.path)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, %v, want %v", tt.path, got, err, tt.want)
+		}
+	}
+
+	errors := map[string]string{
+		"$.a.x":    `no field "x"`,
+		"$.a.b[9]": "out of range",
+		"$.a.b.c":  ".c applied to an array",
+		"a.b":      "must start with $",
+		"$.a[":     "unclosed [",
+		"$.a[x]":   "not an index",
+		"$..a":     "empty field name",
+	}
+	for path, want := range errors {
+		if _, err := EvaluateJSONPath(document, path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v, want an error with %q", path, err, want)
+		}
+	}
+}
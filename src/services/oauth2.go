@@ -0,0 +1,447 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/types"
+)
+
+// OAuth2 grants
+const (
+	OAuth2ClientCredentials = "client_credentials"
+	OAuth2Password          = "password"
+	OAuth2AuthorizationCode = "authorization_code"
+)
+
+// oauth2RefreshMargin is how long before it expires a cached token is replaced
+const oauth2RefreshMargin = 30 * time.Second
+
+// oauth2LoginTimeout limits how long a sign-in waits for the browser
+const oauth2LoginTimeout = 5 * time.Minute
+
+// oauth2Tokens caches tokens for the session, by the settings they were fetched with
+var oauth2Tokens = struct {
+	sync.Mutex
+	tokens map[string]types.OAuth2Token
+}{tokens: map[string]types.OAuth2Token{}}
+
+// oauth2Login implements types.OAuth2Login with a loopback HTTP listener
+type oauth2Login struct {
+	request     types.Request // Carries the auth and the settings the token endpoint is reached with
+	authURL     string
+	redirectURI string
+	verifier    string
+	server      *http.Server
+	codes       chan oauth2Callback
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+// oauth2Callback is what the browser brought back to the redirect
+type oauth2Callback struct {
+	code string
+	err  error
+}
+
+// OAuth2Token returns a token for the auth of request: the cached one while it is valid,
+// otherwise one refreshed with its refresh token or fetched again, through the TLS and proxy
+// settings of request. Tokens of the authorization code grant are only fetched by signing in
+// with StartOAuth2Login.
+func OAuth2Token(ctx context.Context, request types.Request) (types.OAuth2Token, error) {
+	auth := request.Auth
+	key := oauth2CacheKey(auth)
+	oauth2Tokens.Lock()
+	cached, ok := oauth2Tokens.tokens[key]
+	oauth2Tokens.Unlock()
+
+	if ok && (cached.Expiry.IsZero() || time.Until(cached.Expiry) > oauth2RefreshMargin) {
+		return cached, nil
+	}
+	if ok && cached.RefreshToken != "" {
+		token, err := requestOAuth2Token(ctx, request, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {cached.RefreshToken},
+		})
+		if err == nil {
+			if token.RefreshToken == "" {
+				token.RefreshToken = cached.RefreshToken
+			}
+			storeOAuth2Token(auth, token)
+			return token, nil
+		}
+		if auth.Grant == OAuth2AuthorizationCode {
+			return types.OAuth2Token{}, fmt.Errorf("%w; sign in again", err)
+		}
+	}
+	if auth.Grant == OAuth2AuthorizationCode {
+		return types.OAuth2Token{}, errors.New("oauth2: no token yet; sign in to get one for the authorization code grant")
+	}
+	return fetchOAuth2Token(ctx, request)
+}
+
+// FetchOAuth2Token creates a command that fetches a new token for the auth of request with
+// the client credentials or password grant, replacing the cached one
+func FetchOAuth2Token(request types.Request) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(types.DefaultRequestTimeout)*time.Second)
+		defer cancel()
+		token, err := fetchOAuth2Token(ctx, request)
+		return types.OAuth2TokenMsg{Token: token, Err: err}
+	}
+}
+
+// fetchOAuth2Token fetches a token with the client credentials or password grant and caches it
+func fetchOAuth2Token(ctx context.Context, request types.Request) (types.OAuth2Token, error) {
+	auth := request.Auth
+	form := url.Values{}
+	switch auth.Grant {
+	case "", OAuth2ClientCredentials:
+		form.Set("grant_type", OAuth2ClientCredentials)
+	case OAuth2Password:
+		form.Set("grant_type", OAuth2Password)
+		form.Set("username", auth.Username)
+		form.Set("password", auth.Password)
+	default:
+		return types.OAuth2Token{}, fmt.Errorf("oauth2: unknown grant %q", auth.Grant)
+	}
+	if auth.Scope != "" {
+		form.Set("scope", auth.Scope)
+	}
+
+	token, err := requestOAuth2Token(ctx, request, form)
+	if err != nil {
+		return token, err
+	}
+	storeOAuth2Token(auth, token)
+	return token, nil
+}
+
+// requestOAuth2Token posts form to the token endpoint of the auth of request and reads the
+// token in its answer. A client with a secret authenticates with Basic auth, a public one
+// sends its ID.
+func requestOAuth2Token(ctx context.Context, request types.Request, form url.Values) (types.OAuth2Token, error) {
+	auth := request.Auth
+	var token types.OAuth2Token
+	if auth.TokenURL == "" {
+		return token, errors.New("oauth2: the token URL is empty")
+	}
+	if auth.ClientSecret == "" {
+		form.Set("client_id", auth.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return token, fmt.Errorf("oauth2: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if auth.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+	}
+
+	client, err := newHTTPClient(request)
+	if err != nil {
+		return token, fmt.Errorf("oauth2: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return token, fmt.Errorf("oauth2: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return token, fmt.Errorf("oauth2: %w", err)
+	}
+
+	fields := tokenResponseFields(resp.Header.Get("Content-Type"), body)
+	if code := fields["error"]; code != "" {
+		if description := fields["error_description"]; description != "" {
+			return token, fmt.Errorf("oauth2: %s: %s", code, description)
+		}
+		return token, fmt.Errorf("oauth2: %s", code)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return token, fmt.Errorf("oauth2: the token endpoint answered %s", resp.Status)
+	}
+	if fields["access_token"] == "" {
+		return token, errors.New("oauth2: the token endpoint answered without an access_token")
+	}
+
+	token = types.OAuth2Token{
+		AccessToken:  fields["access_token"],
+		TokenType:    fields["token_type"],
+		RefreshToken: fields["refresh_token"],
+		Scope:        fields["scope"],
+	}
+	if seconds, err := strconv.Atoi(fields["expires_in"]); err == nil && seconds > 0 {
+		token.Expiry = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return token, nil
+}
+
+// tokenResponseFields reads a token endpoint answer, which is JSON or, from some servers,
+// form encoded
+func tokenResponseFields(contentType string, body []byte) map[string]string {
+	fields := map[string]string{}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" || mediaType == "text/plain" {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			for name := range values {
+				fields[name] = values.Get(name)
+			}
+			return fields
+		}
+	}
+
+	var document map[string]any
+	if err := json.Unmarshal(body, &document); err != nil {
+		return fields
+	}
+	for name, value := range document {
+		switch value := value.(type) {
+		case string:
+			fields[name] = value
+		case float64:
+			fields[name] = strconv.FormatFloat(value, 'f', -1, 64)
+		}
+	}
+	return fields
+}
+
+// oauth2CacheKey tells apart the tokens of different endpoints, clients, users and scopes,
+// and of different credentials, which it holds as a hash
+func oauth2CacheKey(auth types.Auth) string {
+	grant := auth.Grant
+	if grant == "" {
+		grant = OAuth2ClientCredentials
+	}
+	credentials := sha256.Sum256([]byte(auth.ClientSecret + "\x00" + auth.Password))
+	return strings.Join([]string{auth.TokenURL, grant, auth.ClientID, auth.Username, auth.Scope, hex.EncodeToString(credentials[:])}, "\x00")
+}
+
+// storeOAuth2Token caches token for the requests that use auth
+func storeOAuth2Token(auth types.Auth, token types.OAuth2Token) {
+	oauth2Tokens.Lock()
+	oauth2Tokens.tokens[oauth2CacheKey(auth)] = token
+	oauth2Tokens.Unlock()
+}
+
+// tokenType returns the scheme a token is sent with in the Authorization header
+func tokenType(token types.OAuth2Token) string {
+	if token.TokenType == "" || strings.EqualFold(token.TokenType, "bearer") {
+		return "Bearer"
+	}
+	return token.TokenType
+}
+
+// StartOAuth2Login starts an authorization code sign-in with PKCE for the auth of request: it
+// listens on the loopback redirect of the auth, by default any free port of 127.0.0.1, and
+// returns the login whose URL the user signs in at. The code is exchanged through the TLS and
+// proxy settings of request. The login ends when its Wait returns or it is closed.
+func StartOAuth2Login(request types.Request) (types.OAuth2Login, error) {
+	auth := request.Auth
+	if auth.AuthURL == "" {
+		return nil, errors.New("oauth2: the auth URL is empty")
+	}
+	if auth.TokenURL == "" {
+		return nil, errors.New("oauth2: the token URL is empty")
+	}
+	authURL, err := url.Parse(auth.AuthURL)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: auth URL: %w", err)
+	}
+
+	redirect := auth.RedirectURL
+	if redirect == "" {
+		redirect = "http://127.0.0.1:0/callback"
+	}
+	redirectURL, err := url.Parse(redirect)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: redirect: %w", err)
+	}
+	host := redirectURL.Hostname()
+	if redirectURL.Scheme != "http" || (host != "127.0.0.1" && host != "localhost" && host != "::1") {
+		return nil, fmt.Errorf("oauth2: the redirect must be an http:// loopback address, such as http://127.0.0.1:8765/callback")
+	}
+	port := redirectURL.Port()
+	if port == "" {
+		port = "0"
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: redirect: %w", err)
+	}
+	redirectURL.Host = net.JoinHostPort(host, strconv.Itoa(listener.Addr().(*net.TCPAddr).Port))
+	if redirectURL.Path == "" {
+		redirectURL.Path = "/"
+	}
+
+	verifier, err := randomBase64URL(32)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	state, err := randomHex(16)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", auth.ClientID)
+	query.Set("redirect_uri", redirectURL.String())
+	query.Set("state", state)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	if auth.Scope != "" {
+		query.Set("scope", auth.Scope)
+	}
+	authURL.RawQuery = query.Encode()
+
+	login := &oauth2Login{
+		request:     request,
+		authURL:     authURL.String(),
+		redirectURI: redirectURL.String(),
+		verifier:    verifier,
+		codes:       make(chan oauth2Callback, 1),
+		closed:      make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(redirectURL.Path, func(w http.ResponseWriter, r *http.Request) {
+		callback := oauth2Callback{code: r.URL.Query().Get("code")}
+		switch {
+		case r.URL.Query().Get("state") != state:
+			callback.err = errors.New("oauth2: the sign-in came back with the wrong state")
+		case r.URL.Query().Get("error") != "":
+			callback.err = fmt.Errorf("oauth2: %s", strings.TrimSpace(r.URL.Query().Get("error")+": "+r.URL.Query().Get("error_description")))
+		case callback.code == "":
+			callback.err = errors.New("oauth2: the sign-in came back without a code")
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if callback.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Sign-in failed: %v\n", callback.err)
+		} else {
+			fmt.Fprintln(w, "Signed in. You can close this tab and return to postty.")
+		}
+		select {
+		case login.codes <- callback:
+		default:
+		}
+	})
+	login.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go login.server.Serve(listener)
+	return login, nil
+}
+
+// URL implements types.OAuth2Login
+func (l *oauth2Login) URL() string {
+	return l.authURL
+}
+
+// Wait implements types.OAuth2Login: it waits for the browser to bring back the code,
+// exchanges it for a token and caches the token
+func (l *oauth2Login) Wait(ctx context.Context) (types.OAuth2Token, error) {
+	defer l.Close()
+
+	var callback oauth2Callback
+	select {
+	case callback = <-l.codes:
+	case <-l.closed:
+		return types.OAuth2Token{}, errors.New("oauth2: sign-in cancelled")
+	case <-ctx.Done():
+		return types.OAuth2Token{}, fmt.Errorf("oauth2: sign-in: %w", ctx.Err())
+	}
+	if callback.err != nil {
+		return types.OAuth2Token{}, callback.err
+	}
+
+	form := url.Values{
+		"grant_type":    {OAuth2AuthorizationCode},
+		"code":          {callback.code},
+		"redirect_uri":  {l.redirectURI},
+		"code_verifier": {l.verifier},
+	}
+	token, err := requestOAuth2Token(ctx, l.request, form)
+	if err != nil {
+		return token, err
+	}
+	storeOAuth2Token(l.request.Auth, token)
+	return token, nil
+}
+
+// Close implements types.OAuth2Login
+func (l *oauth2Login) Close() {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+		// Let the browser receive its page before the listener goes away
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			l.server.Shutdown(ctx)
+		}()
+	})
+}
+
+// WaitOAuth2Login creates a command that opens the sign-in page of login in the browser and
+// delivers the token once the user has signed in
+func WaitOAuth2Login(login types.OAuth2Login) tea.Cmd {
+	return func() tea.Msg {
+		openBrowser(login.URL())
+		ctx, cancel := context.WithTimeout(context.Background(), oauth2LoginTimeout)
+		defer cancel()
+		token, err := login.Wait(ctx)
+		return types.OAuth2TokenMsg{Login: login, Token: token, Err: err}
+	}
+}
+
+// openBrowser opens url with the desktop's default browser
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// randomBase64URL returns n random bytes in unpadded base64url, as PKCE verifiers are written
+func randomBase64URL(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"postty/src/types"
+)
+
+// newTokenServer answers client credentials grants for the client secret "s3", counting calls
+func newTokenServer(newServer func(http.Handler) *httptest.Server, calls *int32) *httptest.Server {
+	return newServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if _, secret, _ := r.BasicAuth(); secret != "s3" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"invalid_client","error_description":"bad secret"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token":"cc-%d","token_type":"bearer","expires_in":3600}`, n)
+	}))
+}
+
+func TestOAuth2TokenCache(t *testing.T) {
+	var calls int32
+	tokens := newTokenServer(httptest.NewServer, &calls)
+	defer tokens.Close()
+
+	request := types.Request{Auth: types.Auth{Type: types.AuthOAuth2, TokenURL: tokens.URL, ClientID: "app", ClientSecret: "s3"}}
+	for range 2 {
+		token, err := OAuth2Token(context.Background(), request)
+		if err != nil || token.AccessToken != "cc-1" {
+			t.Fatalf("got %+v, %v", token, err)
+		}
+	}
+
+	// Other credentials do not get the token cached for the right ones
+	request.Auth.ClientSecret = "wrong"
+	if _, err := OAuth2Token(context.Background(), request); err == nil {
+		t.Error("expected the token endpoint to turn down the wrong secret")
+	}
+	if calls != 2 {
+		t.Errorf("token endpoint called %d times, want 2", calls)
+	}
+}
+
+func TestOAuth2TokenEndpointTLS(t *testing.T) {
+	var calls int32
+	tokens := newTokenServer(httptest.NewTLSServer, &calls)
+	defer tokens.Close()
+
+	request := types.Request{Auth: types.Auth{Type: types.AuthOAuth2, TokenURL: tokens.URL, ClientID: "app", ClientSecret: "s3"}}
+	if _, err := OAuth2Token(context.Background(), request); err == nil {
+		t.Fatal("expected the server's certificate to be rejected")
+	}
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tokens.Certificate().Raw}), 0o600)
+	request.TLS.CAFiles = []string{ca}
+	if token, err := OAuth2Token(context.Background(), request); err != nil || token.AccessToken == "" {
+		t.Errorf("got %+v, %v with the server's CA trusted", token, err)
+	}
+}
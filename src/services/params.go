@@ -0,0 +1,145 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"postty/src/types"
+)
+
+// splitURLQuery splits a URL into the part before the query, the raw query and the fragment
+// (including its '#'). The query is empty when the URL has none.
+func splitURLQuery(rawURL string) (base, query, fragment string) {
+	base = rawURL
+	if i := strings.IndexByte(base, '#'); i >= 0 {
+		base, fragment = base[:i], base[i:]
+	}
+	base, query, _ = strings.Cut(base, "?")
+	return base, query, fragment
+}
+
+// ParseQueryParams returns the query parameters of rawURL, decoded and in order.
+// Components that are not valid percent-encoding are kept as typed.
+func ParseQueryParams(rawURL string) []types.QueryParam {
+	_, query, _ := splitURLQuery(rawURL)
+	if query == "" {
+		return nil
+	}
+
+	var params []types.QueryParam
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+		params = append(params, parseQueryPair(pair))
+	}
+	return params
+}
+
+// parseQueryPair decodes a key=value pair of a query. A pair that cannot be decoded
+// keeps its raw text, so it is written back to the URL unchanged.
+func parseQueryPair(pair string) types.QueryParam {
+	key, value, equals := strings.Cut(pair, "=")
+	param := types.QueryParam{Key: unescapeQueryComponent(key), Value: unescapeQueryComponent(value), Equals: equals && value == ""}
+	if _, err := url.QueryUnescape(key); err != nil {
+		param.Raw = pair
+	} else if _, err := url.QueryUnescape(value); err != nil {
+		param.Raw = pair
+	}
+	return param
+}
+
+// SetQueryParams replaces the query of rawURL with the enabled params, keeping the
+// rest of the URL, including any fragment, as it is. Pairs that could not be decoded
+// are written as they were typed until they are edited.
+func SetQueryParams(rawURL string, params []types.QueryParam) string {
+	base, _, fragment := splitURLQuery(rawURL)
+
+	var pairs []string
+	for _, p := range params {
+		if p.Disabled || (p.Key == "" && p.Value == "") {
+			continue
+		}
+		if p.Raw != "" {
+			if raw := parseQueryPair(p.Raw); raw.Key == p.Key && raw.Value == p.Value && raw.Equals == p.Equals {
+				pairs = append(pairs, p.Raw)
+				continue
+			}
+		}
+		pair := escapeQueryComponent(p.Key)
+		if p.Value != "" || p.Equals {
+			pair += "=" + escapeQueryComponent(p.Value)
+		}
+		pairs = append(pairs, pair)
+	}
+
+	if len(pairs) == 0 {
+		return base + fragment
+	}
+	return base + "?" + strings.Join(pairs, "&") + fragment
+}
+
+// MergeQueryParams combines the params parsed from a freshly edited URL with the
+// current list: disabled params keep their place and enabled ones are replaced in order
+func MergeQueryParams(current, parsed []types.QueryParam) []types.QueryParam {
+	var merged []types.QueryParam
+	next := 0
+	for _, p := range current {
+		if p.Disabled {
+			merged = append(merged, p)
+			continue
+		}
+		if next < len(parsed) {
+			merged = append(merged, parsed[next])
+			next++
+		}
+	}
+	return append(merged, parsed[next:]...)
+}
+
+// HasDisabledParams reports whether any param is toggled off
+func HasDisabledParams(params []types.QueryParam) bool {
+	for _, p := range params {
+		if p.Disabled {
+			return true
+		}
+	}
+	return false
+}
+
+// unescapeQueryComponent decodes a key or value, treating '+' as a space
+func unescapeQueryComponent(s string) string {
+	decoded, err := url.QueryUnescape(s)
+	if err != nil {
+		return s
+	}
+	return decoded
+}
+
+// escapeQueryComponent percent-encodes a key or value for the query string. Characters
+// that are legal in a query are left readable and {{variables}} are kept intact.
+func escapeQueryComponent(s string) string {
+	var b strings.Builder
+	for len(s) > 0 {
+		if strings.HasPrefix(s, "{{") {
+			if end := strings.Index(s, "}}"); end >= 0 {
+				b.WriteString(s[:end+2])
+				s = s[end+2:]
+				continue
+			}
+		}
+
+		c := s[0]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', strings.IndexByte("-._~!$'()*,/:;@?", c) >= 0:
+			b.WriteByte(c)
+		case c == ' ':
+			b.WriteByte('+')
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+		s = s[1:]
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"postty/src/types"
+)
+
+func TestParseQueryParams(t *testing.T) {
+	got := ParseQueryParams("https://x.test/s?q=hello+world&tag=a%2Fb&flag&empty=&bad=%zz#q=frag")
+	want := []types.QueryParam{
+		{Key: "q", Value: "hello world"},
+		{Key: "tag", Value: "a/b"},
+		{Key: "flag"},
+		{Key: "empty", Equals: true},
+		{Key: "bad", Value: "%zz", Raw: "bad=%zz"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v", got)
+	}
+	if got := ParseQueryParams("https://x.test/s#a?b"); got != nil {
+		t.Errorf("got %+v for a URL without a query", got)
+	}
+}
+
+func TestSetQueryParams(t *testing.T) {
+	params := []types.QueryParam{
+		{Key: "name", Value: "jo & co"},
+		{Key: "id", Value: "{{user id}}"},
+		{Key: "off", Value: "1", Disabled: true},
+		{Key: "flag"},
+		{},
+	}
+	got := SetQueryParams("https://x.test/a?old=1#top", params)
+	if want := "https://x.test/a?name=jo+%26+co&id={{user id}}&flag#top"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got := SetQueryParams("https://x.test/a?b", nil); got != "https://x.test/a" {
+		t.Errorf("got %s with no params", got)
+	}
+
+	// Parsing what was set gives back the enabled params
+	want := []types.QueryParam{params[0], params[1], params[3]}
+	if back := ParseQueryParams(got); !reflect.DeepEqual(back, want) {
+		t.Errorf("round trip of %s gave %+v", got, back)
+	}
+
+	// Empty values keep their "=" and pairs that cannot be decoded are kept as typed
+	url := "https://x.test/a?q=&flag&bad=%zz&%zz&a+b=%2F"
+	if got := SetQueryParams(url, ParseQueryParams(url)); got != "https://x.test/a?q=&flag&bad=%zz&%zz&a+b=/" {
+		t.Errorf("got %s", got)
+	}
+
+	// Until they are edited
+	edited := ParseQueryParams(url)
+	edited[2].Value = "100%"
+	if got := SetQueryParams(url, edited); got != "https://x.test/a?q=&flag&bad=100%25&%zz&a+b=/" {
+		t.Errorf("got %s after editing", got)
+	}
+}
+
+func TestMergeQueryParams(t *testing.T) {
+	current := []types.QueryParam{{Key: "a", Value: "1"}, {Key: "b", Value: "2", Disabled: true}, {Key: "c", Value: "3"}}
+	parsed := []types.QueryParam{{Key: "a", Value: "9"}, {Key: "c", Value: "3"}, {Key: "d", Value: "4"}}
+	got := MergeQueryParams(current, parsed)
+	want := []types.QueryParam{{Key: "a", Value: "9"}, {Key: "b", Value: "2", Disabled: true}, {Key: "c", Value: "3"}, {Key: "d", Value: "4"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v", got)
+	}
+	if !HasDisabledParams(got) || HasDisabledParams(parsed) {
+		t.Error("HasDisabledParams is wrong")
+	}
+
+	// Removing a param from the URL drops the enabled param in its place
+	got = MergeQueryParams(current, parsed[:1])
+	if len(got) != 2 || got[0].Key != "a" || !got[1].Disabled {
+		t.Errorf("got %+v", got)
+	}
+}
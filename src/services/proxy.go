@@ -0,0 +1,129 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"postty/src/types"
+)
+
+// ProxyDirect as a proxy URL reaches hosts without a proxy, ignoring HTTP_PROXY and HTTPS_PROXY
+const ProxyDirect = "direct"
+
+// ProxySettingsFor returns the proxy settings of requests sent with the named environment
+// active: its own from the config when it has some, the global ones otherwise
+func ProxySettingsFor(config types.Config, environment string) types.ProxySettings {
+	if settings, ok := config.EnvironmentProxies[environment]; ok && environment != "" {
+		return settings
+	}
+	return config.Proxy
+}
+
+// proxyFunc returns the function choosing the proxy of each request URL: the first rule
+// matching its host, then URL unless the host is in NoProxy, or the environment's proxy
+// variables when URL is empty. A nil proxy means the request goes direct; loopback hosts
+// only go through a proxy when a rule names them.
+func proxyFunc(settings types.ProxySettings) (func(*url.URL) (*url.URL, error), error) {
+	type rule struct {
+		hosts []string
+		proxy *url.URL
+	}
+	var rules []rule
+	for _, r := range settings.Rules {
+		proxy, err := parseProxyURL(r.URL)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule{hosts: r.Hosts, proxy: proxy})
+	}
+
+	config := httpproxy.FromEnvironment()
+	if settings.URL != "" {
+		proxy, err := parseProxyURL(settings.URL)
+		if err != nil {
+			return nil, err
+		}
+		config = &httpproxy.Config{NoProxy: settings.NoProxy}
+		if proxy != nil {
+			config.HTTPProxy = proxy.String()
+			config.HTTPSProxy = proxy.String()
+		}
+	}
+	fallback := config.ProxyFunc()
+
+	return func(target *url.URL) (*url.URL, error) {
+		for _, r := range rules {
+			if proxyHostMatches(r.hosts, target.Hostname()) {
+				return r.proxy, nil
+			}
+		}
+		return fallback(target)
+	}, nil
+}
+
+// parseProxyURL parses a proxy URL, nil for ProxyDirect. Addresses without a scheme are
+// HTTP proxies.
+func parseProxyURL(raw string) (*url.URL, error) {
+	if raw == ProxyDirect {
+		return nil, nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	proxy, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("proxy: unsupported scheme %s://, use http, https or socks5", proxy.Scheme)
+	}
+	if proxy.Host == "" {
+		return nil, fmt.Errorf("proxy: %q has no host", raw)
+	}
+	return proxy, nil
+}
+
+// proxyHostMatches reports whether host matches one of patterns: "*", a host name, which
+// also matches its subdomains, "*.domain" or ".domain" for subdomains only, an IP or a CIDR
+func proxyHostMatches(patterns []string, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	ip := net.ParseIP(host)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case pattern == "*":
+			return true
+		case strings.Contains(pattern, "/"):
+			if _, network, err := net.ParseCIDR(pattern); err == nil && ip != nil && network.Contains(ip) {
+				return true
+			}
+		case strings.HasPrefix(pattern, "*.") || strings.HasPrefix(pattern, "."):
+			if strings.HasSuffix(host, strings.TrimPrefix(pattern, "*")) {
+				return true
+			}
+		case host == pattern || strings.HasSuffix(host, "."+pattern):
+			return true
+		}
+	}
+	return false
+}
+
+// usedProxy returns the proxy settings send target through, without its password, or
+// "" when it goes direct
+func usedProxy(settings types.ProxySettings, target *url.URL) string {
+	choose, err := proxyFunc(settings)
+	if err != nil {
+		return ""
+	}
+	proxy, err := choose(target)
+	if err != nil || proxy == nil {
+		return ""
+	}
+	return proxy.Redacted()
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"postty/src/types"
+)
+
+func TestProxyFunc(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://env-proxy:3128")
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("NO_PROXY", "skip.test")
+
+	rules := []types.ProxyRule{
+		{Hosts: []string{"*.internal"}, URL: "socks5://bob:pw@socks:1080"},
+		{Hosts: []string{"example.org", "10.0.0.0/8"}, URL: ProxyDirect},
+		{Hosts: []string{"localhost"}, URL: "debug:8888"},
+	}
+	tests := []struct {
+		name     string
+		settings types.ProxySettings
+		target   string
+		want     string
+	}{
+		{"global", types.ProxySettings{URL: "corp:3128"}, "https://api.test/", "http://corp:3128"},
+		{"no proxy", types.ProxySettings{URL: "corp:3128", NoProxy: "api.test"}, "http://api.test/", ""},
+		{"no proxy subdomain", types.ProxySettings{URL: "corp:3128", NoProxy: "api.test"}, "http://v1.api.test/", ""},
+		{"direct", types.ProxySettings{URL: ProxyDirect}, "http://api.test/", ""},
+		{"loopback", types.ProxySettings{URL: "corp:3128"}, "http://127.0.0.1:8080/", ""},
+		{"environment", types.ProxySettings{}, "http://api.test/", "http://env-proxy:3128"},
+		{"environment no proxy", types.ProxySettings{}, "http://skip.test/", ""},
+		{"rule", types.ProxySettings{URL: "corp:3128", Rules: rules}, "http://a.svc.internal/", "socks5://bob:pw@socks:1080"},
+		{"rule direct", types.ProxySettings{URL: "corp:3128", Rules: rules}, "http://www.example.org/", ""},
+		{"rule CIDR", types.ProxySettings{URL: "corp:3128", Rules: rules}, "http://10.1.2.3/", ""},
+		{"rule loopback", types.ProxySettings{URL: "corp:3128", Rules: rules}, "http://localhost:8080/", "http://debug:8888"},
+		{"no rule", types.ProxySettings{URL: "corp:3128", Rules: rules}, "http://internal/", "http://corp:3128"},
+	}
+	for _, tt := range tests {
+		choose, err := proxyFunc(tt.settings)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		target, _ := url.Parse(tt.target)
+		proxy, err := choose(target)
+		got := ""
+		if proxy != nil {
+			got = proxy.String()
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"ftp://x", "http://", "http://a b"} {
+		if _, err := proxyFunc(types.ProxySettings{URL: bad}); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+		if _, err := proxyFunc(types.ProxySettings{Rules: []types.ProxyRule{{Hosts: []string{"*"}, URL: bad}}}); err == nil {
+			t.Errorf("%s: expected an error in a rule", bad)
+		}
+	}
+}
+
+func TestProxySettingsFor(t *testing.T) {
+	config := types.Config{
+		Proxy:              types.ProxySettings{URL: "global:3128"},
+		EnvironmentProxies: map[string]types.ProxySettings{"staging": {URL: "staging:3128"}},
+	}
+	if got := ProxySettingsFor(config, "staging").URL; got != "staging:3128" {
+		t.Errorf("got %s for staging", got)
+	}
+	if got := ProxySettingsFor(config, "prod").URL; got != "global:3128" {
+		t.Errorf("got %s for an environment without its own proxy", got)
+	}
+	if got := ProxySettingsFor(config, "").URL; got != "global:3128" {
+		t.Errorf("got %s without an environment", got)
+	}
+}
+
+func TestExecuteRequestThroughProxy(t *testing.T) {
+	var auth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Proxy-Authorization")
+		w.Write([]byte("via proxy to " + r.URL.String()))
+	}))
+	defer proxy.Close()
+
+	settings := types.ProxySettings{URL: strings.Replace(proxy.URL, "http://", "http://alice:secret@", 1)}
+	msg := ExecuteRequest(context.Background(), types.Request{Method: "GET", URL: "http://api.test/x", Proxy: settings, NoCookies: true})().(types.ResponseMsg)
+	if msg.Err != nil || msg.Body != "via proxy to http://api.test/x" {
+		t.Fatalf("got %q, %v", msg.Body, msg.Err)
+	}
+	if auth != "Basic YWxpY2U6c2VjcmV0" {
+		t.Errorf("proxy got Proxy-Authorization %q", auth)
+	}
+	if want := strings.Replace(proxy.URL, "http://", "http://alice:xxxxx@", 1); msg.Meta.Proxy != want {
+		t.Errorf("got proxy %q in the response info, want %q", msg.Meta.Proxy, want)
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"net/http"
+	"time"
+
+	"postty/src/types"
+)
+
+// redirectChain follows the redirects of one request as its policy allows, recording each
+// redirect it follows
+type redirectChain struct {
+	policy   types.RedirectPolicy
+	hops     []types.RedirectHop
+	hopStart time.Time
+}
+
+// newRedirectChain starts recording the redirects of a request sent at start
+func newRedirectChain(policy types.RedirectPolicy, start time.Time) *redirectChain {
+	return &redirectChain{policy: policy, hopStart: start}
+}
+
+// check implements http.Client.CheckRedirect. A redirect the policy does not follow is
+// returned as the response rather than as an error.
+func (c *redirectChain) check(req *http.Request, via []*http.Request) error {
+	limit := c.policy.Max
+	if limit <= 0 {
+		limit = types.DefaultMaxRedirects
+	}
+	if c.policy.NoFollow || len(via) > limit {
+		return http.ErrUseLastResponse
+	}
+
+	now := time.Now()
+	previous := via[len(via)-1]
+	c.hops = append(c.hops, types.RedirectHop{
+		Method:     previous.Method,
+		URL:        previous.URL.Redacted(),
+		StatusCode: req.Response.StatusCode,
+		Status:     req.Response.Status,
+		Location:   req.URL.Redacted(),
+		Duration:   now.Sub(c.hopStart),
+	})
+	c.hopStart = now
+
+	// A 303 asks for a GET of another resource, so only 301 and 302 get the method back
+	status := req.Response.StatusCode
+	if c.policy.KeepMethod && (status == http.StatusMovedPermanently || status == http.StatusFound) {
+		return keepMethod(req, via[0])
+	}
+	return nil
+}
+
+// finalURL returns the URL resp was received from when redirects led there, "" otherwise
+func (c *redirectChain) finalURL(resp *http.Response) string {
+	if len(c.hops) == 0 {
+		return ""
+	}
+	return resp.Request.URL.Redacted()
+}
+
+// keepMethod resends the method, body and body headers of the first request on a 301 or 302
+// redirect the client turned into a GET
+func keepMethod(req, first *http.Request) error {
+	req.Method = first.Method
+	if req.Body != nil || first.GetBody == nil {
+		return nil
+	}
+	body, err := first.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	req.GetBody = first.GetBody
+	req.ContentLength = first.ContentLength
+	for _, name := range []string{"Content-Type", "Content-Encoding", "Content-Language"} {
+		if values := first.Header.Values(name); len(values) > 0 {
+			req.Header[name] = values
+		}
+	}
+	return nil
+}
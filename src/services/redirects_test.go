@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"postty/src/types"
+)
+
+// newRedirectServer redirects /<status> to /echo with that status, /loop to itself, and
+// answers /echo with the method and body it received
+func newRedirectServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := strings.TrimPrefix(r.URL.Path, "/"); path {
+		case "echo":
+			body, _ := io.ReadAll(r.Body)
+			io.WriteString(w, r.Method+" "+string(body))
+		case "loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			status, _ := strconv.Atoi(path)
+			http.Redirect(w, r, "/echo", status)
+		}
+	}))
+}
+
+func TestRedirectMethods(t *testing.T) {
+	server := newRedirectServer()
+	defer server.Close()
+
+	tests := []struct {
+		status     int
+		keepMethod bool
+		want       string
+	}{
+		{http.StatusFound, false, "GET "},
+		{http.StatusSeeOther, false, "GET "},
+		{http.StatusTemporaryRedirect, false, "POST a=1"},
+		{http.StatusPermanentRedirect, false, "POST a=1"},
+		{http.StatusMovedPermanently, true, "POST a=1"},
+		{http.StatusFound, true, "POST a=1"},
+		{http.StatusSeeOther, true, "GET "},
+	}
+	for _, tt := range tests {
+		request := types.Request{
+			Method:    "POST",
+			URL:       server.URL + "/" + strconv.Itoa(tt.status),
+			Body:      "a=1",
+			Redirects: types.RedirectPolicy{KeepMethod: tt.keepMethod},
+			NoCookies: true,
+		}
+		msg := ExecuteRequest(context.Background(), request)().(types.ResponseMsg)
+		if msg.Err != nil {
+			t.Fatal(msg.Err)
+		}
+		if msg.Body != tt.want {
+			t.Errorf("%d, keep method %v: got %q, want %q", tt.status, tt.keepMethod, msg.Body, tt.want)
+		}
+		if len(msg.Meta.Redirects) != 1 || msg.Meta.Redirects[0].StatusCode != tt.status || msg.Meta.URL != server.URL+"/echo" {
+			t.Errorf("%d: got redirects %+v to %s", tt.status, msg.Meta.Redirects, msg.Meta.URL)
+		}
+	}
+}
+
+func TestRedirectLimits(t *testing.T) {
+	server := newRedirectServer()
+	defer server.Close()
+	send := func(url string, policy types.RedirectPolicy) types.ResponseMsg {
+		msg := ExecuteRequest(context.Background(), types.Request{Method: "GET", URL: url, Redirects: policy, NoCookies: true})().(types.ResponseMsg)
+		if msg.Err != nil {
+			t.Fatal(msg.Err)
+		}
+		return msg
+	}
+
+	if msg := send(server.URL+"/302", types.RedirectPolicy{NoFollow: true}); msg.StatusCode != http.StatusFound || len(msg.Meta.Redirects) != 0 {
+		t.Errorf("not following: got %d after %d redirects", msg.StatusCode, len(msg.Meta.Redirects))
+	}
+	if msg := send(server.URL+"/loop", types.RedirectPolicy{Max: 3}); msg.StatusCode != http.StatusFound || len(msg.Meta.Redirects) != 3 {
+		t.Errorf("max 3: got %d after %d redirects", msg.StatusCode, len(msg.Meta.Redirects))
+	}
+	if msg := send(server.URL+"/loop", types.RedirectPolicy{}); len(msg.Meta.Redirects) != types.DefaultMaxRedirects {
+		t.Errorf("default: followed %d redirects, want %d", len(msg.Meta.Redirects), types.DefaultMaxRedirects)
+	}
+}
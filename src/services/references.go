@@ -0,0 +1,104 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"postty/src/types"
+)
+
+// referencePattern matches the history[N] part that starts a response reference
+var referencePattern = regexp.MustCompile(`^history\[(\d+)\](.*)$`)
+
+// IsReference reports whether a {{name}} reference points into history rather than at a variable
+func IsReference(name string) bool {
+	return strings.HasPrefix(name, "history[")
+}
+
+// ResolveReferences replaces {{history[N]...}} references in req with values taken from the
+// responses in history, most recent first, so history[0] is the last response received:
+//
+//	{{history[0].body}}            the whole body
+//	{{history[0].body.$.token}}    the value at a JSONPath of a JSON body
+//	{{history[1].headers.ETag}}    the value of a response header
+//	{{history[0].status}}          the status code
+//
+// Other references are left for the environment. Every reference that does not resolve is
+// reported in the returned error and left as it is.
+func ResolveReferences(req types.Request, history []types.HistoryItem) (types.Request, error) {
+	var problems []error
+	seen := map[string]bool{}
+	req = mapRequestText(req, func(text string) string {
+		return variablePattern.ReplaceAllStringFunc(text, func(match string) string {
+			name := variablePattern.FindStringSubmatch(match)[1]
+			if !IsReference(name) {
+				return match
+			}
+			value, err := resolveReference(name, history)
+			if err != nil {
+				if !seen[name] {
+					seen[name] = true
+					problems = append(problems, err)
+				}
+				return match
+			}
+			return value
+		})
+	})
+	return req, errors.Join(problems...)
+}
+
+// resolveReference looks up a single history[N]... reference
+func resolveReference(name string, history []types.HistoryItem) (string, error) {
+	parts := referencePattern.FindStringSubmatch(name)
+	if parts == nil {
+		return "", fmt.Errorf("%s: write references as history[N].body, history[N].body.$.path, history[N].headers.Name or history[N].status", name)
+	}
+	index, _ := strconv.Atoi(parts[1])
+	if index >= len(history) {
+		return "", fmt.Errorf("%s: history holds %d requests", name, len(history))
+	}
+
+	item := history[index]
+	if item.Outcome != types.OutcomeCompleted || (item.StatusCode == 0 && item.ResponseMeta == nil) {
+		return "", fmt.Errorf("%s: %s %s got no response", name, item.Method, item.URL)
+	}
+
+	field := parts[2]
+	switch {
+	case field == ".status":
+		return strconv.Itoa(item.StatusCode), nil
+
+	case field == ".body":
+		return item.ResponseBody, nil
+
+	case strings.HasPrefix(field, ".body."):
+		path := strings.TrimPrefix(field, ".body.")
+		var document any
+		if err := json.Unmarshal([]byte(item.ResponseBody), &document); err != nil {
+			return "", fmt.Errorf("%s: the body of %s %s is not JSON", name, item.Method, item.URL)
+		}
+		value, err := EvaluateJSONPath(document, path)
+		if err != nil {
+			return "", fmt.Errorf("history[%d].body: %w", index, err)
+		}
+		if text, ok := value.(string); ok {
+			return text, nil
+		}
+		return compactJSON(value), nil
+
+	case strings.HasPrefix(field, ".headers."):
+		header := strings.TrimPrefix(field, ".headers.")
+		for _, h := range item.ResponseHeaders {
+			if strings.EqualFold(h.Key, header) {
+				return h.Value, nil
+			}
+		}
+		return "", fmt.Errorf("%s: the response to %s %s has no %s header", name, item.Method, item.URL, header)
+	}
+	return "", fmt.Errorf("%s: expected .body, .body.$.path, .headers.Name or .status after history[%d]", name, index)
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"postty/src/types"
+)
+
+// testReferenceHistory holds a JSON login response, a failed request and a plain text
+// response, most recent first
+var testReferenceHistory = []types.HistoryItem{
+	{
+		Request:         types.Request{Method: "POST", URL: "/login"},
+		StatusCode:      200,
+		ResponseBody:    "{\n  \"token\": \"abc\",\n  \"n\": [1, {\"x\": true}]\n}",
+		ResponseHeaders: []types.Header{{Key: "Etag", Value: "v1"}},
+	},
+	{Request: types.Request{Method: "GET", URL: "/x"}, Outcome: types.OutcomeError, ResponseBody: "Error: boom"},
+	{Request: types.Request{Method: "GET", URL: "/txt"}, StatusCode: 201, ResponseBody: "plain"},
+}
+
+func TestResolveReferences(t *testing.T) {
+	req := types.Request{
+		URL:     "http://h/{{history[0].body.$.token}}?e={{ history[0].headers.etag }}&s={{history[2].status}}&v={{host}}",
+		Body:    `{"n": {{history[0].body.$.n[1]}}, "t": "{{history[2].body}}"}`,
+		Headers: []types.Header{{Key: "Authorization", Value: "Bearer {{history[0].body.$.token}}"}},
+	}
+	resolved, err := ResolveReferences(req, testReferenceHistory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.URL != "http://h/abc?e=v1&s=201&v={{host}}" {
+		t.Errorf("got URL %s", resolved.URL)
+	}
+	if resolved.Body != `{"n": {"x":true}, "t": "plain"}` {
+		t.Errorf("got body %s", resolved.Body)
+	}
+	if resolved.Headers[0].Value != "Bearer abc" || req.Headers[0].Value != "Bearer {{history[0].body.$.token}}" {
+		t.Errorf("got headers %v, request headers %v", resolved.Headers, req.Headers)
+	}
+	if !IsReference("history[0].body") || IsReference("host") {
+		t.Error("IsReference is wrong")
+	}
+}
+
+func TestResolveReferencesErrors(t *testing.T) {
+	tests := map[string]string{
+		"{{history[0].body.$.missing}}": `history[0].body: $.missing: no field "missing"`,
+		"{{history[5].body}}":           "history[5].body: history holds 3 requests",
+		"{{history[1].status}}":         "history[1].status: GET /x got no response",
+		"{{history[2].body.$.a}}":       "history[2].body.$.a: the body of GET /txt is not JSON",
+		"{{history[0].headers.X-No}}":   "history[0].headers.X-No: the response to POST /login has no X-No header",
+		"{{history[0].foo}}":            "history[0].foo: expected .body, .body.$.path, .headers.Name or .status after history[0]",
+		"{{history[x].body}}":           "history[x].body: write references as",
+	}
+	for url, want := range tests {
+		resolved, err := ResolveReferences(types.Request{URL: url}, testReferenceHistory)
+		if err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%s: got %v, want %q", url, err, want)
+		}
+		if resolved.URL != url {
+			t.Errorf("%s: an unresolved reference was replaced with %s", url, resolved.URL)
+		}
+	}
+
+	// A reference that fails twice is reported once
+	_, err := ResolveReferences(types.Request{URL: "{{history[9].body}}", Body: "{{history[9].body}}"}, testReferenceHistory)
+	if err == nil || strings.Count(err.Error(), "history[9]") != 1 {
+		t.Errorf("got %v", err)
+	}
+}
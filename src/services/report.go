@@ -0,0 +1,186 @@
+package services
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"postty/src/types"
+)
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite holds the requests run with one data row
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is one request of the sequence
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+// junitFailure explains why a request failed
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// jsonReport is the JSON form of a run report
+type jsonReport struct {
+	Name       string       `json:"name"`
+	Started    string       `json:"started"`
+	TimeMS     float64      `json:"time_ms"`
+	Steps      int          `json:"steps"`
+	Iterations int          `json:"iterations"`
+	Passed     int          `json:"passed"`
+	Failed     int          `json:"failed"`
+	Cancelled  bool         `json:"cancelled,omitempty"`
+	Results    []jsonResult `json:"results"`
+}
+
+// jsonResult is the JSON form of one request of a run
+type jsonResult struct {
+	Iteration int                     `json:"iteration"`
+	Step      int                     `json:"step"`
+	Method    string                  `json:"method"`
+	URL       string                  `json:"url"`
+	Status    int                     `json:"status,omitempty"`
+	TimeMS    float64                 `json:"time_ms"`
+	Outcome   string                  `json:"outcome,omitempty"`
+	Passed    bool                    `json:"passed"`
+	Failures  []string                `json:"failures,omitempty"`
+	Tests     []types.AssertionResult `json:"tests,omitempty"`
+}
+
+// WriteJUnitReport writes report as JUnit XML, with a test suite per data row and a test
+// case per request. Requests a stopped run did not reach are reported as skipped.
+func WriteJUnitReport(w io.Writer, report types.RunReport) error {
+	_, failed := RunCounts(report.Results)
+	root := junitTestSuites{
+		Name:     report.Name,
+		Tests:    report.Steps * report.Iterations,
+		Failures: failed,
+		Time:     seconds(report.Duration),
+	}
+
+	for iteration := 1; iteration <= report.Iterations; iteration++ {
+		suite := junitTestSuite{
+			Name:      report.Name,
+			Tests:     report.Steps,
+			Timestamp: report.Started.Format("2006-01-02T15:04:05"),
+		}
+		if report.Iterations > 1 {
+			suite.Name = fmt.Sprintf("%s (row %d)", report.Name, iteration)
+		}
+
+		var elapsed time.Duration
+		ran := map[int]types.RunResult{}
+		for _, result := range report.Results {
+			if result.Iteration == iteration {
+				ran[result.Step] = result
+			}
+		}
+		for step := 1; step <= report.Steps; step++ {
+			result, ok := ran[step]
+			testCase := junitTestCase{ClassName: suite.Name, Time: seconds(result.Duration)}
+			if !ok {
+				testCase.Name = fmt.Sprintf("%d. not run", step)
+				testCase.Skipped = &struct{}{}
+				suite.Skipped++
+				suite.Cases = append(suite.Cases, testCase)
+				continue
+			}
+
+			testCase.Name = fmt.Sprintf("%d. %s %s", step, result.Method, result.URL)
+			elapsed += result.Duration
+			if len(result.Failures) > 0 {
+				suite.Failures++
+				testCase.Failure = &junitFailure{
+					Message: result.Failures[0],
+					Text:    strings.Join(result.Failures, "\n"),
+				}
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+		suite.Time = seconds(elapsed)
+		root.Suites = append(root.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteJSONReport writes report as an indented JSON document
+func WriteJSONReport(w io.Writer, report types.RunReport) error {
+	passed, failed := RunCounts(report.Results)
+	doc := jsonReport{
+		Name:       report.Name,
+		Started:    report.Started.Format(time.RFC3339),
+		TimeMS:     milliseconds(report.Duration),
+		Steps:      report.Steps,
+		Iterations: report.Iterations,
+		Passed:     passed,
+		Failed:     failed,
+		Cancelled:  report.Cancelled,
+		Results:    []jsonResult{},
+	}
+	for _, result := range report.Results {
+		doc.Results = append(doc.Results, jsonResult{
+			Iteration: result.Iteration,
+			Step:      result.Step,
+			Method:    result.Method,
+			URL:       result.URL,
+			Status:    result.StatusCode,
+			TimeMS:    milliseconds(result.Duration),
+			Outcome:   string(result.Outcome),
+			Passed:    len(result.Failures) == 0,
+			Failures:  result.Failures,
+			Tests:     result.TestResults,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(doc)
+}
+
+// seconds formats a duration the way JUnit reports expect
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"postty/src/types"
+)
+
+// testRunReport is a two-row run stopped after the first step of its second row failed
+func testRunReport() types.RunReport {
+	return types.RunReport{
+		Name:       "login flow",
+		Started:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Duration:   1500 * time.Millisecond,
+		Steps:      2,
+		Iterations: 2,
+		Cancelled:  true,
+		Results: []types.RunResult{
+			{Iteration: 1, Step: 1, Method: "POST", URL: "http://a.test/login", StatusCode: 200, Duration: 250 * time.Millisecond},
+			{Iteration: 1, Step: 2, Method: "GET", URL: "http://a.test/me", StatusCode: 200, Duration: 500 * time.Millisecond},
+			{
+				Iteration: 2, Step: 1, Method: "POST", URL: "http://a.test/login", StatusCode: 500, Duration: 125 * time.Millisecond,
+				Failures:    []string{"status 500 Internal Server Error", "assertion failed: status == 200: got 500"},
+				TestResults: []types.AssertionResult{{Assertion: "status == 200", Message: "got 500"}},
+			},
+		},
+	}
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJUnitReport(&buf, testRunReport()); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte(xml.Header)) {
+		t.Error("report has no XML header")
+	}
+
+	var root junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &root); err != nil {
+		t.Fatal(err)
+	}
+	if root.Tests != 4 || root.Failures != 1 || root.Time != "1.500" || len(root.Suites) != 2 {
+		t.Fatalf("got %+v", root)
+	}
+
+	first, second := root.Suites[0], root.Suites[1]
+	if first.Name != "login flow (row 1)" || first.Failures != 0 || first.Time != "0.750" || first.Timestamp != "2024-05-01T12:00:00" {
+		t.Errorf("got first suite %+v", first)
+	}
+	if first.Cases[1].Name != "2. GET http://a.test/me" || first.Cases[1].ClassName != first.Name {
+		t.Errorf("got test case %+v", first.Cases[1])
+	}
+
+	failure := second.Cases[0].Failure
+	if failure == nil || failure.Message != "status 500 Internal Server Error" || failure.Text != "status 500 Internal Server Error\nassertion failed: status == 200: got 500" {
+		t.Errorf("got failure %+v", failure)
+	}
+	if second.Skipped != 1 || second.Cases[1].Skipped == nil || second.Cases[1].Name != "2. not run" {
+		t.Errorf("got second suite %+v", second)
+	}
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSONReport(&buf, testRunReport()); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Started != "2024-05-01T12:00:00Z" || doc.TimeMS != 1500 || doc.Passed != 2 || doc.Failed != 1 || !doc.Cancelled || len(doc.Results) != 3 {
+		t.Fatalf("got %+v", doc)
+	}
+	failed := doc.Results[2]
+	if failed.Passed || failed.Status != 500 || failed.TimeMS != 125 || len(failed.Failures) != 2 || len(failed.Tests) != 1 {
+		t.Errorf("got %+v", failed)
+	}
+	if !doc.Results[0].Passed || doc.Results[0].Failures != nil {
+		t.Errorf("got %+v", doc.Results[0])
+	}
+}
@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/types"
+)
+
+// RunOptions controls a sequence run
+type RunOptions struct {
+	Variables        map[string]string   // Environment the requests are resolved with
+	Rows             []map[string]string // Data rows; the sequence runs once per row, or once when empty
+	Timeout          int                 // Seconds, for requests that do not set their own
+	StopOnFailure    bool                // Skip the rest of the run after the first failed step
+	ProtoFiles       []string            // Describe gRPC methods, as in the config
+	ProtoImportPaths []string
+	TLS              types.TLSSettings   // Settings each request adds its own to, as in the config
+	Proxy            types.ProxySettings // Proxy settings of the environment
+}
+
+// sequenceRun implements types.SequenceRun for a run started with StartSequence
+type sequenceRun struct {
+	results chan types.RunResult
+	report  types.RunReport
+}
+
+// LoadSequence reads a sequence file
+func LoadSequence(path string) (types.Sequence, error) {
+	var seq types.Sequence
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return seq, err
+	}
+	if err := json.Unmarshal(data, &seq); err != nil {
+		return seq, fmt.Errorf("%s: %w", path, err)
+	}
+	if seq.Name == "" {
+		seq.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return seq, nil
+}
+
+// SaveSequence writes seq into dir as an indented JSON file named after it and returns its
+// path. Secrets are left out of its requests, see RedactSecrets.
+func SaveSequence(dir string, seq types.Sequence) (string, error) {
+	requests := make([]types.Request, len(seq.Requests))
+	for i, req := range seq.Requests {
+		requests[i], _ = RedactSecrets(req)
+	}
+	seq.Requests = requests
+	data, err := json.MarshalIndent(seq, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	data = append(data, '\n')
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, collectionFileName(seq.Name)+".json")
+	if err := writeFileAtomic(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// LoadDataRows reads the rows of a .csv file, whose first line names the columns, or of a
+// .json file holding an array of objects. Values that are not strings are kept as JSON.
+func LoadDataRows(path string) ([]map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rows []map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err := csv.NewReader(file).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("%s: no header line", path)
+		}
+		for _, record := range records[1:] {
+			row := map[string]string{}
+			for i, name := range records[0] {
+				if i < len(record) {
+					row[strings.TrimSpace(name)] = record[i]
+				}
+			}
+			rows = append(rows, row)
+		}
+
+	case ".json":
+		var objects []map[string]any
+		if err := json.NewDecoder(file).Decode(&objects); err != nil {
+			return nil, fmt.Errorf("%s: expected an array of objects: %w", path, err)
+		}
+		for _, object := range objects {
+			row := map[string]string{}
+			for name, value := range object {
+				if text, ok := value.(string); ok {
+					row[name] = text
+				} else {
+					row[name] = compactJSON(value)
+				}
+			}
+			rows = append(rows, row)
+		}
+
+	default:
+		return nil, fmt.Errorf("%s: data files must be .csv or .json", path)
+	}
+	return rows, nil
+}
+
+// RunSequence sends the requests of seq in order, once per data row, calling onResult as
+// each one finishes. Variables set by scripts carry over to the following requests of the
+// same row, and {{history[N]...}} references point at its earlier responses, history[0]
+// being the one just before. Cancelling ctx stops the run after the request in flight.
+func RunSequence(ctx context.Context, seq types.Sequence, options RunOptions, onResult func(types.RunResult)) types.RunReport {
+	report := types.RunReport{
+		Name:       seq.Name,
+		Started:    time.Now(),
+		Steps:      len(seq.Requests),
+		Iterations: max(len(options.Rows), 1),
+	}
+	defer func() { report.Duration = time.Since(report.Started) }()
+
+	for iteration := 1; iteration <= report.Iterations; iteration++ {
+		variables := withRow(options.Variables, options.Rows, iteration)
+		var previous []types.HistoryItem
+		for step, req := range seq.Requests {
+			if ctx.Err() != nil {
+				report.Cancelled = true
+				return report
+			}
+
+			result, item := runStep(ctx, req, variables, previous, options)
+			previous = append([]types.HistoryItem{item}, previous...)
+			result.Iteration = iteration
+			result.Step = step + 1
+			report.Results = append(report.Results, result)
+			if onResult != nil {
+				onResult(result)
+			}
+
+			if len(result.Failures) > 0 && options.StopOnFailure {
+				report.Cancelled = iteration < report.Iterations || step < len(seq.Requests)-1
+				return report
+			}
+		}
+	}
+	return report
+}
+
+// withRow returns the variables a data row runs with: the environment, overridden by the row
+func withRow(variables map[string]string, rows []map[string]string, iteration int) map[string]string {
+	merged := make(map[string]string, len(variables))
+	for name, value := range variables {
+		merged[name] = value
+	}
+	if iteration <= len(rows) {
+		for name, value := range rows[iteration-1] {
+			merged[name] = value
+		}
+	}
+	return merged
+}
+
+// runStep sends one request of a sequence, updating variables with what its scripts set. It
+// returns the response as a history item for the references of the requests that follow.
+func runStep(ctx context.Context, req types.Request, variables map[string]string, previous []types.HistoryItem, options RunOptions) (types.RunResult, types.HistoryItem) {
+	result := types.RunResult{Method: req.Method, URL: req.URL}
+	item := types.HistoryItem{Request: req, Outcome: types.OutcomeError}
+	fail := func(format string, args ...any) (types.RunResult, types.HistoryItem) {
+		result.Failures = append(result.Failures, fmt.Sprintf(format, args...))
+		return result, item
+	}
+
+	scripted, run := RunPreRequestScript(ctx, req.PreScript, req, variables)
+	mergeVariables(variables, run.Variables)
+	if run.Err != nil {
+		return fail("%v", run.Err)
+	}
+
+	scripted, err := ResolveReferences(scripted, previous)
+	if err != nil {
+		return fail("%v", err)
+	}
+	resolved, unresolved := InterpolateRequest(scripted, variables)
+	result.Method, result.URL = resolved.Method, resolved.URL
+	if len(unresolved) > 0 {
+		return fail("unresolved variables: %s", strings.Join(unresolved, ", "))
+	}
+	if resolved.Timeout <= 0 {
+		resolved.Timeout = options.Timeout
+	}
+	resolved.TLS = MergeTLS(options.TLS, resolved.TLS)
+	resolved.Proxy = options.Proxy
+
+	var resp types.ResponseMsg
+	switch resolved.Method {
+	case types.WebSocketMethod:
+		return fail("WebSocket connections cannot be run in a sequence")
+	case types.GRPCMethod:
+		var status string
+		resp, status = runGRPCStep(ctx, resolved, options)
+		if status != "" {
+			result.Failures = append(result.Failures, status)
+		}
+	default:
+		resp = runHTTPStep(ctx, resolved)
+	}
+
+	result.StatusCode = resp.StatusCode
+	result.Duration = resp.Meta.Duration
+	result.Outcome = resp.Outcome
+	item.Outcome = resp.Outcome
+	if resp.Err != nil && resp.Outcome == types.OutcomeCompleted {
+		item.Outcome = types.OutcomeError
+	}
+	item.StatusCode = resp.StatusCode
+	item.ResponseBody = resp.Body
+	item.ResponseHeaders = resp.Headers
+	item.ResponseMeta = &resp.Meta
+	if resp.Err != nil {
+		result.Failures = append(result.Failures, resp.Err.Error())
+	} else if resp.StatusCode >= 400 && !hasStatusAssertion(req.Assertions) {
+		result.Failures = append(result.Failures, "status "+resp.Meta.Status)
+	}
+
+	result.TestResults = EvaluateAssertions(req.Assertions, resp)
+	for _, test := range result.TestResults {
+		if !test.Passed {
+			failure := "assertion failed: " + test.Assertion
+			if test.Message != "" {
+				failure += ": " + test.Message
+			}
+			result.Failures = append(result.Failures, failure)
+		}
+	}
+
+	if resp.Err == nil {
+		run := RunPostResponseScript(ctx, req.PostScript, resolved, resp, variables)
+		mergeVariables(variables, run.Variables)
+		if run.Err != nil {
+			result.Failures = append(result.Failures, run.Err.Error())
+		}
+	}
+	return result, item
+}
+
+// runHTTPStep sends an HTTP request; an event stream is closed as soon as its headers arrive
+func runHTTPStep(ctx context.Context, req types.Request) types.ResponseMsg {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	switch msg := ExecuteRequest(ctx, req)().(type) {
+	case types.StreamStartedMsg:
+		return types.ResponseMsg{StatusCode: msg.StatusCode, Headers: msg.Headers, Meta: msg.Meta}
+	case types.ResponseMsg:
+		return msg
+	}
+	return types.ResponseMsg{}
+}
+
+// runGRPCStep calls a gRPC method and waits for it to end. The reply is the body, or a JSON
+// array of the replies for a streaming call; a status other than OK is returned as a failure.
+func runGRPCStep(ctx context.Context, req types.Request, options RunOptions) (types.ResponseMsg, string) {
+	start := time.Now()
+	var started types.GRPCStartedMsg
+	switch msg := CallGRPC(ctx, req, options.ProtoFiles, options.ProtoImportPaths)().(type) {
+	case types.GRPCStartedMsg:
+		started = msg
+	case types.ResponseMsg:
+		return msg, ""
+	}
+
+	var replies []string
+	for reply := range started.Call.Replies() {
+		replies = append(replies, reply.Message)
+	}
+	result := started.Call.Result()
+
+	resp := types.ResponseMsg{Headers: started.Headers, Meta: started.Meta}
+	resp.Meta.Duration = time.Since(start)
+	resp.Meta.GRPCStatus = result.Code
+	if len(replies) == 1 {
+		resp.Body = replies[0]
+	} else {
+		resp.Body = "[" + strings.Join(replies, ",") + "]"
+	}
+	if result.Number != 0 {
+		return resp, fmt.Sprintf("gRPC status %s: %s", result.Code, result.Message)
+	}
+	return resp, ""
+}
+
+// hasStatusAssertion reports whether the status code is left to an enabled assertion
+func hasStatusAssertion(assertions []types.Assertion) bool {
+	for _, assertion := range assertions {
+		if assertion.Kind == types.AssertStatus && !assertion.Disabled {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeVariables copies the variables a script set into variables
+func mergeVariables(variables, set map[string]string) {
+	for name, value := range set {
+		variables[name] = value
+	}
+}
+
+// StartSequence runs seq in the background; its results are read with WaitSequence
+func StartSequence(ctx context.Context, seq types.Sequence, options RunOptions) types.SequenceRun {
+	run := &sequenceRun{results: make(chan types.RunResult, 16)}
+	go func() {
+		defer close(run.results)
+		run.report = RunSequence(ctx, seq, options, func(result types.RunResult) {
+			run.results <- result
+		})
+	}()
+	return run
+}
+
+// Results implements types.SequenceRun
+func (r *sequenceRun) Results() <-chan types.RunResult {
+	return r.results
+}
+
+// Report implements types.SequenceRun
+func (r *sequenceRun) Report() types.RunReport {
+	return r.report
+}
+
+// WaitSequence creates a command that delivers the next result of run, or its end
+func WaitSequence(run types.SequenceRun) tea.Cmd {
+	return func() tea.Msg {
+		if result, ok := <-run.Results(); ok {
+			return types.RunStepMsg{Run: run, Result: result}
+		}
+		return types.RunEndedMsg{Run: run, Report: run.Report()}
+	}
+}
+
+// RunCounts returns how many steps of a run passed and failed
+func RunCounts(results []types.RunResult) (passed, failed int) {
+	for _, result := range results {
+		if len(result.Failures) == 0 {
+			passed++
+		} else {
+			failed++
+		}
+	}
+	return passed, failed
+}
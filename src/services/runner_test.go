@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"postty/src/types"
+)
+
+// loginServer hands out a token for the user posted to /login and checks it on /me
+func loginServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/login":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Session", "s-"+string(body))
+			fmt.Fprintf(w, `{"token":"tok-%s"}`, body)
+		case "/me":
+			if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer tok-") {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"auth":%q,"session":%q}`, r.Header.Get("Authorization"), r.URL.Query().Get("s"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestLoadDataRows(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "rows.csv")
+	os.WriteFile(csvPath, []byte("user, n\nalice,1\nbob,2\n"), 0o644)
+	rows, err := LoadDataRows(csvPath)
+	if err != nil || !reflect.DeepEqual(rows, []map[string]string{{"user": "alice", "n": "1"}, {"user": "bob", "n": "2"}}) {
+		t.Errorf("got %v, %v", rows, err)
+	}
+
+	jsonPath := filepath.Join(dir, "rows.json")
+	os.WriteFile(jsonPath, []byte(`[{"user":"alice","n":1,"tags":["a"]}]`), 0o644)
+	rows, err = LoadDataRows(jsonPath)
+	if err != nil || !reflect.DeepEqual(rows, []map[string]string{{"user": "alice", "n": "1", "tags": `["a"]`}}) {
+		t.Errorf("got %v, %v", rows, err)
+	}
+
+	os.WriteFile(jsonPath, []byte(`{"user":"alice"}`), 0o644)
+	if _, err := LoadDataRows(jsonPath); err == nil {
+		t.Error("expected an error for a JSON object")
+	}
+	if _, err := LoadDataRows(filepath.Join(dir, "rows.txt")); err == nil {
+		t.Error("expected an error for a .txt file")
+	}
+}
+
+func TestSaveAndLoadSequence(t *testing.T) {
+	dir := t.TempDir()
+	seq := types.Sequence{Name: "Login flow", Requests: []types.Request{{Method: "GET", URL: "http://a.test/"}}}
+	path, err := SaveSequence(dir, seq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSequence(path)
+	if err != nil || loaded.Name != seq.Name || len(loaded.Requests) != 1 || loaded.Requests[0].URL != "http://a.test/" {
+		t.Errorf("got %+v, %v", loaded, err)
+	}
+
+	unnamed := filepath.Join(dir, "smoke.json")
+	os.WriteFile(unnamed, []byte(`{"requests":[]}`), 0o644)
+	if loaded, err := LoadSequence(unnamed); err != nil || loaded.Name != "smoke" {
+		t.Errorf("got %+v, %v, want the sequence named after its file", loaded, err)
+	}
+}
+
+func TestRunSequence(t *testing.T) {
+	server := loginServer()
+	defer server.Close()
+
+	seq := types.Sequence{Name: "login flow", Requests: []types.Request{
+		{
+			Method: "POST", URL: server.URL + "/login", Body: "{{user}}", ContentType: "text/plain",
+			PostScript: "env.set('token', response.json().token)",
+			Assertions: []types.Assertion{{Kind: types.AssertStatus, Value: "200"}},
+		},
+		{
+			Method: "GET", URL: server.URL + "/me?s={{history[0].headers.X-Session}}", ContentType: "application/json",
+			Headers:    []types.Header{{Key: "Authorization", Value: "Bearer {{token}}"}},
+			Assertions: []types.Assertion{{Kind: types.AssertJSONEquals, Target: "$.auth", Value: "Bearer tok-alice"}},
+		},
+		{Method: "GET", URL: server.URL + "/missing", ContentType: "application/json"},
+	}}
+
+	var streamed int
+	report := RunSequence(context.Background(), seq, RunOptions{Rows: []map[string]string{{"user": "alice"}, {"user": "bob"}}}, func(types.RunResult) { streamed++ })
+	if report.Steps != 3 || report.Iterations != 2 || len(report.Results) != 6 || streamed != 6 || report.Cancelled {
+		t.Fatalf("got %+v", report)
+	}
+
+	var failures []string
+	for _, result := range report.Results {
+		failures = append(failures, fmt.Sprintf("%d.%d %s", result.Iteration, result.Step, strings.Join(result.Failures, "; ")))
+	}
+	want := []string{
+		"1.1 ",
+		"1.2 ",
+		"1.3 status 404 Not Found",
+		"2.1 ",
+		`2.2 assertion failed: $.auth == Bearer tok-alice: got "Bearer tok-bob"`,
+		"2.3 status 404 Not Found",
+	}
+	if !reflect.DeepEqual(failures, want) {
+		t.Errorf("got failures\n%s", strings.Join(failures, "\n"))
+	}
+	if url := report.Results[1].URL; url != server.URL+"/me?s=s-alice" {
+		t.Errorf("second step sent to %s", url)
+	}
+	if passed, failed := RunCounts(report.Results); passed != 3 || failed != 3 {
+		t.Errorf("got %d passed, %d failed", passed, failed)
+	}
+
+	// Stopping on the first failure skips the rest of the run
+	report = RunSequence(context.Background(), seq, RunOptions{Rows: []map[string]string{{"user": "alice"}, {"user": "bob"}}, StopOnFailure: true}, nil)
+	if len(report.Results) != 3 || !report.Cancelled {
+		t.Errorf("got %d results, cancelled %v", len(report.Results), report.Cancelled)
+	}
+
+	// A reference to a response that does not exist fails the step without sending it
+	report = RunSequence(context.Background(), types.Sequence{Requests: seq.Requests[1:2]}, RunOptions{}, nil)
+	if failures := report.Results[0].Failures; len(failures) != 1 || !strings.Contains(failures[0], "history[0]") {
+		t.Errorf("got %v", failures)
+	}
+}
+
+func TestStartSequence(t *testing.T) {
+	server := loginServer()
+	defer server.Close()
+
+	seq := types.Sequence{Requests: []types.Request{{Method: "POST", URL: server.URL + "/login", Body: "alice"}, {Method: "GET", URL: server.URL + "/me"}}}
+	run := StartSequence(context.Background(), seq, RunOptions{})
+	var steps []int
+	for {
+		msg := WaitSequence(run)()
+		if step, ok := msg.(types.RunStepMsg); ok {
+			steps = append(steps, step.Result.Step)
+			continue
+		}
+		ended := msg.(types.RunEndedMsg)
+		if len(ended.Report.Results) != 2 {
+			t.Errorf("got report %+v", ended.Report)
+		}
+		break
+	}
+	if !reflect.DeepEqual(steps, []int{1, 2}) {
+		t.Errorf("got steps %v", steps)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report := RunSequence(ctx, seq, RunOptions{}, nil); !report.Cancelled || len(report.Results) != 0 {
+		t.Errorf("got %+v for a cancelled run", report)
+	}
+}
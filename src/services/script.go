@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dop251/goja"
+
+	"postty/src/types"
+)
+
+// ScriptTimeout bounds how long a script may run before it is interrupted
+const ScriptTimeout = 5 * time.Second
+
+// ErrScriptCancelled is the error of a script interrupted by cancelling its context
+var ErrScriptCancelled = errors.New("script cancelled")
+
+// scriptHashes are the digests offered to scripts through the crypto object
+var scriptHashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// scriptRuntime is a JavaScript interpreter with the globals shared by both kinds of script
+type scriptRuntime struct {
+	vm        *goja.Runtime
+	phase     types.ScriptPhase
+	variables map[string]string
+	run       types.ScriptRun
+}
+
+// RunPreRequestScript runs script before req is sent and returns the request as the script
+// left it. The script sees the request as written in the form, before {{name}} references
+// are resolved; variables it sets with env.set are used to resolve them. Cancelling ctx
+// interrupts the script.
+func RunPreRequestScript(ctx context.Context, script string, req types.Request, variables map[string]string) (types.Request, types.ScriptRun) {
+	if strings.TrimSpace(script) == "" {
+		return req, types.ScriptRun{}
+	}
+
+	// The script changes its own copy of the headers
+	req.Headers = append([]types.Header(nil), req.Headers...)
+
+	s := newScriptRuntime(types.PreRequestPhase, variables)
+	request := s.requestObject(&req, true)
+	s.vm.Set("request", request)
+	if s.execute(ctx, script) == nil {
+		req.Method = strings.ToUpper(request.Get("method").String())
+		req.URL = request.Get("url").String()
+		req.Body = request.Get("body").String()
+	}
+	return req, s.run
+}
+
+// RunPostResponseScript runs script once the response to req has arrived, so it can check
+// the response and keep values from it with env.set. Cancelling ctx interrupts the script.
+func RunPostResponseScript(ctx context.Context, script string, req types.Request, resp types.ResponseMsg, variables map[string]string) types.ScriptRun {
+	if strings.TrimSpace(script) == "" {
+		return types.ScriptRun{}
+	}
+
+	s := newScriptRuntime(types.PostResponsePhase, variables)
+	s.vm.Set("request", s.requestObject(&req, false))
+	s.vm.Set("response", s.responseObject(resp))
+	s.execute(ctx, script)
+	return s.run
+}
+
+// PreRequestScript runs the pre-request script of req in the background and reports the
+// request it left
+func PreRequestScript(ctx context.Context, req types.Request, variables map[string]string) tea.Cmd {
+	return func() tea.Msg {
+		scripted, run := RunPreRequestScript(ctx, req.PreScript, req, variables)
+		return types.PreScriptMsg{Request: scripted, Run: run}
+	}
+}
+
+// PostResponseScript runs script against the response to req in the background
+func PostResponseScript(ctx context.Context, script string, req types.Request, resp types.ResponseMsg, variables map[string]string) tea.Cmd {
+	return func() tea.Msg {
+		return types.PostScriptMsg{Response: resp, Run: RunPostResponseScript(ctx, script, req, resp, variables)}
+	}
+}
+
+// newScriptRuntime creates an interpreter with console, env, crypto, btoa and atob defined
+func newScriptRuntime(phase types.ScriptPhase, variables map[string]string) *scriptRuntime {
+	s := &scriptRuntime{
+		vm:        goja.New(),
+		phase:     phase,
+		variables: variables,
+		run:       types.ScriptRun{Variables: map[string]string{}},
+	}
+	vm := s.vm
+
+	console := vm.NewObject()
+	for _, level := range []string{"log", "info", "warn", "error"} {
+		console.Set(level, func(call goja.FunctionCall) goja.Value {
+			parts := make([]string, len(call.Arguments))
+			for i, arg := range call.Arguments {
+				parts[i] = s.format(arg)
+			}
+			s.log(level, strings.Join(parts, " "))
+			return goja.Undefined()
+		})
+	}
+	vm.Set("console", console)
+
+	env := vm.NewObject()
+	env.Set("get", func(name string) goja.Value {
+		if value, ok := s.variable(name); ok {
+			return vm.ToValue(value)
+		}
+		return goja.Undefined()
+	})
+	env.Set("set", func(name string, value goja.Value) {
+		s.run.Variables[name] = s.format(value)
+	})
+	env.Set("resolve", func(text string) string {
+		resolved, _ := Interpolate(text, s.allVariables())
+		return resolved
+	})
+	vm.Set("env", env)
+
+	crypto := vm.NewObject()
+	for name, newHash := range scriptHashes {
+		crypto.Set(name, func(data string) string {
+			h := newHash()
+			h.Write([]byte(data))
+			return hex.EncodeToString(h.Sum(nil))
+		})
+		crypto.Set("hmac"+strings.ToUpper(name), func(key, data string) string {
+			h := hmac.New(newHash, []byte(key))
+			h.Write([]byte(data))
+			return hex.EncodeToString(h.Sum(nil))
+		})
+	}
+	crypto.Set("randomUUID", func() string {
+		b := make([]byte, 16)
+		rand.Read(b)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	})
+	vm.Set("crypto", crypto)
+
+	vm.Set("btoa", func(text string) string {
+		return base64.StdEncoding.EncodeToString([]byte(text))
+	})
+	vm.Set("atob", func(text string) (string, error) {
+		data, err := base64.StdEncoding.DecodeString(text)
+		return string(data), err
+	})
+	return s
+}
+
+// requestObject exposes req to a script. The header functions change req directly; the
+// method, URL and body are read back from the object after a pre-request script.
+func (s *scriptRuntime) requestObject(req *types.Request, writable bool) *goja.Object {
+	request := s.vm.NewObject()
+	request.Set("method", req.Method)
+	request.Set("url", req.URL)
+	request.Set("body", req.Body)
+	request.Set("getHeader", func(name string) goja.Value {
+		return s.header(req.Headers, name)
+	})
+	request.DefineAccessorProperty("headers", s.vm.ToValue(func() goja.Value {
+		return s.headerList(req.Headers)
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+
+	if !writable {
+		return request
+	}
+	request.Set("setHeader", func(name string, value goja.Value) {
+		text := s.format(value)
+		for i, h := range req.Headers {
+			if strings.EqualFold(h.Key, name) {
+				req.Headers[i].Value = text
+				return
+			}
+		}
+		req.Headers = append(req.Headers, types.Header{Key: name, Value: text})
+	})
+	request.Set("removeHeader", func(name string) {
+		kept := req.Headers[:0]
+		for _, h := range req.Headers {
+			if !strings.EqualFold(h.Key, name) {
+				kept = append(kept, h)
+			}
+		}
+		req.Headers = kept
+	})
+	return request
+}
+
+// responseObject exposes a response to a post-response script
+func (s *scriptRuntime) responseObject(resp types.ResponseMsg) *goja.Object {
+	response := s.vm.NewObject()
+	response.Set("status", resp.StatusCode)
+	response.Set("statusText", resp.Meta.Status)
+	response.Set("body", resp.Body)
+	response.Set("time", resp.Meta.Duration.Milliseconds())
+	response.Set("size", resp.Meta.BodySize)
+	response.Set("headers", s.headerList(resp.Headers))
+	response.Set("getHeader", func(name string) goja.Value {
+		return s.header(resp.Headers, name)
+	})
+	response.Set("json", func() (goja.Value, error) {
+		parse, _ := goja.AssertFunction(s.vm.Get("JSON").ToObject(s.vm).Get("parse"))
+		return parse(goja.Undefined(), s.vm.ToValue(resp.Body))
+	})
+	return response
+}
+
+// execute runs script until it ends, times out or ctx is cancelled, logging and recording
+// the error that stopped it if any
+func (s *scriptRuntime) execute(ctx context.Context, script string) error {
+	timer := time.AfterFunc(ScriptTimeout, func() {
+		s.vm.Interrupt(fmt.Errorf("script timed out after %s", ScriptTimeout))
+	})
+	defer timer.Stop()
+	stop := context.AfterFunc(ctx, func() {
+		s.vm.Interrupt(ErrScriptCancelled)
+	})
+	defer stop()
+
+	_, err := s.vm.RunScript(string(s.phase)+".js", script)
+	if err != nil {
+		cause := err
+		if interrupted, ok := err.(*goja.InterruptedError); ok {
+			cause = interrupted.Value().(error)
+		}
+		s.run.Err = fmt.Errorf("%s script: %w", s.phase, cause)
+		s.log("error", cause.Error())
+	}
+	return err
+}
+
+// log adds an entry to the console output of the run
+func (s *scriptRuntime) log(level, text string) {
+	s.run.Log = append(s.run.Log, types.ScriptLogEntry{Time: time.Now(), Phase: s.phase, Level: level, Text: text})
+}
+
+// format renders a value for the console: strings as they are, anything else as JSON
+func (s *scriptRuntime) format(value goja.Value) string {
+	if value == nil || goja.IsUndefined(value) {
+		return "undefined"
+	}
+	if _, isString := value.Export().(string); isString {
+		return value.String()
+	}
+	if _, isFunction := goja.AssertFunction(value); !isFunction {
+		stringify, _ := goja.AssertFunction(s.vm.Get("JSON").ToObject(s.vm).Get("stringify"))
+		if text, err := stringify(goja.Undefined(), value); err == nil && !goja.IsUndefined(text) {
+			return text.String()
+		}
+	}
+	return value.String()
+}
+
+// header returns the first value of the named header, or undefined when it is missing
+func (s *scriptRuntime) header(headers []types.Header, name string) goja.Value {
+	for _, h := range headers {
+		if strings.EqualFold(h.Key, name) {
+			return s.vm.ToValue(h.Value)
+		}
+	}
+	return goja.Undefined()
+}
+
+// headerList converts headers to an array of {key, value} objects
+func (s *scriptRuntime) headerList(headers []types.Header) goja.Value {
+	list := make([]any, len(headers))
+	for i, h := range headers {
+		list[i] = map[string]any{"key": h.Key, "value": h.Value}
+	}
+	return s.vm.NewArray(list...)
+}
+
+// variable looks a variable up, preferring values the script has set
+func (s *scriptRuntime) variable(name string) (string, bool) {
+	if value, ok := s.run.Variables[name]; ok {
+		return value, true
+	}
+	value, ok := s.variables[name]
+	return value, ok
+}
+
+// allVariables returns the variables with the values the script has set applied
+func (s *scriptRuntime) allVariables() map[string]string {
+	merged := make(map[string]string, len(s.variables)+len(s.run.Variables))
+	for name, value := range s.variables {
+		merged[name] = value
+	}
+	for name, value := range s.run.Variables {
+		merged[name] = value
+	}
+	return merged
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"postty/src/types"
+)
+
+func TestPreRequestScript(t *testing.T) {
+	req := types.Request{Method: "POST", URL: "https://x.test/{{id}}", Body: `{"id":"{{id}}"}`, Headers: []types.Header{{Key: "X-A", Value: "1"}}}
+	scripted, run := RunPreRequestScript(context.Background(), `
+		request.method = "put";
+		request.url += "?v=2";
+		request.setHeader("x-a", "2");
+		request.setHeader("X-Sig", crypto.md5(env.resolve(request.body)));
+		env.set("id", 42);
+	`, req, map[string]string{"id": "7"})
+	if run.Err != nil {
+		t.Fatal(run.Err)
+	}
+	if scripted.Method != "PUT" || scripted.URL != "https://x.test/{{id}}?v=2" {
+		t.Errorf("got %s %s", scripted.Method, scripted.URL)
+	}
+	// md5 of {"id":"7"}, the body as resolved when the script signed it
+	sig := fmt.Sprintf("%x", md5.Sum([]byte(`{"id":"7"}`)))
+	if len(scripted.Headers) != 2 || scripted.Headers[0].Value != "2" || scripted.Headers[1] != (types.Header{Key: "X-Sig", Value: sig}) {
+		t.Errorf("got headers %v", scripted.Headers)
+	}
+	if req.Headers[0].Value != "1" {
+		t.Error("the script changed the headers of the request it was given")
+	}
+	if run.Variables["id"] != "42" {
+		t.Errorf("got variables %v", run.Variables)
+	}
+}
+
+func TestPreRequestScriptErrors(t *testing.T) {
+	for _, script := range []string{`nope()`, `throw new Error("boom")`, `let x = ;`} {
+		_, run := RunPreRequestScript(context.Background(), script, types.Request{}, nil)
+		if run.Err == nil {
+			t.Errorf("%s: expected an error", script)
+			continue
+		}
+		if last := run.Log[len(run.Log)-1]; last.Level != "error" {
+			t.Errorf("%s: last log entry is %+v, want the error", script, last)
+		}
+	}
+}
+
+func TestPreRequestScriptCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, run := RunPreRequestScript(ctx, `while (true) {}`, types.Request{}, nil)
+	if !errors.Is(run.Err, ErrScriptCancelled) || run.Err.Error() != "pre-request script: script cancelled" {
+		t.Errorf("got %v, want the script cancelled", run.Err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the script ran for %v after it was cancelled", elapsed)
+	}
+}
+
+func TestPostResponseScript(t *testing.T) {
+	resp := types.ResponseMsg{
+		StatusCode: 200,
+		Body:       `{"token":"abc"}`,
+		Headers:    []types.Header{{Key: "Content-Type", Value: "application/json"}},
+		Meta:       types.ResponseMeta{Status: "200 OK"},
+	}
+	run := RunPostResponseScript(context.Background(), `
+		env.set("token", response.json().token);
+		console.log(response.status, response.getHeader("content-type"), request.url);
+	`, types.Request{URL: "https://x.test/login"}, resp, nil)
+	if run.Err != nil {
+		t.Fatal(run.Err)
+	}
+	if run.Variables["token"] != "abc" {
+		t.Errorf("got variables %v", run.Variables)
+	}
+	if len(run.Log) != 1 || !strings.Contains(run.Log[0].Text, "200 application/json https://x.test/login") {
+		t.Errorf("got log %+v", run.Log)
+	}
+}
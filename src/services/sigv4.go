@@ -0,0 +1,181 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"postty/src/types"
+)
+
+// sigV4Algorithm names the signing algorithm in the Authorization header and string to sign
+const sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+// sigV4UnsignedHeaders are left out of the signature: they are changed on the way, or
+// carry the signature itself
+var sigV4UnsignedHeaders = map[string]bool{
+	"authorization":   true,
+	"user-agent":      true,
+	"expect":          true,
+	"x-amzn-trace-id": true,
+}
+
+// signSigV4 signs req with AWS Signature Version 4, adding the X-Amz-Content-Sha256 header
+// with the hash of body, which S3 requires, before signing
+func signSigV4(req *http.Request, auth types.Auth, body string, now time.Time) error {
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	return sigV4Sign(req, auth, payloadHash, now)
+}
+
+// sigV4Sign adds X-Amz-Date, the session token if any and the Authorization header made from
+// the canonical request: the method, path, query, the headers already on req, plus Host, and
+// payloadHash
+func sigV4Sign(req *http.Request, auth types.Auth, payloadHash string, now time.Time) error {
+	switch {
+	case auth.AccessKey == "" || auth.SecretKey == "":
+		return errors.New("auth: SigV4 needs an access key and a secret key")
+	case auth.Region == "" || auth.Service == "":
+		return errors.New("auth: SigV4 needs a region and a service")
+	}
+
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if auth.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", auth.SessionToken)
+	}
+
+	headers, signedHeaders := sigV4CanonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4CanonicalPath(req, auth.Service),
+		sigV4CanonicalQuery(req.URL.RawQuery),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, auth.Region, auth.Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+auth.SecretKey), date)
+	key = hmacSHA256(key, auth.Region)
+	key = hmacSHA256(key, auth.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, auth.AccessKey, scope, signedHeaders, signature))
+	return nil
+}
+
+// sigV4CanonicalPath returns the URI-encoded path. Services other than S3 sign the path as
+// sent encoded once more.
+func sigV4CanonicalPath(req *http.Request, service string) string {
+	path := req.URL.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	if service == "s3" {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = sigV4Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// sigV4CanonicalQuery returns the query parameters URI-encoded and sorted by name, then value
+func sigV4CanonicalQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	// Sort the pairs before joining them, so a name such as "a-b" cannot sort before "a"
+	var params [][2]string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		params = append(params, [2]string{sigV4Escape(unescapeQueryComponent(name)), sigV4Escape(unescapeQueryComponent(value))})
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i][0] != params[j][0] {
+			return params[i][0] < params[j][0]
+		}
+		return params[i][1] < params[j][1]
+	})
+	joined := make([]string, len(params))
+	for i, param := range params {
+		joined[i] = param[0] + "=" + param[1]
+	}
+	return strings.Join(joined, "&")
+}
+
+// sigV4CanonicalHeaders returns the canonical header lines, each ending in a newline, and the
+// list of signed header names
+func sigV4CanonicalHeaders(req *http.Request) (string, string) {
+	values := map[string][]string{}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values["host"] = []string{host}
+	for name, list := range req.Header {
+		name = strings.ToLower(name)
+		if sigV4UnsignedHeaders[name] {
+			continue
+		}
+		for _, value := range list {
+			values[name] = append(values[name], strings.Join(strings.Fields(value), " "))
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines strings.Builder
+	for _, name := range names {
+		lines.WriteString(name + ":" + strings.Join(values[name], ",") + "\n")
+	}
+	return lines.String(), strings.Join(names, ";")
+}
+
+// sigV4Escape percent-encodes everything but the unreserved characters of RFC 3986
+func sigV4Escape(s string) string {
+	var escaped strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			escaped.WriteByte(c)
+		} else {
+			fmt.Fprintf(&escaped, "%%%02X", c)
+		}
+	}
+	return escaped.String()
+}
+
+// sha256Hex returns the hex SHA-256 hash of s
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"postty/src/types"
+)
+
+// eventStream implements types.EventStream over a text/event-stream response body
+type eventStream struct {
+	events chan types.SSEEvent
+	err    error
+}
+
+// isEventStream reports whether a Content-Type header announces Server-Sent Events
+func isEventStream(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// newEventStream starts parsing body into events; the body is closed when the stream ends
+func newEventStream(body io.ReadCloser) *eventStream {
+	stream := &eventStream{events: make(chan types.SSEEvent, 64)}
+	go func() {
+		defer close(stream.events)
+		defer body.Close()
+		stream.err = ParseEventStream(body, func(event types.SSEEvent) {
+			stream.events <- event
+		})
+	}()
+	return stream
+}
+
+// Events implements types.EventStream
+func (s *eventStream) Events() <-chan types.SSEEvent {
+	return s.events
+}
+
+// Err implements types.EventStream
+func (s *eventStream) Err() error {
+	return s.err
+}
+
+// WaitEventStream creates a command that delivers the next event of stream, or its end
+func WaitEventStream(stream types.EventStream) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-stream.Events()
+		if ok {
+			return types.SSEEventMsg{Stream: stream, Event: event}
+		}
+
+		msg := types.StreamEndedMsg{Stream: stream}
+		if err := stream.Err(); err != nil {
+			msg.Err = err
+			msg.Outcome = errorOutcome(context.Background(), err)
+		}
+		return msg
+	}
+}
+
+// ParseEventStream reads Server-Sent Events from r, calling emit for each complete event.
+// It follows the parsing rules of the HTML specification: comment lines are skipped,
+// data lines are joined with newlines and the last event ID carries over between events.
+func ParseEventStream(r io.Reader, emit func(types.SSEEvent)) error {
+	reader := &eventLineReader{reader: bufio.NewReader(r)}
+	lastID := ""
+	var data []string
+	event := types.SSEEvent{}
+	hasData := false
+
+	for {
+		line, err := reader.readLine()
+		if err != nil && line == "" {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		// A blank line dispatches the event collected so far
+		if line == "" {
+			if hasData {
+				event.ID = lastID
+				event.Data = strings.Join(data, "\n")
+				if event.Event == "" {
+					event.Event = "message"
+				}
+				event.Time = time.Now()
+				emit(event)
+			}
+			data = nil
+			hasData = false
+			event = types.SSEEvent{}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data = append(data, value)
+			hasData = true
+		case "event":
+			event.Event = value
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				lastID = value
+			}
+		case "retry":
+			if retry, err := strconv.Atoi(value); err == nil && retry >= 0 {
+				event.Retry = retry
+			}
+		}
+	}
+}
+
+// eventLineReader splits an event stream into lines, which end in "\r\n", "\n" or "\r"
+type eventLineReader struct {
+	reader  *bufio.Reader
+	afterCR bool // The last line ended in "\r", so a "\n" right after it belongs to that line
+}
+
+// readLine returns the next line without its ending. It does not wait for the byte after
+// a "\r", so a line ending in "\r" is returned as soon as it arrives.
+func (l *eventLineReader) readLine() (string, error) {
+	var line []byte
+	for {
+		c, err := l.reader.ReadByte()
+		if err != nil {
+			return string(line), err
+		}
+		if l.afterCR {
+			l.afterCR = false
+			if c == '\n' {
+				continue
+			}
+		}
+		switch c {
+		case '\n':
+			return string(line), nil
+		case '\r':
+			l.afterCR = true
+			return string(line), nil
+		}
+		line = append(line, c)
+	}
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"postty/src/types"
+)
+
+func TestParseEventStream(t *testing.T) {
+	stream := ": comment\nid: 1\nevent: tick\ndata: {\"a\":1}\n\n" +
+		"data: line1\r\ndata:line2\r\nretry: 3000\r\n\r\n" +
+		"id: 2\ndata\n\n" +
+		"data: cr1\rdata: cr2\r\r" +
+		"event: ignored\n\n" +
+		"data: unterminated"
+
+	var events []types.SSEEvent
+	if err := ParseEventStream(strings.NewReader(stream), func(e types.SSEEvent) { events = append(events, e) }); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []types.SSEEvent{
+		{ID: "1", Event: "tick", Data: `{"a":1}`},
+		{ID: "1", Event: "message", Data: "line1\nline2", Retry: 3000},
+		{ID: "2", Event: "message", Data: ""},
+		{ID: "2", Event: "message", Data: "cr1\ncr2"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events: %+v", len(events), events)
+	}
+	for i, e := range events {
+		if e.ID != want[i].ID || e.Event != want[i].Event || e.Data != want[i].Data || e.Retry != want[i].Retry {
+			t.Errorf("event %d: got %+v, want %+v", i, e, want[i])
+		}
+		if e.Time.IsZero() {
+			t.Errorf("event %d has no time", i)
+		}
+	}
+}
+
+func TestParseEventStreamDoesNotWaitAfterCR(t *testing.T) {
+	r, w := io.Pipe()
+	events := make(chan types.SSEEvent)
+	go ParseEventStream(r, func(e types.SSEEvent) { events <- e })
+	defer w.Close()
+
+	w.Write([]byte("data: now\r\r"))
+	select {
+	case e := <-events:
+		if e.Data != "now" {
+			t.Errorf("got %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the event was held back until more of the stream arrived")
+	}
+}
+
+func TestExecuteRequestStreamsEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		for i := 1; i <= 3; i++ {
+			fmt.Fprintf(w, "id: %d\ndata: tick %d\n\n", i, i)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	msg := ExecuteRequest(context.Background(), types.Request{Method: "GET", URL: server.URL})()
+	started, ok := msg.(types.StreamStartedMsg)
+	if !ok {
+		t.Fatalf("got %#v, want a stream", msg)
+	}
+	if started.StatusCode != 200 {
+		t.Errorf("got status %d", started.StatusCode)
+	}
+
+	var data []string
+	for {
+		switch msg := WaitEventStream(started.Stream)().(type) {
+		case types.SSEEventMsg:
+			data = append(data, msg.Event.ID+":"+msg.Event.Data)
+			continue
+		case types.StreamEndedMsg:
+			if msg.Err != nil {
+				t.Errorf("stream ended with %v", msg.Err)
+			}
+		default:
+			t.Fatalf("got %#v", msg)
+		}
+		break
+	}
+	if got := strings.Join(data, ","); got != "1:tick 1,2:tick 2,3:tick 3" {
+		t.Errorf("got events %s", got)
+	}
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"software.sslmate.com/src/go-pkcs12"
+
+	"postty/src/types"
+)
+
+// tlsVersions maps the min_version setting to its TLS version
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// MergeTLS returns the global settings with those of a request on top: the request's
+// certificate, server name and minimum version replace the global ones, its CA files are
+// trusted as well and either can skip verification
+func MergeTLS(global, request types.TLSSettings) types.TLSSettings {
+	merged := global
+	if request.CertFile != "" {
+		merged.CertFile = request.CertFile
+		merged.KeyFile = request.KeyFile
+		merged.CertPassword = request.CertPassword
+	}
+	merged.CAFiles = append(append([]string{}, global.CAFiles...), request.CAFiles...)
+	merged.Insecure = global.Insecure || request.Insecure
+	if request.ServerName != "" {
+		merged.ServerName = request.ServerName
+	}
+	if request.MinVersion != "" {
+		merged.MinVersion = request.MinVersion
+	}
+	return merged
+}
+
+// mapTLSText applies replace to the paths, password and server name of settings
+func mapTLSText(settings types.TLSSettings, replace func(string) string) types.TLSSettings {
+	settings.CertFile = replace(settings.CertFile)
+	settings.KeyFile = replace(settings.KeyFile)
+	settings.CertPassword = replace(settings.CertPassword)
+	settings.ServerName = replace(settings.ServerName)
+	if settings.CAFiles != nil {
+		caFiles := make([]string, len(settings.CAFiles))
+		for i, path := range settings.CAFiles {
+			caFiles[i] = replace(path)
+		}
+		settings.CAFiles = caFiles
+	}
+	return settings
+}
+
+// TLSConfig builds the client TLS configuration for settings. The extra CA files are
+// trusted along with the system roots.
+func TLSConfig(settings types.TLSSettings) (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: settings.Insecure,
+		ServerName:         settings.ServerName,
+	}
+
+	if settings.MinVersion != "" {
+		version, ok := tlsVersions[settings.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("tls: unknown minimum version %q, use 1.0, 1.1, 1.2 or 1.3", settings.MinVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if len(settings.CAFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, path := range settings.CAFiles {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("tls: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("tls: no PEM certificates in %s", path)
+			}
+		}
+		config.RootCAs = pool
+	}
+
+	if settings.CertFile != "" {
+		cert, err := clientCertificate(settings)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// clientCertificate loads the client certificate of settings. A CertFile that is not PEM is
+// read as PKCS#12; a PEM one takes its key from KeyFile, or from itself when KeyFile is empty.
+func clientCertificate(settings types.TLSSettings) (tls.Certificate, error) {
+	data, err := os.ReadFile(settings.CertFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("tls: %w", err)
+	}
+
+	if block, _ := pem.Decode(data); block == nil {
+		key, leaf, chain, err := pkcs12.DecodeChain(data, settings.CertPassword)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("tls: reading %s as PKCS#12: %w", settings.CertFile, err)
+		}
+		cert := tls.Certificate{PrivateKey: key, Leaf: leaf, Certificate: [][]byte{leaf.Raw}}
+		for _, ca := range chain {
+			cert.Certificate = append(cert.Certificate, ca.Raw)
+		}
+		return cert, nil
+	}
+
+	keyData := data
+	if settings.KeyFile != "" {
+		if keyData, err = os.ReadFile(settings.KeyFile); err != nil {
+			return tls.Certificate{}, fmt.Errorf("tls: %w", err)
+		}
+	}
+	if !bytes.Contains(keyData, []byte("PRIVATE KEY")) {
+		return tls.Certificate{}, fmt.Errorf("tls: no private key for %s, set key_file", settings.CertFile)
+	}
+	cert, err := tls.X509KeyPair(data, keyData)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("tls: %w", err)
+	}
+	return cert, nil
+}
+
+// tlsInfo describes a TLS connection for the response metadata, nil for plain text
+func tlsInfo(state *tls.ConnectionState) *types.TLSInfo {
+	if state == nil {
+		return nil
+	}
+	info := &types.TLSInfo{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+	}
+	for _, cert := range state.PeerCertificates {
+		info.PeerCertificates = append(info.PeerCertificates, types.CertificateInfo{
+			Subject:  cert.Subject.String(),
+			Issuer:   cert.Issuer.String(),
+			NotAfter: cert.NotAfter,
+			DNSNames: cert.DNSNames,
+		})
+	}
+	return info
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+
+	"postty/src/types"
+)
+
+// testCertificate is a certificate along with its key
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCertificate creates a certificate for name signed by parent, or self-signed as a CA
+func newTestCertificate(t *testing.T, name string, parent *testCertificate) testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		DNSNames:              []string{name},
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return testCertificate{cert, key}
+}
+
+// certPEM and keyPEM encode a test certificate and its key
+func (c testCertificate) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+}
+
+func (c testCertificate) keyPEM() []byte {
+	der, _ := x509.MarshalECPrivateKey(c.key)
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func TestTLSSettings(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificate(t, "Test CA", nil)
+	serverCert := newTestCertificate(t, "localhost", &ca)
+	client := newTestCertificate(t, "client", &ca)
+
+	file := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, data, 0o600)
+		return path
+	}
+	caFile := file("ca.pem", ca.certPEM())
+	combined := file("client.pem", append(client.certPEM(), client.keyPEM()...))
+	certOnly := file("client-only.pem", client.certPEM())
+	keyFile := file("client.key", client.keyPEM())
+	p12, err := pkcs12.Modern.Encode(client.key, client.cert, []*x509.Certificate{ca.cert}, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p12File := file("client.p12", p12)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		who := "anonymous"
+		if len(r.TLS.PeerCertificates) > 0 {
+			who = r.TLS.PeerCertificates[0].Subject.CommonName
+		}
+		w.Write([]byte(who))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.cert.Raw, ca.cert.Raw}, PrivateKey: serverCert.key}},
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	url := "https://localhost:" + port + "/"
+
+	tests := []struct {
+		name     string
+		settings types.TLSSettings
+		body     string
+		err      string
+	}{
+		{"system roots", types.TLSSettings{}, "", "certificate"},
+		{"insecure", types.TLSSettings{Insecure: true}, "anonymous", ""},
+		{"CA file", types.TLSSettings{CAFiles: []string{caFile}}, "anonymous", ""},
+		{"PEM with its key", types.TLSSettings{CAFiles: []string{caFile}, CertFile: combined}, "client", ""},
+		{"PEM and key file", types.TLSSettings{CAFiles: []string{caFile}, CertFile: certOnly, KeyFile: keyFile}, "client", ""},
+		{"PEM without a key", types.TLSSettings{CAFiles: []string{caFile}, CertFile: certOnly}, "", "set key_file"},
+		{"PKCS#12", types.TLSSettings{CAFiles: []string{caFile}, CertFile: p12File, CertPassword: "s3cret"}, "client", ""},
+		{"PKCS#12 wrong password", types.TLSSettings{CAFiles: []string{caFile}, CertFile: p12File, CertPassword: "nope"}, "", "PKCS#12"},
+		{"other server name", types.TLSSettings{CAFiles: []string{caFile}, ServerName: "other.test"}, "", "other.test"},
+		{"unknown minimum version", types.TLSSettings{MinVersion: "1.4"}, "", "unknown minimum version"},
+	}
+	for _, tt := range tests {
+		msg := ExecuteRequest(context.Background(), types.Request{Method: "GET", URL: url, TLS: tt.settings, NoCookies: true})().(types.ResponseMsg)
+		if tt.err != "" {
+			if msg.Err == nil || !strings.Contains(msg.Err.Error(), tt.err) {
+				t.Errorf("%s: got %v, want an error with %q", tt.name, msg.Err, tt.err)
+			}
+			continue
+		}
+		if msg.Err != nil || msg.Body != tt.body {
+			t.Errorf("%s: got %q, %v", tt.name, msg.Body, msg.Err)
+			continue
+		}
+		if info := msg.Meta.TLS; info == nil || info.Version != "TLS 1.3" || len(info.PeerCertificates) != 2 || info.PeerCertificates[0].Issuer != "CN=Test CA" {
+			t.Errorf("%s: got TLS info %+v", tt.name, info)
+		}
+	}
+}
+
+func TestMergeTLS(t *testing.T) {
+	global := types.TLSSettings{CertFile: "g.pem", KeyFile: "g.key", CAFiles: []string{"a.pem"}, ServerName: "g.test", MinVersion: "1.2"}
+	merged := MergeTLS(global, types.TLSSettings{CertFile: "r.p12", CertPassword: "pw", CAFiles: []string{"b.pem"}, Insecure: true})
+	want := types.TLSSettings{CertFile: "r.p12", CertPassword: "pw", CAFiles: []string{"a.pem", "b.pem"}, Insecure: true, ServerName: "g.test", MinVersion: "1.2"}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("got %+v", merged)
+	}
+	if len(global.CAFiles) != 1 {
+		t.Error("MergeTLS changed the global CA files")
+	}
+	if merged := MergeTLS(global, types.TLSSettings{}); !reflect.DeepEqual(merged, global) {
+		t.Errorf("got %+v without request settings", merged)
+	}
+}
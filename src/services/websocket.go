@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gorilla/websocket"
+
+	"postty/src/types"
+)
+
+// webSocketCloseWait is how long Close waits for the server to answer the close frame
+const webSocketCloseWait = 3 * time.Second
+
+// webSocketSession implements types.WebSocketSession on top of a gorilla connection
+type webSocketSession struct {
+	conn     *websocket.Conn
+	messages chan types.WebSocketMessage
+	writeMu  sync.Mutex
+	closing  bool
+}
+
+// ConnectWebSocket creates a command that opens a WebSocket connection to the request URL,
+// sending its custom headers with the handshake. Failures are reported as a ResponseMsg.
+func ConnectWebSocket(ctx context.Context, req types.Request) tea.Cmd {
+	return func() tea.Msg {
+		header := http.Header{}
+		for _, h := range req.Headers {
+			if h.Key != "" && h.Value != "" {
+				header.Set(h.Key, h.Value)
+			}
+		}
+
+		dialer := *websocket.DefaultDialer
+		tlsConfig, err := TLSConfig(req.TLS)
+		if err != nil {
+			return types.ResponseMsg{Err: err, Outcome: types.OutcomeError}
+		}
+		dialer.TLSClientConfig = tlsConfig
+		choose, err := proxyFunc(req.Proxy)
+		if err != nil {
+			return types.ResponseMsg{Err: err, Outcome: types.OutcomeError}
+		}
+		dialer.Proxy = func(r *http.Request) (*url.URL, error) { return choose(r.URL) }
+		if !req.NoCookies {
+			dialer.Jar = sessionCookies
+		}
+		if req.Timeout > 0 {
+			dialer.HandshakeTimeout = time.Duration(req.Timeout) * time.Second
+		}
+
+		start := time.Now()
+		conn, resp, err := dialer.DialContext(ctx, WebSocketURL(req.URL), header)
+		if err != nil {
+			if resp != nil && errors.Is(err, websocket.ErrBadHandshake) {
+				err = fmt.Errorf("%w: server answered %s", err, resp.Status)
+			}
+			return types.ResponseMsg{Err: err, Outcome: errorOutcome(ctx, err)}
+		}
+
+		session := &webSocketSession{conn: conn, messages: make(chan types.WebSocketMessage, 64)}
+		go session.read()
+
+		return types.WebSocketConnectedMsg{
+			Session:    session,
+			StatusCode: resp.StatusCode,
+			Headers:    headerList(resp.Header),
+			Meta: types.ResponseMeta{
+				Status:        resp.Status,
+				Proto:         resp.Proto,
+				Duration:      time.Since(start),
+				ContentLength: -1,
+				TLS:           webSocketTLS(conn),
+				Proxy:         usedProxy(req.Proxy, resp.Request.URL),
+			},
+		}
+	}
+}
+
+// WaitWebSocket creates a command that delivers the next log entry of session
+func WaitWebSocket(session types.WebSocketSession) tea.Cmd {
+	return func() tea.Msg {
+		message, ok := <-session.Messages()
+		if !ok {
+			return types.WebSocketClosedMsg{Session: session}
+		}
+		return types.WebSocketMessageMsg{Session: session, Message: message}
+	}
+}
+
+// SendWebSocket creates a command that writes one frame and reports it as a log entry
+func SendWebSocket(session types.WebSocketSession, binary bool, data []byte) tea.Cmd {
+	return func() tea.Msg {
+		message := types.WebSocketMessage{Time: time.Now(), Direction: types.WebSocketSent, Binary: binary, Data: string(data)}
+		if err := session.Send(binary, data); err != nil {
+			message = types.WebSocketMessage{Time: time.Now(), Direction: types.WebSocketEvent, Data: fmt.Sprintf("Send failed: %v", err)}
+		}
+		return types.WebSocketSentMsg{Session: session, Message: message}
+	}
+}
+
+// CloseWebSocket creates a command that starts the closing handshake with code
+func CloseWebSocket(session types.WebSocketSession, code int) tea.Cmd {
+	return func() tea.Msg {
+		text := "Sent close " + closeCodeText(code, "")
+		if err := session.Close(code, ""); err != nil {
+			text = fmt.Sprintf("Close failed: %v", err)
+		}
+		return types.WebSocketSentMsg{Session: session, Message: types.WebSocketMessage{Time: time.Now(), Direction: types.WebSocketEvent, Data: text}}
+	}
+}
+
+// webSocketTLS describes the TLS connection under conn, nil for ws:// URLs
+func webSocketTLS(conn *websocket.Conn) *types.TLSInfo {
+	tlsConn, ok := conn.NetConn().(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	state := tlsConn.ConnectionState()
+	return tlsInfo(&state)
+}
+
+// WebSocketURL maps http and https URLs to their ws and wss equivalents
+func WebSocketURL(rawURL string) string {
+	switch {
+	case strings.HasPrefix(rawURL, "http://"):
+		return "ws://" + strings.TrimPrefix(rawURL, "http://")
+	case strings.HasPrefix(rawURL, "https://"):
+		return "wss://" + strings.TrimPrefix(rawURL, "https://")
+	case !strings.Contains(rawURL, "://"):
+		return "ws://" + rawURL
+	}
+	return rawURL
+}
+
+// WebSocketPayload converts the body into frame data. Binary frames are written as hex
+// digits in the body, whitespace allowed, so arbitrary bytes can be typed.
+func WebSocketPayload(body string, binary bool) ([]byte, error) {
+	if !binary {
+		return []byte(body), nil
+	}
+	digits := strings.Join(strings.Fields(body), "")
+	data, err := hex.DecodeString(digits)
+	if err != nil {
+		return nil, fmt.Errorf("binary frames are written as hex bytes, e.g. \"de ad be ef\": %w", err)
+	}
+	return data, nil
+}
+
+// Messages implements types.WebSocketSession
+func (s *webSocketSession) Messages() <-chan types.WebSocketMessage {
+	return s.messages
+}
+
+// Send implements types.WebSocketSession
+func (s *webSocketSession) Send(binary bool, data []byte) error {
+	messageType := websocket.TextMessage
+	if binary {
+		messageType = websocket.BinaryMessage
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteMessage(messageType, data)
+}
+
+// Close implements types.WebSocketSession. It sends a close frame and lets the reader
+// finish when the server answers, dropping the connection if it does not in time.
+func (s *webSocketSession) Close(code int, reason string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if s.closing {
+		return nil
+	}
+	s.closing = true
+
+	err := s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(webSocketCloseWait))
+	if err != nil {
+		s.conn.Close()
+		return err
+	}
+
+	time.AfterFunc(webSocketCloseWait, func() { s.conn.Close() })
+	return nil
+}
+
+// read forwards received frames to the message channel until the connection ends
+func (s *webSocketSession) read() {
+	defer close(s.messages)
+	defer s.conn.Close()
+
+	for {
+		messageType, data, err := s.conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			text := fmt.Sprintf("Connection lost: %v", err)
+			switch {
+			case errors.As(err, &closeErr):
+				text = "Connection closed with " + closeCodeText(closeErr.Code, closeErr.Text)
+			case s.isClosing():
+				text = "Connection closed"
+			}
+			s.messages <- types.WebSocketMessage{Time: time.Now(), Direction: types.WebSocketEvent, Data: text}
+			return
+		}
+
+		s.messages <- types.WebSocketMessage{
+			Time:      time.Now(),
+			Direction: types.WebSocketReceived,
+			Binary:    messageType == websocket.BinaryMessage,
+			Data:      string(data),
+		}
+	}
+}
+
+// isClosing reports whether Close has been called
+func (s *webSocketSession) isClosing() bool {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.closing
+}
+
+// closeCodeText describes a close code such as "1000 (Normal Closure)"
+func closeCodeText(code int, reason string) string {
+	text := fmt.Sprintf("%d", code)
+	for _, known := range types.WebSocketCloseCodes {
+		if known.Code == code {
+			text += " (" + known.Name + ")"
+			break
+		}
+	}
+	if reason != "" {
+		text += ": " + reason
+	}
+	return text
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"postty/src/types"
+)
+
+// echoWebSocketServer greets each connection with its X-Token header and echoes every frame
+func echoWebSocketServer() *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteMessage(websocket.TextMessage, []byte("hello "+r.Header.Get("X-Token")))
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(messageType, append([]byte("echo:"), data...))
+		}
+	}))
+}
+
+func TestWebSocketSession(t *testing.T) {
+	server := echoWebSocketServer()
+	defer server.Close()
+
+	req := types.Request{URL: server.URL, Headers: []types.Header{{Key: "X-Token", Value: "abc"}}, NoCookies: true}
+	connected, ok := ConnectWebSocket(context.Background(), req)().(types.WebSocketConnectedMsg)
+	if !ok {
+		t.Fatal("the handshake failed")
+	}
+	if connected.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("got status %d", connected.StatusCode)
+	}
+	session := connected.Session
+
+	next := func() types.WebSocketMessage {
+		t.Helper()
+		msg, ok := WaitWebSocket(session)().(types.WebSocketMessageMsg)
+		if !ok {
+			t.Fatal("the connection ended early")
+		}
+		return msg.Message
+	}
+	if got := next(); got.Direction != types.WebSocketReceived || got.Data != "hello abc" {
+		t.Errorf("got %+v", got)
+	}
+
+	sent := SendWebSocket(session, false, []byte(`{"a":1}`))().(types.WebSocketSentMsg)
+	if sent.Message.Direction != types.WebSocketSent || sent.Message.Data != `{"a":1}` {
+		t.Errorf("got %+v", sent.Message)
+	}
+	if got := next(); got.Binary || got.Data != `echo:{"a":1}` {
+		t.Errorf("got %+v", got)
+	}
+
+	payload, err := WebSocketPayload("de ad\nbe ef", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SendWebSocket(session, true, payload)()
+	if got := next(); !got.Binary || got.Data != "echo:\xde\xad\xbe\xef" {
+		t.Errorf("got %+v", got)
+	}
+
+	closing := CloseWebSocket(session, websocket.CloseNormalClosure)().(types.WebSocketSentMsg)
+	if !strings.HasPrefix(closing.Message.Data, "Sent close 1000") {
+		t.Errorf("got %+v", closing.Message)
+	}
+	if got := next(); got.Direction != types.WebSocketEvent || !strings.HasPrefix(got.Data, "Connection closed") {
+		t.Errorf("got %+v", got)
+	}
+	if _, ok := WaitWebSocket(session)().(types.WebSocketClosedMsg); !ok {
+		t.Error("the session did not end after closing")
+	}
+}
+
+func TestWebSocketHandshakeFailure(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	msg, ok := ConnectWebSocket(context.Background(), types.Request{URL: server.URL, NoCookies: true})().(types.ResponseMsg)
+	if !ok || msg.Err == nil || !strings.Contains(msg.Err.Error(), "404 Not Found") {
+		t.Errorf("got %+v", msg)
+	}
+}
+
+func TestWebSocketURLAndPayload(t *testing.T) {
+	for url, want := range map[string]string{
+		"http://a.test/ws":  "ws://a.test/ws",
+		"https://a.test/ws": "wss://a.test/ws",
+		"a.test/ws":         "ws://a.test/ws",
+		"wss://a.test/ws":   "wss://a.test/ws",
+	} {
+		if got := WebSocketURL(url); got != want {
+			t.Errorf("%s: got %s", url, got)
+		}
+	}
+
+	if data, err := WebSocketPayload("de ad", false); err != nil || string(data) != "de ad" {
+		t.Errorf("got %q, %v for a text frame", data, err)
+	}
+	if _, err := WebSocketPayload("xyz", true); err == nil {
+		t.Error("expected an error for a binary frame that is not hex")
+	}
+}
@@ -3,6 +3,26 @@ package types
 // HTTPMethods contains all supported HTTP methods
 var HTTPMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
 
+// WebSocketMethod is the Method pane entry that opens a WebSocket connection
+const WebSocketMethod = "WS"
+
+// GRPCMethod is the Method pane entry that calls a gRPC method
+const GRPCMethod = "GRPC"
+
+// Methods lists the Method pane entries: the HTTP methods followed by the other protocols
+var Methods = append(append([]string{}, HTTPMethods...), WebSocketMethod, GRPCMethod)
+
+// WebSocketCloseCodes are the close codes offered when closing a WebSocket connection
+var WebSocketCloseCodes = []WebSocketCloseCode{
+	{Code: 1000, Name: "Normal Closure"},
+	{Code: 1001, Name: "Going Away"},
+	{Code: 1002, Name: "Protocol Error"},
+	{Code: 1003, Name: "Unsupported Data"},
+	{Code: 1008, Name: "Policy Violation"},
+	{Code: 1011, Name: "Internal Error"},
+	{Code: 4000, Name: "Application"},
+}
+
 // ContentTypes contains all supported content types
 var ContentTypes = []string{
 	"application/json",
@@ -10,6 +30,7 @@ var ContentTypes = []string{
 	"text/plain",
 	"application/x-www-form-urlencoded",
 	"multipart/form-data",
+	"application/graphql",
 }
 
 // HeaderTemplates contains predefined header templates
@@ -22,3 +43,31 @@ var HeaderTemplates = []HeaderTemplate{
 	{Name: "Accept", Key: "Accept", Placeholder: "application/json"},
 	{Name: "Custom Header", Key: "", Placeholder: ""},
 }
+
+// DefaultRequestTimeout is the request timeout in seconds used when the config does not say otherwise
+const DefaultRequestTimeout = 30
+
+// RequestTimeoutStep is how many seconds each adjustment of the per-request timeout changes it by
+const RequestTimeoutStep = 5
+
+// DefaultHistoryLimit is the number of history items kept when the config does not say otherwise
+const DefaultHistoryLimit = 50
+
+// MaxHistoryBodySize is the longest response body, in bytes, kept in the history log
+const MaxHistoryBodySize = 1 << 20
+
+// DefaultCollectionsDir is where collections are stored, relative to the working directory,
+// so they can be committed alongside the service they exercise
+const DefaultCollectionsDir = ".postty/collections"
+
+// DefaultEnvironmentsDir is where environment files are read from, relative to the working directory
+const DefaultEnvironmentsDir = ".postty/environments"
+
+// DefaultSequencesDir is where requests exported from history for the runner are written
+const DefaultSequencesDir = ".postty/sequences"
+
+// MaxSSEEvents is the number of most recent events of a stream kept in the event list
+const MaxSSEEvents = 1000
+
+// MaxURLLength is the longest URL the URL input accepts
+const MaxURLLength = 8192
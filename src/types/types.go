@@ -1,6 +1,9 @@
 package types
 
 import (
+	"context"
+	"time"
+
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -17,6 +20,8 @@ const (
 	ResponsePane
 	HeadersPane
 	HistoryPane
+	CollectionsPane
+	EnvironmentsPane
 )
 
 // HeadersMode represents the current mode of the headers pane
@@ -30,8 +35,26 @@ const (
 
 // Header represents a custom HTTP header
 type Header struct {
-	Key   string
-	Value string
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// QueryParam represents a single query string parameter of the URL
+type QueryParam struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"` // Kept in the list but left out of the URL
+	Equals   bool   `json:"equals,omitempty"`   // Written as "key=" although the value is empty
+	Raw      string `json:"raw,omitempty"`      // The pair as typed, when it is not valid percent-encoding
+}
+
+// FormField represents a field of a urlencoded or multipart body
+type FormField struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`                  // Text value, or the file path of a file part
+	File        bool   `json:"file,omitempty"`         // Value names a local file to upload
+	ContentType string `json:"content_type,omitempty"` // Part content type, multipart only
+	Disabled    bool   `json:"disabled,omitempty"`
 }
 
 // HeaderTemplate represents a template for creating headers
@@ -41,16 +64,492 @@ type HeaderTemplate struct {
 	Placeholder string
 }
 
+// GraphQLEditor represents the editor shown in the body pane for GraphQL requests
+type GraphQLEditor int
+
+const (
+	GraphQLQueryEditor GraphQLEditor = iota
+	GraphQLVariablesEditor
+	GraphQLOperationEditor
+)
+
+// BodyTab represents the editor shown in the body pane
+type BodyTab int
+
+const (
+	RequestBodyTab BodyTab = iota
+	PreScriptTab
+	PostScriptTab
+)
+
+// HeadersPaneTab represents the list shown in the headers pane
+type HeadersPaneTab int
+
+const (
+	CustomHeadersTab HeadersPaneTab = iota
+	QueryParamsTab
+	AuthTab
+	AssertionsTab
+)
+
+// ResponseTab represents the view shown in the response pane
+type ResponseTab int
+
+const (
+	ResponseBodyTab ResponseTab = iota
+	ResponseHeadersTab
+	ResponseInfoTab
+	ResponseTestsTab
+	ResponseConsoleTab
+)
+
+// CollectionsMode represents the current mode of the collections pane
+type CollectionsMode int
+
+const (
+	CollectionsViewMode CollectionsMode = iota
+	CollectionsSaveMode
+	CollectionsFolderMode
+)
+
+// RequestOutcome describes how a request finished
+type RequestOutcome string
+
+const (
+	OutcomeCompleted RequestOutcome = ""
+	OutcomeError     RequestOutcome = "error"
+	OutcomeCancelled RequestOutcome = "cancelled"
+	OutcomeTimedOut  RequestOutcome = "timeout"
+)
+
+// Request holds everything needed to send an HTTP request from the form
+type Request struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	Body        string       `json:"body,omitempty"`
+	ContentType string       `json:"content_type"`
+	Headers     []Header     `json:"headers,omitempty"`
+	Timeout     int          `json:"timeout,omitempty"` // Seconds, 0 uses the configured default
+	Params      []QueryParam `json:"params,omitempty"`  // Full param list, only stored when some are disabled
+	Form        []FormField  `json:"form,omitempty"`    // Fields sent instead of Body for form content types
+
+	// GraphQL requests send Body as the query, along with these
+	GraphQLVariables string `json:"graphql_variables,omitempty"` // JSON object
+	GraphQLOperation string `json:"graphql_operation,omitempty"` // operationName
+
+	// JavaScript run before the request is sent and after its response arrives
+	PreScript  string `json:"pre_script,omitempty"`
+	PostScript string `json:"post_script,omitempty"`
+
+	Assertions []Assertion `json:"assertions,omitempty"` // Checked against the response
+
+	Auth Auth        `json:"auth,omitzero"` // Credentials applied when the request is sent
+	TLS  TLSSettings `json:"tls,omitzero"`  // Added to the configured TLS settings
+
+	Proxy ProxySettings `json:"-"` // Taken from the config when the request is sent
+
+	NoCookies bool `json:"no_cookies,omitempty"` // Send no cookies from the jar and keep none the response sets
+
+	Redirects RedirectPolicy `json:"redirects,omitzero"` // Which redirects are followed
+}
+
+// DefaultMaxRedirects is how many redirects a request follows unless its policy says otherwise
+const DefaultMaxRedirects = 10
+
+// RedirectPolicy says which redirects a request follows. 307 and 308 redirects resend the
+// method and body; 301 and 302 turn them into a GET unless KeepMethod is set, 303 always does.
+type RedirectPolicy struct {
+	NoFollow   bool `json:"no_follow,omitempty"`   // Return the first redirect response as is
+	Max        int  `json:"max,omitempty"`         // Redirects followed at most, 0 for DefaultMaxRedirects
+	KeepMethod bool `json:"keep_method,omitempty"` // Resend the method and body on 301 and 302 too
+}
+
+// RedirectHop is a redirect response a request followed on its way to the final response
+type RedirectHop struct {
+	Method     string        `json:"method"`
+	URL        string        `json:"url"`
+	StatusCode int           `json:"status_code"`
+	Status     string        `json:"status"`   // Status line text, e.g. "301 Moved Permanently"
+	Location   string        `json:"location"` // Where the redirect pointed, resolved against URL
+	Duration   time.Duration `json:"duration"` // From sending the request to the redirect's headers
+}
+
+// AuthType names how a request authenticates
+type AuthType string
+
+const (
+	AuthNone   AuthType = ""
+	AuthBasic  AuthType = "basic"  // Username and password in an Authorization: Basic header
+	AuthBearer AuthType = "bearer" // Token in an Authorization: Bearer header
+	AuthAPIKey AuthType = "apikey" // Value sent in the header or query param named Key
+	AuthDigest AuthType = "digest" // Username and password answering the server's Digest challenge
+	AuthHMAC   AuthType = "hmac"   // Signature of the request made with Secret
+	AuthOAuth2 AuthType = "oauth2" // Bearer token fetched from TokenURL with Grant
+	AuthSigV4  AuthType = "sigv4"  // AWS Signature Version 4 made with AccessKey and SecretKey
+)
+
+// AuthTypes lists the auth types in the order the Auth tab cycles through them
+var AuthTypes = []AuthType{AuthNone, AuthBasic, AuthBearer, AuthAPIKey, AuthDigest, AuthHMAC, AuthOAuth2, AuthSigV4}
+
+// Auth holds the credentials of a request. Which fields are used depends on Type.
+type Auth struct {
+	Type      AuthType `json:"type,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	Password  string   `json:"password,omitempty"`
+	Token     string   `json:"token,omitempty"`
+	Key       string   `json:"key,omitempty"`       // API key header or param name, HMAC key ID
+	Value     string   `json:"value,omitempty"`     // API key
+	In        string   `json:"in,omitempty"`        // Where the API key goes: "header" or "query"
+	Secret    string   `json:"secret,omitempty"`    // HMAC signing secret
+	Algorithm string   `json:"algorithm,omitempty"` // HMAC hash: sha256, sha1 or sha512
+	Header    string   `json:"header,omitempty"`    // Header the HMAC signature is sent in
+
+	// OAuth2
+	Grant        string `json:"grant,omitempty"` // client_credentials, password or authorization_code
+	TokenURL     string `json:"token_url,omitempty"`
+	AuthURL      string `json:"auth_url,omitempty"`     // Authorization endpoint of the code grant
+	RedirectURL  string `json:"redirect_url,omitempty"` // Loopback address the code grant listens on
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+
+	// AWS Signature Version 4
+	AccessKey    string `json:"access_key,omitempty"`
+	SecretKey    string `json:"secret_key,omitempty"`
+	SessionToken string `json:"session_token,omitempty"` // Temporary credentials only
+	Region       string `json:"region,omitempty"`
+	Service      string `json:"service,omitempty"` // Signing name, e.g. execute-api or s3
+}
+
+// TLSSettings configure the TLS connections of HTTPS, WebSocket and gRPC requests
+type TLSSettings struct {
+	CertFile     string   `json:"cert_file,omitempty"`     // Client certificate, PEM or PKCS#12 (.p12, .pfx)
+	KeyFile      string   `json:"key_file,omitempty"`      // PEM private key, when not in CertFile
+	CertPassword string   `json:"cert_password,omitempty"` // Password of a PKCS#12 CertFile
+	CAFiles      []string `json:"ca_files,omitempty"`      // PEM bundles trusted on top of the system roots
+	Insecure     bool     `json:"insecure,omitempty"`      // Skip verifying the server certificate
+	ServerName   string   `json:"server_name,omitempty"`   // SNI and verified name, instead of the URL host
+	MinVersion   string   `json:"min_version,omitempty"`   // Lowest version offered: 1.0, 1.1, 1.2 or 1.3
+}
+
+// ProxySettings choose the proxy HTTP and WebSocket requests go through. When no rule
+// matches and URL is empty, HTTP_PROXY, HTTPS_PROXY and NO_PROXY from the environment apply.
+type ProxySettings struct {
+	URL     string      `json:"url,omitempty"`      // http://, https:// or socks5:// proxy, user:password@ for auth, or "direct"
+	NoProxy string      `json:"no_proxy,omitempty"` // Comma-separated hosts, domains and CIDRs reached without URL
+	Rules   []ProxyRule `json:"rules,omitempty"`    // Tried in order before URL
+}
+
+// ProxyRule sends the requests to matching hosts through a proxy of their own
+type ProxyRule struct {
+	Hosts []string `json:"hosts"` // Host names, which match their subdomains too, *.domain, IPs or CIDRs
+	URL   string   `json:"url"`   // Proxy as in ProxySettings.URL; "direct" reaches the hosts without one
+}
+
+// Cookie is a cookie kept in the cookie jar
+type Cookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain"`
+	Path     string    `json:"path"`
+	Expires  time.Time `json:"expires,omitzero"` // Zero for a session cookie
+	Secure   bool      `json:"secure,omitempty"`
+	HTTPOnly bool      `json:"http_only,omitempty"`
+	HostOnly bool      `json:"host_only,omitempty"` // Sent to Domain only, not to its subdomains
+	SameSite string    `json:"same_site,omitempty"`
+}
+
+// TLSInfo describes the TLS connection a response came over
+type TLSInfo struct {
+	Version          string            `json:"version"`
+	CipherSuite      string            `json:"cipher_suite"`
+	ServerName       string            `json:"server_name,omitempty"`
+	PeerCertificates []CertificateInfo `json:"peer_certificates,omitempty"` // Leaf first
+}
+
+// CertificateInfo summarizes a certificate of the server's chain
+type CertificateInfo struct {
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"not_after"`
+	DNSNames []string  `json:"dns_names,omitempty"`
+}
+
+// OAuth2Token is an access token fetched from a token endpoint
+type OAuth2Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	Scope        string
+	Expiry       time.Time // Zero when the token does not expire
+}
+
+// OAuth2Login is an authorization code sign-in waiting for the browser to come back to
+// its loopback redirect
+type OAuth2Login interface {
+	URL() string                                   // Where the user signs in
+	Wait(ctx context.Context) (OAuth2Token, error) // Exchanges the code once it arrives
+	Close()                                        // Stops waiting
+}
+
+// AssertionKind names what an assertion checks
+type AssertionKind string
+
+const (
+	AssertStatus       AssertionKind = "status"        // Status code equals Value
+	AssertHeader       AssertionKind = "header"        // Header Target matches the Value regex
+	AssertJSONEquals   AssertionKind = "json_equals"   // Value at the JSONPath Target equals Value
+	AssertJSONContains AssertionKind = "json_contains" // Value at the JSONPath Target contains Value
+	AssertTime         AssertionKind = "time"          // Response time is under Value milliseconds
+	AssertBody         AssertionKind = "body"          // Body matches the Value regex
+)
+
+// Assertion is a check run against the response of a request
+type Assertion struct {
+	Kind     AssertionKind `json:"kind"`
+	Target   string        `json:"target,omitempty"` // Header name or JSONPath
+	Value    string        `json:"value"`
+	Disabled bool          `json:"disabled,omitempty"`
+}
+
+// AssertionResult is the outcome of checking an assertion against a response
+type AssertionResult struct {
+	Assertion string `json:"assertion"` // As written, e.g. "status == 200"
+	Passed    bool   `json:"passed"`
+	Message   string `json:"message,omitempty"` // What was found instead, for failures
+}
+
+// Sequence is an ordered list of requests replayed by the runner
+type Sequence struct {
+	Name     string    `json:"name"`
+	Requests []Request `json:"requests"`
+}
+
+// RunResult is the outcome of one request of a sequence run
+type RunResult struct {
+	Iteration   int // Data row the request ran with, counted from 1
+	Step        int // Position of the request in the sequence, counted from 1
+	Method      string
+	URL         string // As sent, with variables resolved
+	StatusCode  int
+	Duration    time.Duration
+	Outcome     RequestOutcome
+	Failures    []string // Why the step failed; empty when it passed
+	TestResults []AssertionResult
+}
+
+// RunReport summarises a sequence run
+type RunReport struct {
+	Name       string
+	Started    time.Time
+	Duration   time.Duration
+	Steps      int // Requests in the sequence
+	Iterations int // Data rows, or 1 without a data file
+	Results    []RunResult
+	Cancelled  bool // The run was stopped before every step ran
+}
+
+// SequenceRun is a sequence run in progress
+type SequenceRun interface {
+	Results() <-chan RunResult // Closed once the run ends
+	Report() RunReport         // Valid after Results is closed
+}
+
+// ScriptPhase tells the script that produced a console entry
+type ScriptPhase string
+
+const (
+	PreRequestPhase   ScriptPhase = "pre-request"
+	PostResponsePhase ScriptPhase = "post-response"
+)
+
+// ScriptLogEntry is a line of script output shown in the console
+type ScriptLogEntry struct {
+	Time  time.Time   `json:"time"`
+	Phase ScriptPhase `json:"phase"`
+	Level string      `json:"level"` // "log", "info", "warn" or "error"
+	Text  string      `json:"text"`
+}
+
+// ScriptRun is what running a script produced
+type ScriptRun struct {
+	Log       []ScriptLogEntry
+	Variables map[string]string // Values given to env.set, in effect for the rest of the request
+	Err       error             // Why the script failed, also logged as an error entry
+}
+
+// GRPCMethodInfo describes a method offered by a gRPC server
+type GRPCMethodInfo struct {
+	FullName        string // e.g. "/helloworld.Greeter/SayHello"
+	ClientStreaming bool
+	ServerStreaming bool
+	Template        string // Request message as JSON with every field present
+}
+
+// GRPCReply is a response message of a gRPC call
+type GRPCReply struct {
+	Time    time.Time
+	Message string // JSON
+}
+
+// GRPCResult is how a gRPC call ended
+type GRPCResult struct {
+	Code     string // Status code name, e.g. "OK" or "NOT_FOUND"
+	Number   int    // Status code number
+	Message  string // Status message
+	Trailers []Header
+}
+
+// GRPCCall is a gRPC call whose response messages are being received
+type GRPCCall interface {
+	Replies() <-chan GRPCReply // Closed once the call ends
+	Result() GRPCResult        // Valid after Replies is closed
+}
+
+// GraphQLSchema is what introspection tells about an endpoint, enough to complete fields
+type GraphQLSchema struct {
+	QueryType        string
+	MutationType     string
+	SubscriptionType string
+	Types            map[string][]GraphQLField // Fields of each object and interface type
+}
+
+// GraphQLField is a field of a GraphQL type
+type GraphQLField struct {
+	Name     string
+	Type     string // As written in SDL, e.g. "[User!]!"
+	TypeName string // Named type without list and non-null wrappers, e.g. "User"
+}
+
+// GraphQLCompletion is the completion being cycled through in the query editor
+type GraphQLCompletion struct {
+	Candidates []string
+	Index      int
+	Inserted   string // Text inserted by the last completion, replaced by the next one
+}
+
+// WebSocketDirection tells sent frames, received frames and connection events apart
+type WebSocketDirection string
+
+const (
+	WebSocketSent     WebSocketDirection = "sent"
+	WebSocketReceived WebSocketDirection = "received"
+	WebSocketEvent    WebSocketDirection = "event" // Connected, closed or failed
+)
+
+// WebSocketMessage is one entry of the WebSocket message log
+type WebSocketMessage struct {
+	Time      time.Time
+	Direction WebSocketDirection
+	Binary    bool
+	Data      string
+}
+
+// WebSocketCloseCode is a close status code with its name
+type WebSocketCloseCode struct {
+	Code int
+	Name string
+}
+
+// WebSocketSession is an open WebSocket connection
+type WebSocketSession interface {
+	Send(binary bool, data []byte) error
+	Close(code int, reason string) error
+	Messages() <-chan WebSocketMessage // Received frames and the final close event; closed once the connection is gone
+}
+
+// SSEEvent is one event of a Server-Sent Events stream
+type SSEEvent struct {
+	ID    string
+	Event string // Event type, "message" unless the server names one
+	Data  string
+	Retry int // Reconnection time in milliseconds, 0 when not sent
+	Time  time.Time
+}
+
+// EventStream is a text/event-stream response being read
+type EventStream interface {
+	Events() <-chan SSEEvent // Closed once the stream ends
+	Err() error              // Why the stream ended, nil when the server finished it; valid after Events is closed
+}
+
+// ResponseMeta holds details about a response beyond its status code and body
+type ResponseMeta struct {
+	Status          string        `json:"status"` // Status line text, e.g. "200 OK"
+	Proto           string        `json:"proto"`
+	Duration        time.Duration `json:"duration"`
+	BodySize        int64         `json:"body_size"`      // Bytes of body read, after any transparent decompression
+	ContentLength   int64         `json:"content_length"` // Length announced by the server, -1 if unknown
+	ContentEncoding string        `json:"content_encoding,omitempty"`
+	Decompressed    bool          `json:"decompressed,omitempty"` // Body was transparently decompressed by the client
+	GRPCStatus      string        `json:"grpc_status,omitempty"`  // Status code name of a gRPC call
+	TLS             *TLSInfo      `json:"tls,omitempty"`          // Nil for plain-text connections
+	Proxy           string        `json:"proxy,omitempty"`        // Proxy the request went through, password hidden
+	URL             string        `json:"url,omitempty"`          // URL of the final response, set when redirects were followed
+	Redirects       []RedirectHop `json:"redirects,omitempty"`    // Redirects followed before the final response, in order
+}
+
 // HistoryItem represents a single HTTP request in history
 type HistoryItem struct {
-	Method        string
-	URL           string
-	Body          string
-	ContentType   string
-	Headers       []Header
-	StatusCode    int
-	Timestamp     string
-	ResponseBody  string
+	Request
+	StatusCode      int               `json:"status_code"`
+	Outcome         RequestOutcome    `json:"outcome,omitempty"`
+	Timestamp       string            `json:"timestamp"`
+	ResponseBody    string            `json:"response_body,omitempty"`
+	ResponseHeaders []Header          `json:"response_headers,omitempty"`
+	ResponseMeta    *ResponseMeta     `json:"response_meta,omitempty"`
+	ScriptLog       []ScriptLogEntry  `json:"script_log,omitempty"`
+	TestResults     []AssertionResult `json:"test_results,omitempty"`
+}
+
+// SavedRequest represents a named request stored in a collection
+type SavedRequest struct {
+	Name string `json:"name"`
+	Request
+	Path string `json:"-"` // File the request was read from
+}
+
+// Collection represents a folder of saved requests on disk
+type Collection struct {
+	Name     string
+	Path     string
+	Folders  []Collection
+	Requests []SavedRequest
+}
+
+// CollectionNode is a single visible row of the collections tree
+type CollectionNode struct {
+	Depth    int
+	Folder   *Collection
+	Request  *SavedRequest
+	Expanded bool
+}
+
+// Environment represents a named set of variables substituted into requests
+type Environment struct {
+	Name      string
+	Path      string
+	Variables map[string]string
+}
+
+// Config represents user settings loaded from the config file
+type Config struct {
+	HistoryLimit    int    `json:"history_limit"`
+	RequestTimeout  int    `json:"request_timeout"` // Seconds, 0 disables the timeout
+	CollectionsDir  string `json:"collections_dir"`
+	EnvironmentsDir string `json:"environments_dir"`
+	SequencesDir    string `json:"sequences_dir"`
+
+	// gRPC methods are described by these .proto files, or by server reflection when empty
+	ProtoFiles       []string `json:"proto_files,omitempty"`
+	ProtoImportPaths []string `json:"proto_import_paths,omitempty"`
+
+	TLS TLSSettings `json:"tls,omitzero"` // Used by every request, which may add its own
+
+	Proxy              ProxySettings            `json:"proxy,omitzero"`
+	EnvironmentProxies map[string]ProxySettings `json:"environment_proxies,omitempty"` // By environment name, replacing Proxy while it is active
+
+	PersistCookies bool `json:"persist_cookies,omitempty"` // Keep the cookie jar on disk across sessions
 }
 
 // Model represents the application state
@@ -61,26 +560,219 @@ type Model struct {
 	URLInput             textinput.Model
 	BodyInput            textarea.Model
 	ResponseViewport     viewport.Model
+	ResponseTab          ResponseTab
+	ResponseBody         string // Content of the body tab, including error text
+	ResponseHeaders      []Header
+	ResponseMeta         *ResponseMeta
 	MethodViewport       viewport.Model
 	ContentTypeViewport  viewport.Model
 	StatusCode           int
 	Width                int
 	Height               int
 	Executing            bool
+	CancelRequest        context.CancelFunc // Cancels the request in flight, nil when idle
+	RequestTimeout       int                // Per-request timeout in seconds, 0 uses Config.RequestTimeout
 	CustomHeaders        []Header
 	SelectedCustomHeader int
 	HeadersMode          HeadersMode
+	HeadersPaneTab       HeadersPaneTab
+	QueryParams          []QueryParam // Params of URLInput plus any disabled ones
+	SelectedParam        int
+	Assertions           []Assertion
+	SelectedAssertion    int
+	Auth                 Auth
+	SelectedAuthField    int
+	OAuth2Login          OAuth2Login       // Sign-in in progress, nil when none
+	TLS                  TLSSettings       // TLS settings of the request being edited
+	TestResults          []AssertionResult // Assertions checked against the response shown
 	SelectedTemplate     int
 	HeaderEditInput      textinput.Model
 	History              []HistoryItem
 	SelectedHistory      int
+	MarkedHistory        map[int]bool // Indexes into History chosen for a sequence
 	HistoryViewport      viewport.Model
 	PendingRequest       *HistoryItem // Stores the current request being executed
+	Config               Config
+	HistoryPath          string // Location of the persisted history log, empty to disable
+	HistoryLogEntries    int    // Items in the history log, which runs past the limit until it is compacted
+	StatusMessage        string // Transient message shown next to the help bar
+	CollectionsDir       string // Root directory of the collections tree
+	Collections          Collection
+	CollectionNodes      []CollectionNode // Flattened, visible rows of Collections
+	CollapsedFolders     map[string]bool
+	SelectedCollection   int
+	CollectionsMode      CollectionsMode
+	CollectionNameInput  textinput.Model
+	CollectionsViewport  viewport.Model
+	EnvironmentsDir      string // Directory the environment files are read from
+	Environments         []Environment
+	SelectedEnvironment  int
+	ActiveEnvironment    int // Index into Environments, -1 when no environment is active
+	EnvironmentsViewport viewport.Model
+	FormFields           []FormField // Body fields used for form content types
+	SelectedFormField    int
+	EditingFormField     bool // The form field input is focused
+	FormFieldInput       textinput.Model
+	FormViewport         viewport.Model
+	WebSocket            WebSocketSession // Open connection, nil when disconnected
+	WebSocketLog         []WebSocketMessage
+	WebSocketRequest     *HistoryItem // Recorded in history with the log once the connection ends
+	WebSocketBinary      bool         // Send the body as binary frames instead of text
+	WebSocketCloseCode   int          // Index into WebSocketCloseCodes
+	EventStream          EventStream  // Server-Sent Events response being read, nil otherwise
+	SSEEvents            []SSEEvent
+	SSEDropped           int  // Oldest events of the stream dropped to keep SSEEvents within MaxSSEEvents
+	StreamPaused         bool // The event list stops updating while events keep arriving
+	StreamShown          int  // Number of events received when the list was paused
+	GraphQLEditor        GraphQLEditor
+	GraphQLVariables     textarea.Model
+	GraphQLOperation     textinput.Model
+	GraphQLSchema        *GraphQLSchema // Introspected schema of GraphQLSchemaURL
+	GraphQLSchemaURL     string
+	GraphQLCompletion    *GraphQLCompletion // Completion being cycled, nil when none
+	GRPCCall             GRPCCall           // gRPC call in progress, nil otherwise
+	GRPCReplies          []GRPCReply
+	GRPCMethods          []GRPCMethodInfo // Methods listed for picking in the response pane
+	SelectedGRPCMethod   int
+	BrowsingGRPC         bool     // The response pane lists GRPCMethods
+	Cookies              []Cookie // Cookies listed in the response pane
+	SelectedCookie       int
+	BrowsingCookies      bool            // The response pane lists Cookies
+	EditingCookie        bool            // CookieInput holds a cookie being edited or added
+	EditedCookie         *Cookie         // Cookie replaced by the edit, nil when adding
+	CookieInput          textinput.Model // Cookie in Set-Cookie form
+	NoCookies            bool            // Cookie handling is off for the request being edited
+	Redirects            RedirectPolicy  // Redirects followed by the request being edited
+	EditingMaxRedirects  bool            // MaxRedirectsInput holds the redirect limit being edited
+	MaxRedirectsInput    textinput.Model
+	BodyTab              BodyTab
+	PreScriptInput       textarea.Model
+	PostScriptInput      textarea.Model
+	ScriptLog            []ScriptLogEntry // Console output of the scripts of the last request
+	SequenceRun          SequenceRun      // Sequence being run from history, nil otherwise
+	RunResults           []RunResult
+	ImportingCurl        bool // The body pane shows the cURL import editor
+	CurlInput            textarea.Model
+	Exporting            bool    // The response pane shows the export preview
+	ExportFormat         int     // Index into services.SnippetFormats
+	ExportRequest        Request // Request being exported
+}
+
+// WebSocketConnectedMsg reports a completed WebSocket handshake
+type WebSocketConnectedMsg struct {
+	Session    WebSocketSession
+	StatusCode int
+	Headers    []Header
+	Meta       ResponseMeta
+}
+
+// WebSocketMessageMsg carries a log entry from an open WebSocket connection
+type WebSocketMessageMsg struct {
+	Session WebSocketSession
+	Message WebSocketMessage
+}
+
+// WebSocketSentMsg carries the log entry for a frame sent, or a close started, from the form
+type WebSocketSentMsg struct {
+	Session WebSocketSession
+	Message WebSocketMessage
+}
+
+// WebSocketClosedMsg reports that a WebSocket connection has ended
+type WebSocketClosedMsg struct {
+	Session WebSocketSession
+}
+
+// StreamStartedMsg reports the headers of a Server-Sent Events response whose events follow
+type StreamStartedMsg struct {
+	Stream     EventStream
+	StatusCode int
+	Headers    []Header
+	Meta       ResponseMeta
+}
+
+// SSEEventMsg carries the next event of a stream
+type SSEEventMsg struct {
+	Stream EventStream
+	Event  SSEEvent
+}
+
+// StreamEndedMsg reports that a stream has finished, failed or been stopped
+type StreamEndedMsg struct {
+	Stream  EventStream
+	Outcome RequestOutcome
+	Err     error
+}
+
+// GRPCStartedMsg reports that a gRPC call was sent and its response headers arrived
+type GRPCStartedMsg struct {
+	Call    GRPCCall
+	Headers []Header
+	Meta    ResponseMeta
+}
+
+// GRPCReplyMsg carries the next response message of a gRPC call
+type GRPCReplyMsg struct {
+	Call  GRPCCall
+	Reply GRPCReply
+}
+
+// GRPCEndedMsg reports that a gRPC call has ended; its status is in Call.Result
+type GRPCEndedMsg struct {
+	Call GRPCCall
+}
+
+// GRPCMethodsMsg carries the methods a gRPC server offers
+type GRPCMethodsMsg struct {
+	Methods []GRPCMethodInfo
+	Source  string // Where the methods were found: server reflection or .proto files
+	Err     error
+}
+
+// OAuth2TokenMsg reports a token fetched from the Auth tab, or why it could not be
+type OAuth2TokenMsg struct {
+	Login OAuth2Login // Set when the token came from a sign-in
+	Token OAuth2Token
+	Err   error
+}
+
+// PreScriptMsg carries the request as its pre-request script left it, ready to be sent
+type PreScriptMsg struct {
+	Request Request
+	Run     ScriptRun
+}
+
+// PostScriptMsg reports that the post-response script of the pending request has run
+type PostScriptMsg struct {
+	Response ResponseMsg
+	Run      ScriptRun
+}
+
+// RunStepMsg carries the result of the next request of a sequence run
+type RunStepMsg struct {
+	Run    SequenceRun
+	Result RunResult
+}
+
+// RunEndedMsg reports that a sequence run has finished or been stopped
+type RunEndedMsg struct {
+	Run    SequenceRun
+	Report RunReport
+}
+
+// GraphQLSchemaMsg carries the result of introspecting a GraphQL endpoint
+type GraphQLSchemaMsg struct {
+	URL    string
+	Schema *GraphQLSchema
+	Err    error
 }
 
 // ResponseMsg represents a message containing HTTP response data
 type ResponseMsg struct {
 	Body       string
 	StatusCode int
+	Headers    []Header
+	Meta       ResponseMeta
+	Outcome    RequestOutcome
 	Err        error
 }